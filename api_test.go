@@ -5,7 +5,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
+	"strings"
 	"testing"
 	"time"
 
@@ -131,6 +133,31 @@ func TestEndToEndAPI(t *testing.T) {
 		assert.Equal(t, "healthy", response["status"])
 	})
 
+	t.Run("Register Admin User", func(t *testing.T) {
+		timestamp := time.Now().UnixNano()
+		ts.adminEmail = fmt.Sprintf("admin%d@test.com", timestamp)
+		userData := map[string]interface{}{
+			"name":     "Admin User",
+			"email":    ts.adminEmail,
+			"password": "password123",
+			"role":     "admin", // ignored: registration never grants roles
+		}
+
+		resp, body, err := ts.makeRequest("POST", "/auth/register", userData, "")
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		var response map[string]interface{}
+		err = json.Unmarshal(body, &response)
+		assert.NoError(t, err)
+		assert.Contains(t, response, "token")
+		assert.Contains(t, response, "user")
+
+		ts.adminToken = response["token"].(string)
+		ts.adminUser = response["user"].(map[string]interface{})
+		assert.Equal(t, "member", ts.adminUser["role"])
+	})
+
 	t.Run("Create Organization", func(t *testing.T) {
 		// Use timestamp to make organization name unique
 		timestamp := time.Now().UnixNano()
@@ -141,6 +168,10 @@ func TestEndToEndAPI(t *testing.T) {
 
 		resp, body, err := ts.makeRequest("POST", "/organizations/", orgData, "")
 		assert.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+		resp, body, err = ts.makeRequest("POST", "/organizations/", orgData, ts.adminToken)
+		assert.NoError(t, err)
 		assert.Equal(t, http.StatusCreated, resp.StatusCode)
 
 		var response map[string]interface{}
@@ -152,32 +183,16 @@ func TestEndToEndAPI(t *testing.T) {
 		ts.orgID = uint(org["id"].(float64))
 		ts.organization = org
 		assert.Contains(t, org["name"].(string), "Test Organization")
-	})
 
-	t.Run("Register Admin User", func(t *testing.T) {
-		timestamp := time.Now().UnixNano()
-		ts.adminEmail = fmt.Sprintf("admin%d@test.com", timestamp)
-		userData := map[string]interface{}{
-			"name":            "Admin User",
-			"email":           ts.adminEmail,
-			"password":        "password123",
-			"role":            "admin",
-			"organization_id": ts.orgID,
-		}
-
-		resp, body, err := ts.makeRequest("POST", "/auth/register", userData, "")
+		endpoint := fmt.Sprintf("/organizations/%d/owner", ts.orgID)
+		resp, body, err = ts.makeRequestWithOrgHeader("GET", endpoint, nil, ts.adminToken, ts.orgID)
 		assert.NoError(t, err)
-		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
 
-		var response map[string]interface{}
 		err = json.Unmarshal(body, &response)
 		assert.NoError(t, err)
-		assert.Contains(t, response, "token")
-		assert.Contains(t, response, "user")
-
-		ts.adminToken = response["token"].(string)
-		ts.adminUser = response["user"].(map[string]interface{})
-		assert.Equal(t, "admin", ts.adminUser["role"])
+		owner := response["owner"].(map[string]interface{})
+		assert.Equal(t, ts.adminUser["id"], owner["user_id"])
 	})
 
 	t.Run("Register Member User", func(t *testing.T) {
@@ -187,7 +202,6 @@ func TestEndToEndAPI(t *testing.T) {
 			"name":            "Member User",
 			"email":           ts.memberEmail,
 			"password":        "password123",
-			"role":            "member",
 			"organization_id": ts.orgID,
 		}
 
@@ -206,6 +220,73 @@ func TestEndToEndAPI(t *testing.T) {
 		assert.Equal(t, "member", ts.memberUser["role"])
 	})
 
+	t.Run("Member Cannot Grant Roles", func(t *testing.T) {
+		endpoint := fmt.Sprintf("/organizations/%d/members/%d/role", ts.orgID, uint(ts.adminUser["id"].(float64)))
+		resp, _, err := ts.makeRequestWithOrgHeader("PUT", endpoint, map[string]interface{}{"role": "admin"}, ts.memberToken, ts.orgID)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+		resp, _, err = ts.makeRequest("PUT", fmt.Sprintf("/admin/users/%d/role", uint(ts.memberUser["id"].(float64))), map[string]interface{}{"role": "admin"}, ts.memberToken)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("Manage Custom Roles", func(t *testing.T) {
+		endpoint := fmt.Sprintf("/organizations/%d/roles", ts.orgID)
+		roleData := map[string]interface{}{
+			"name":        "reviewer",
+			"description": "Comments on drafts",
+			"permissions": []map[string]interface{}{
+				{"resource": "article", "actions": []string{"view", "comment"}},
+			},
+		}
+		resp, _, err := ts.makeRequestWithOrgHeader("POST", endpoint, roleData, ts.memberToken, ts.orgID)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+		resp, _, err = ts.makeRequestWithOrgHeader("POST", endpoint, roleData, ts.adminToken, ts.orgID)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		resp, _, err = ts.makeRequestWithOrgHeader("POST", endpoint, roleData, ts.adminToken, ts.orgID)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+		invalid := map[string]interface{}{"name": "admin"}
+		resp, _, err = ts.makeRequestWithOrgHeader("POST", endpoint, invalid, ts.adminToken, ts.orgID)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		// Members are refused whether or not the role exists.
+		for _, name := range []string{"reviewer", "no-such-role"} {
+			resp, _, err = ts.makeRequestWithOrgHeader("PUT", endpoint+"/"+name, roleData, ts.memberToken, ts.orgID)
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		}
+
+		resp, _, err = ts.makeRequestWithOrgHeader("PUT", endpoint+"/no-such-role", roleData, ts.adminToken, ts.orgID)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+		roleData["description"] = "Reviews articles"
+		resp, _, err = ts.makeRequestWithOrgHeader("PUT", endpoint+"/reviewer", roleData, ts.adminToken, ts.orgID)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp, body, err := ts.makeRequestWithOrgHeader("GET", endpoint, nil, ts.memberToken, ts.orgID)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Contains(t, string(body), "Reviews articles")
+
+		resp, _, err = ts.makeRequestWithOrgHeader("DELETE", endpoint+"/reviewer", nil, ts.memberToken, ts.orgID)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+		resp, _, err = ts.makeRequestWithOrgHeader("DELETE", endpoint+"/reviewer", nil, ts.adminToken, ts.orgID)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
 	t.Run("Login Admin User", func(t *testing.T) {
 		loginData := map[string]interface{}{
 			"email":    ts.adminEmail,
@@ -250,6 +331,15 @@ func TestEndToEndAPI(t *testing.T) {
 		assert.Equal(t, ts.orgName, response["name"])
 	})
 
+	t.Run("Member Cannot Rename Organization", func(t *testing.T) {
+		endpoint := fmt.Sprintf("/organizations/%d/", ts.orgID)
+		for _, change := range []map[string]interface{}{{"name": "Hijacked"}, {"slug": "hijacked"}} {
+			resp, _, err := ts.makeRequestWithOrgHeader("PUT", endpoint, change, ts.memberToken, ts.orgID)
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		}
+	})
+
 	t.Run("Create Article as Admin", func(t *testing.T) {
 		articleData := map[string]interface{}{
 			"title":   "Test Article",
@@ -271,6 +361,8 @@ func TestEndToEndAPI(t *testing.T) {
 		ts.articleID = uint(article["ID"].(float64))
 		assert.Equal(t, "Test Article", article["title"])
 		assert.Equal(t, "draft", article["status"])
+		assert.Equal(t, "markdown", article["content_format"])
+		assert.Equal(t, "<p>This is a test article content</p>\n", article["content_html"])
 	})
 
 	t.Run("Get Article as Admin", func(t *testing.T) {
@@ -289,6 +381,21 @@ func TestEndToEndAPI(t *testing.T) {
 		assert.Equal(t, "owner", permission)
 	})
 
+	t.Run("Get Article by Slug", func(t *testing.T) {
+		endpoint := fmt.Sprintf("/organizations/%d/articles/test-article/", ts.orgID)
+		resp, body, err := ts.makeRequestWithOrgHeader("GET", endpoint, nil, ts.adminToken, ts.orgID)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var response map[string]interface{}
+		err = json.Unmarshal(body, &response)
+		assert.NoError(t, err)
+
+		article := response["article"].(map[string]interface{})
+		assert.Equal(t, float64(ts.articleID), article["ID"])
+		assert.Equal(t, "test-article", article["slug"])
+	})
+
 	t.Run("Update Article Status to Published", func(t *testing.T) {
 		updateData := map[string]interface{}{
 			"status": "published",
@@ -306,6 +413,44 @@ func TestEndToEndAPI(t *testing.T) {
 
 		article := response["article"].(map[string]interface{})
 		assert.Equal(t, "published", article["status"])
+		assert.Equal(t, "public", article["visibility"])
+
+		resp, body, err = ts.makeRequest("GET", fmt.Sprintf("/public/%d/articles", ts.orgID), nil, "")
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		var listing struct {
+			Articles []struct {
+				Slug string `json:"slug"`
+			} `json:"articles"`
+		}
+		err = json.Unmarshal(body, &listing)
+		assert.NoError(t, err)
+		listed := make([]string, 0, len(listing.Articles))
+		for _, a := range listing.Articles {
+			listed = append(listed, a.Slug)
+		}
+		assert.Contains(t, listed, article["slug"], "new articles are public by default")
+
+		resp, _, err = ts.makeRequestWithOrgHeader("PUT", endpoint, map[string]interface{}{"visibility": "organization"}, ts.adminToken, ts.orgID)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		publicEndpoint := fmt.Sprintf("/public/%d/articles/%s", ts.orgID, article["slug"])
+		resp, _, err = ts.makeRequest("GET", publicEndpoint, nil, "")
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+		resp, _, err = ts.makeRequestWithOrgHeader("PUT", endpoint, map[string]interface{}{"visibility": "everyone"}, ts.adminToken, ts.orgID)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		resp, _, err = ts.makeRequestWithOrgHeader("PUT", endpoint, map[string]interface{}{"visibility": "public"}, ts.adminToken, ts.orgID)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp, _, err = ts.makeRequest("GET", publicEndpoint, nil, "")
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
 	})
 
 	t.Run("Get Article as Member", func(t *testing.T) {
@@ -323,6 +468,31 @@ func TestEndToEndAPI(t *testing.T) {
 		assert.Equal(t, "comment", permission) // Member should have comment permission on published article
 	})
 
+	t.Run("Get Permissions as Member", func(t *testing.T) {
+		endpoint := fmt.Sprintf("/organizations/%d/permissions?article_id=%d", ts.orgID, ts.articleID)
+		resp, body, err := ts.makeRequestWithOrgHeader("GET", endpoint, nil, ts.memberToken, ts.orgID)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var response map[string]interface{}
+		err = json.Unmarshal(body, &response)
+		assert.NoError(t, err)
+		assert.Equal(t, "member", response["role"])
+
+		article := response["article"].(map[string]interface{})
+		assert.ElementsMatch(t, []interface{}{"comment", "view"}, article["actions"])
+	})
+
+	t.Run("Policy Rejects Unknown Actions", func(t *testing.T) {
+		endpoint := fmt.Sprintf("/organizations/%d/policy", ts.orgID)
+		typo := map[string]interface{}{
+			"rules": []map[string]interface{}{{"role": "member", "resource": "article", "actions": []string{"veiw"}}},
+		}
+		resp, _, err := ts.makeRequestWithOrgHeader("PUT", endpoint, typo, ts.adminToken, ts.orgID)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
 	t.Run("Create Comment as Member", func(t *testing.T) {
 		commentData := map[string]interface{}{
 			"content": "This is a test comment",
@@ -358,10 +528,62 @@ func TestEndToEndAPI(t *testing.T) {
 		assert.Len(t, comments, 1)
 	})
 
+	t.Run("Tag and Categorize Article", func(t *testing.T) {
+		endpoint := fmt.Sprintf("/organizations/%d/categories", ts.orgID)
+		resp, _, err := ts.makeRequestWithOrgHeader("POST", endpoint, map[string]interface{}{"name": "Guides"}, ts.memberToken, ts.orgID)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+		resp, body, err := ts.makeRequestWithOrgHeader("POST", endpoint, map[string]interface{}{"name": "Guides"}, ts.adminToken, ts.orgID)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		var response map[string]interface{}
+		err = json.Unmarshal(body, &response)
+		assert.NoError(t, err)
+		categoryID := response["category"].(map[string]interface{})["ID"]
+
+		updateData := map[string]interface{}{
+			"tags":        []string{"Go", "go", "Testing"},
+			"category_id": categoryID,
+		}
+		endpoint = fmt.Sprintf("/organizations/%d/articles/%d/", ts.orgID, ts.articleID)
+		resp, body, err = ts.makeRequestWithOrgHeader("PUT", endpoint, updateData, ts.adminToken, ts.orgID)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		err = json.Unmarshal(body, &response)
+		assert.NoError(t, err)
+		article := response["article"].(map[string]interface{})
+		assert.Equal(t, categoryID, article["category_id"])
+		assert.Len(t, article["tags"], 2)
+
+		endpoint = fmt.Sprintf("/organizations/%d/articles?tag=go&tag=testing", ts.orgID)
+		resp, body, err = ts.makeRequestWithOrgHeader("GET", endpoint, nil, ts.adminToken, ts.orgID)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		err = json.Unmarshal(body, &response)
+		assert.NoError(t, err)
+		assert.Len(t, response["articles"], 1)
+
+		endpoint = fmt.Sprintf("/organizations/%d/tags?status=published", ts.orgID)
+		resp, body, err = ts.makeRequestWithOrgHeader("GET", endpoint, nil, ts.memberToken, ts.orgID)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		err = json.Unmarshal(body, &response)
+		assert.NoError(t, err)
+		tags := response["tags"].([]interface{})
+		assert.Len(t, tags, 2)
+		assert.Equal(t, float64(1), tags[0].(map[string]interface{})["article_count"])
+	})
+
 	t.Run("Get Published Articles", func(t *testing.T) {
-		resp, body, err := ts.makeRequest("GET", "/articles/published", nil, "")
+		resp, body, err := ts.makeRequest("GET", fmt.Sprintf("/public/%d/articles", ts.orgID), nil, "")
 		assert.NoError(t, err)
 		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.NotEmpty(t, resp.Header.Get("ETag"))
 
 		var response map[string]interface{}
 		err = json.Unmarshal(body, &response)
@@ -370,6 +592,62 @@ func TestEndToEndAPI(t *testing.T) {
 
 		articles := response["articles"].([]interface{})
 		assert.GreaterOrEqual(t, len(articles), 1)
+		article := articles[0].(map[string]interface{})
+		assert.NotContains(t, article, "content_html")
+		author := article["author"].(map[string]interface{})
+		assert.NotContains(t, author, "email")
+
+		endpoint := fmt.Sprintf("/public/%d/articles/%s", ts.orgID, article["slug"])
+		resp, body, err = ts.makeRequest("GET", endpoint, nil, "")
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		err = json.Unmarshal(body, &response)
+		assert.NoError(t, err)
+		assert.Contains(t, response["article"], "content_html")
+
+		endpoint = fmt.Sprintf("/public/%d/tags/go/articles", ts.orgID)
+		resp, body, err = ts.makeRequest("GET", endpoint, nil, "")
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		err = json.Unmarshal(body, &response)
+		assert.NoError(t, err)
+		assert.Equal(t, float64(1), response["total"])
+
+		endpoint = fmt.Sprintf("/public/%d/authors/%v/articles", ts.orgID, author["id"])
+		resp, _, err = ts.makeRequest("GET", endpoint, nil, "")
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("Get Organization Feeds", func(t *testing.T) {
+		endpoint := fmt.Sprintf("/public/%d/feeds/atom", ts.orgID)
+		resp, body, err := ts.makeRequest("GET", endpoint, nil, "")
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "application/atom+xml; charset=utf-8", resp.Header.Get("Content-Type"))
+		assert.Contains(t, string(body), "<entry>")
+		assert.NotEmpty(t, resp.Header.Get("Last-Modified"))
+
+		req, err := http.NewRequest("GET", baseURL+endpoint, nil)
+		assert.NoError(t, err)
+		req.Header.Set("If-None-Match", resp.Header.Get("ETag"))
+		resp, err = ts.client.Do(req)
+		assert.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+
+		endpoint = fmt.Sprintf("/public/%d/tags/testing/feeds/rss?content=summary", ts.orgID)
+		resp, body, err = ts.makeRequest("GET", endpoint, nil, "")
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Contains(t, string(body), "<item>")
+		assert.NotContains(t, string(body), "<content:encoded>")
+
+		resp, _, err = ts.makeRequest("GET", fmt.Sprintf("/public/%d/feeds/json", ts.orgID), nil, "")
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
 	})
 
 	t.Run("Get My Articles", func(t *testing.T) {
@@ -404,6 +682,75 @@ func TestEndToEndAPI(t *testing.T) {
 		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
 	})
 
+	t.Run("Share Article with Member for Editing", func(t *testing.T) {
+		endpoint := fmt.Sprintf("/organizations/%d/articles/%d/collaborators", ts.orgID, ts.articleID)
+		collaboratorData := map[string]interface{}{
+			"user_id":    uint(ts.memberUser["id"].(float64)),
+			"permission": "edit",
+		}
+		resp, _, err := ts.makeRequestWithOrgHeader("POST", endpoint, collaboratorData, ts.adminToken, ts.orgID)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		articleEndpoint := fmt.Sprintf("/organizations/%d/articles/%d/", ts.orgID, ts.articleID)
+		resp, body, err := ts.makeRequestWithOrgHeader("GET", articleEndpoint, nil, ts.memberToken, ts.orgID)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var response map[string]interface{}
+		err = json.Unmarshal(body, &response)
+		assert.NoError(t, err)
+		assert.Equal(t, "edit", response["permission"])
+	})
+
+	t.Run("Upload and Download Attachment", func(t *testing.T) {
+		upload := func(name string, content []byte) (*http.Response, []byte) {
+			var form bytes.Buffer
+			writer := multipart.NewWriter(&form)
+			part, err := writer.CreateFormFile("file", name)
+			assert.NoError(t, err)
+			part.Write(content)
+			assert.NoError(t, writer.Close())
+
+			endpoint := fmt.Sprintf("%s/organizations/%d/articles/%d/attachments", baseURL, ts.orgID, ts.articleID)
+			req, err := http.NewRequest("POST", endpoint, &form)
+			assert.NoError(t, err)
+			req.Header.Set("Content-Type", writer.FormDataContentType())
+			req.Header.Set("Authorization", "Bearer "+ts.adminToken)
+			req.Header.Set("X-Organization-ID", fmt.Sprintf("%d", ts.orgID))
+
+			resp, err := ts.client.Do(req)
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			assert.NoError(t, err)
+			return resp, body
+		}
+
+		resp, _ := upload("page.html", []byte("<html><script>alert(1)</script></html>"))
+		assert.Equal(t, http.StatusUnsupportedMediaType, resp.StatusCode)
+
+		image := append([]byte("\x89PNG\r\n\x1a\n"), bytes.Repeat([]byte{0}, 64)...)
+		resp, body := upload("pixel.png", image)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		var response map[string]interface{}
+		err := json.Unmarshal(body, &response)
+		assert.NoError(t, err)
+		attachment := response["attachment"].(map[string]interface{})
+		assert.Equal(t, "image/png", attachment["content_type"])
+		url := strings.TrimPrefix(attachment["url"].(string), "/api/v1")
+
+		resp, body, err = ts.makeRequest("GET", url, nil, "")
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, image, body)
+
+		resp, _, err = ts.makeRequest("GET", url+"0", nil, "")
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
 	t.Run("Delete Article as Owner", func(t *testing.T) {
 		endpoint := fmt.Sprintf("/organizations/%d/articles/%d/", ts.orgID, ts.articleID)
 		resp, _, err := ts.makeRequestWithOrgHeader("DELETE", endpoint, nil, ts.adminToken, ts.orgID)
@@ -411,6 +758,147 @@ func TestEndToEndAPI(t *testing.T) {
 		assert.Equal(t, http.StatusOK, resp.StatusCode)
 	})
 
+	t.Run("Deleted Article Is Gone and Trash Is Admin Only", func(t *testing.T) {
+		endpoint := fmt.Sprintf("/organizations/%d/articles/%d/", ts.orgID, ts.articleID)
+		resp, _, err := ts.makeRequestWithOrgHeader("GET", endpoint, nil, ts.adminToken, ts.orgID)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+		endpoint = fmt.Sprintf("/organizations/%d/trash/articles/%d/restore", ts.orgID, ts.articleID)
+		resp, _, err = ts.makeRequestWithOrgHeader("POST", endpoint, nil, ts.memberToken, ts.orgID)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("Member Cannot Delete Organization", func(t *testing.T) {
+		endpoint := fmt.Sprintf("/organizations/%d/deletion", ts.orgID)
+		resp, _, err := ts.makeRequestWithOrgHeader("POST", endpoint, nil, ts.memberToken, ts.orgID)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+		endpoint = fmt.Sprintf("/organizations/%d/", ts.orgID)
+		resp, _, err = ts.makeRequestWithOrgHeader("DELETE", endpoint, map[string]interface{}{"confirmation_token": "guess"}, ts.memberToken, ts.orgID)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("Ownership Transfers Are Admin Only", func(t *testing.T) {
+		endpoint := fmt.Sprintf("/organizations/%d/owner", ts.orgID)
+		resp, _, err := ts.makeRequestWithOrgHeader("GET", endpoint, nil, ts.memberToken, ts.orgID)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		endpoint = fmt.Sprintf("/organizations/%d/ownership-transfers", ts.orgID)
+		resp, _, err = ts.makeRequestWithOrgHeader("GET", endpoint, nil, ts.memberToken, ts.orgID)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+		resp, _, err = ts.makeRequestWithOrgHeader("GET", endpoint, nil, ts.adminToken, ts.orgID)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("List Members and Owner Cannot Leave", func(t *testing.T) {
+		endpoint := fmt.Sprintf("/organizations/%d/members?role=owner", ts.orgID)
+		resp, body, err := ts.makeRequestWithOrgHeader("GET", endpoint, nil, ts.memberToken, ts.orgID)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var response map[string]interface{}
+		err = json.Unmarshal(body, &response)
+		assert.NoError(t, err)
+		assert.Equal(t, float64(1), response["total"])
+		members := response["members"].([]interface{})
+		assert.NotContains(t, members[0], "email", "only those who manage members see email addresses")
+
+		resp, body, err = ts.makeRequestWithOrgHeader("GET", endpoint, nil, ts.adminToken, ts.orgID)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		err = json.Unmarshal(body, &response)
+		assert.NoError(t, err)
+		members = response["members"].([]interface{})
+		assert.Contains(t, members[0], "email")
+
+		endpoint = fmt.Sprintf("/organizations/%d/members/leave", ts.orgID)
+		resp, _, err = ts.makeRequestWithOrgHeader("POST", endpoint, nil, ts.adminToken, ts.orgID)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	})
+
+	t.Run("Export Profile and Owner Cannot Delete Account", func(t *testing.T) {
+		resp, body, err := ts.makeRequest("GET", "/auth/profile/export", nil, ts.memberToken)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "application/zip", resp.Header.Get("Content-Type"))
+		assert.NotEmpty(t, body)
+
+		resp, _, err = ts.makeRequest("POST", "/auth/profile/deletion", map[string]interface{}{"password": "password123"}, ts.adminToken)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	})
+
+	t.Run("Usage Is Admin Only", func(t *testing.T) {
+		endpoint := fmt.Sprintf("/organizations/%d/usage", ts.orgID)
+		resp, body, err := ts.makeRequestWithOrgHeader("GET", endpoint, nil, ts.adminToken, ts.orgID)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var response map[string]interface{}
+		err = json.Unmarshal(body, &response)
+		assert.NoError(t, err)
+		usage := response["usage"].(map[string]interface{})
+		assert.Equal(t, float64(2), usage["members"].(map[string]interface{})["used"])
+
+		resp, _, err = ts.makeRequestWithOrgHeader("GET", endpoint, nil, ts.memberToken, ts.orgID)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("Settings Are Admin Only and Validated", func(t *testing.T) {
+		endpoint := fmt.Sprintf("/organizations/%d/settings", ts.orgID)
+		resp, _, err := ts.makeRequestWithOrgHeader("PUT", endpoint, map[string]interface{}{"comment_policy": "closed"}, ts.memberToken, ts.orgID)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+		resp, _, err = ts.makeRequestWithOrgHeader("PUT", endpoint, map[string]interface{}{"timezone": "Mars/Olympus_Mons"}, ts.adminToken, ts.orgID)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		update := map[string]interface{}{
+			"timezone": "Europe/Berlin",
+			"branding": map[string]interface{}{"primary_color": "#336699"},
+		}
+		resp, _, err = ts.makeRequestWithOrgHeader("PUT", endpoint, update, ts.adminToken, ts.orgID)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp, body, err := ts.makeRequest("GET", fmt.Sprintf("/public/%d/articles", ts.orgID), nil, "")
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		var response map[string]interface{}
+		err = json.Unmarshal(body, &response)
+		assert.NoError(t, err)
+		org := response["organization"].(map[string]interface{})
+		assert.Equal(t, "#336699", org["primary_color"])
+	})
+
+	t.Run("Audit Log Is Admin Only", func(t *testing.T) {
+		endpoint := fmt.Sprintf("/organizations/%d/audit?action=settings.update", ts.orgID)
+		resp, _, err := ts.makeRequestWithOrgHeader("GET", endpoint, nil, ts.memberToken, ts.orgID)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+		resp, body, err := ts.makeRequestWithOrgHeader("GET", endpoint, nil, ts.adminToken, ts.orgID)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.NotEmpty(t, resp.Header.Get("X-Request-ID"))
+
+		var response map[string]interface{}
+		err = json.Unmarshal(body, &response)
+		assert.NoError(t, err)
+		assert.Equal(t, float64(1), response["total"])
+	})
+
 	t.Run("Invalid Authentication", func(t *testing.T) {
 		resp, _, err := ts.makeRequest("GET", "/auth/profile", nil, "invalid-token")
 		assert.NoError(t, err)
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/repository"
+)
+
+const usage = `usage:
+  app                               serve the API
+  app promote-super-admin <email>   make a registered user the platform super admin`
+
+// runCommand carries out the administrative command given on the command
+// line, if any, and reports whether there was one. Platform super admins are
+// only ever appointed this way or by another super admin, never by signing
+// up with a particular email address.
+func runCommand(db *gorm.DB, args []string) (bool, error) {
+	if len(args) == 0 {
+		return false, nil
+	}
+
+	switch args[0] {
+	case "promote-super-admin":
+		if len(args) != 2 {
+			return true, fmt.Errorf("%s", usage)
+		}
+		return true, promoteSuperAdmin(db, args[1])
+	case "help", "-h", "--help":
+		fmt.Fprintln(os.Stdout, usage)
+		return true, nil
+	default:
+		return true, fmt.Errorf("unknown command %q\n%s", args[0], usage)
+	}
+}
+
+func promoteSuperAdmin(db *gorm.DB, email string) error {
+	ctx := context.Background()
+	user, err := repository.NewUserRepository(db).GetUserByEmail(ctx, strings.TrimSpace(email))
+	if err != nil {
+		return fmt.Errorf("no user registered with email %s: %w", email, err)
+	}
+	if user.Role == model.RoleSuperAdmin {
+		fmt.Printf("User %d (%s) already is a super admin\n", user.ID, user.Email)
+		return nil
+	}
+
+	if _, err := repository.NewRoleRepository(db).SetGlobalRole(ctx, 0, user.ID, model.RoleSuperAdmin); err != nil {
+		return err
+	}
+	fmt.Printf("User %d (%s) is now a super admin\n", user.ID, user.Email)
+	return nil
+}
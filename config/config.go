@@ -3,6 +3,8 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"strings"
 	"sync"
 
 	"github.com/joho/godotenv"
@@ -15,6 +17,50 @@ type Config struct {
 	DBPassword string
 	DBName     string
 	JWTSecret  string
+	// PolicyFile optionally replaces the built-in default authorization policy.
+	PolicyFile string
+	// TenantRLS enables Postgres row-level security as a tenant isolation
+	// backstop.
+	TenantRLS bool
+	// TenancyMode is "shared" (default) or "schema". In schema mode every
+	// organization keeps its tenant tables in its own Postgres schema, and
+	// routes outside /organizations/:orgId only see the public schema.
+	// Switching an existing database to schema mode moves each
+	// organization's content into its schema at startup.
+	TenancyMode string
+	// TenantStrategies lists, in order, how requests are mapped to an
+	// organization: "header", "path" and/or "host". Defaults to "header".
+	TenantStrategies []string
+	// TenantBaseDomain is the domain organizations get subdomains of when the
+	// host strategy is enabled, e.g. blog.example.com.
+	TenantBaseDomain string
+	// TrashRetentionDays is how long deleted organizations, articles and
+	// comments can be restored before they are purged. Defaults to 30.
+	TrashRetentionDays int
+	// ExportDir is where organization export archives are written. Defaults
+	// to "exports".
+	ExportDir string
+	// AccountDeletionGraceDays is how long a user can cancel the deletion of
+	// their account before it is carried out. Defaults to 14.
+	AccountDeletionGraceDays int
+	// StorageBackend is where attachments are kept: "local" (default) or
+	// "s3" for any S3-compatible service.
+	StorageBackend string
+	// StorageDir is the directory of the local storage backend. Defaults to
+	// "uploads".
+	StorageDir string
+	// S3Endpoint, S3Region, S3Bucket and the access keys configure the s3
+	// backend. S3PathStyle is needed by most S3-compatible servers such as
+	// MinIO.
+	S3Endpoint        string
+	S3Region          string
+	S3Bucket          string
+	S3AccessKeyID     string
+	S3SecretAccessKey string
+	S3PathStyle       bool
+	// MaxAttachmentSize is the largest file in bytes that can be attached to
+	// an article. Defaults to 10 MiB.
+	MaxAttachmentSize int64
 }
 
 var (
@@ -36,6 +82,69 @@ func LoadConfig() *Config {
 			DBPassword: os.Getenv("DB_PASSWORD"),
 			DBName:     os.Getenv("DB_NAME"),
 			JWTSecret:  os.Getenv("JWT_SECRET"),
+
+			PolicyFile:  os.Getenv("POLICY_FILE"),
+			TenantRLS:   os.Getenv("TENANT_RLS") == "true",
+			TenancyMode: os.Getenv("TENANCY_MODE"),
+
+			TenantBaseDomain: strings.ToLower(os.Getenv("TENANT_BASE_DOMAIN")),
+			ExportDir:        os.Getenv("EXPORT_DIR"),
+
+			StorageBackend:    os.Getenv("STORAGE_BACKEND"),
+			StorageDir:        os.Getenv("STORAGE_DIR"),
+			S3Endpoint:        os.Getenv("S3_ENDPOINT"),
+			S3Region:          os.Getenv("S3_REGION"),
+			S3Bucket:          os.Getenv("S3_BUCKET"),
+			S3AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+			S3SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+			S3PathStyle:       os.Getenv("S3_PATH_STYLE") == "true",
+		}
+		if config.ExportDir == "" {
+			config.ExportDir = "exports"
+		}
+		if config.StorageBackend == "" {
+			config.StorageBackend = "local"
+		}
+		if config.StorageDir == "" {
+			config.StorageDir = "uploads"
+		}
+		if config.TenancyMode == "" {
+			config.TenancyMode = "shared"
+		}
+		for _, strategy := range strings.Split(os.Getenv("TENANT_STRATEGIES"), ",") {
+			if strategy = strings.TrimSpace(strategy); strategy != "" {
+				config.TenantStrategies = append(config.TenantStrategies, strategy)
+			}
+		}
+		if len(config.TenantStrategies) == 0 {
+			config.TenantStrategies = []string{"header"}
+		}
+
+		config.TrashRetentionDays = 30
+		if days := os.Getenv("TRASH_RETENTION_DAYS"); days != "" {
+			retention, err := strconv.Atoi(days)
+			if err != nil || retention < 0 {
+				log.Fatalf("Invalid TRASH_RETENTION_DAYS %q", days)
+			}
+			config.TrashRetentionDays = retention
+		}
+
+		config.AccountDeletionGraceDays = 14
+		if days := os.Getenv("ACCOUNT_DELETION_GRACE_DAYS"); days != "" {
+			grace, err := strconv.Atoi(days)
+			if err != nil || grace < 0 {
+				log.Fatalf("Invalid ACCOUNT_DELETION_GRACE_DAYS %q", days)
+			}
+			config.AccountDeletionGraceDays = grace
+		}
+
+		config.MaxAttachmentSize = 10 << 20
+		if size := os.Getenv("MAX_ATTACHMENT_BYTES"); size != "" {
+			maxSize, err := strconv.ParseInt(size, 10, 64)
+			if err != nil || maxSize <= 0 {
+				log.Fatalf("Invalid MAX_ATTACHMENT_BYTES %q", size)
+			}
+			config.MaxAttachmentSize = maxSize
 		}
 	})
 
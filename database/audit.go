@@ -0,0 +1,41 @@
+package database
+
+import "gorm.io/gorm"
+
+// auditScrubSetting is only set, transaction-locally, by
+// scrub_audit_log_user while it rewrites the rows of an erased user.
+const auditScrubSetting = "app.audit_scrub"
+
+// ProtectAuditLog makes the audit log append-only at the database level, so
+// not even a bug or a stray query in the application can rewrite history.
+// The one exception is scrub_audit_log_user, which erases the personal data
+// of a deleted account: it clears the IP and actor of the entries the user
+// made and the changes recorded about them. The function runs with its
+// owner's rights and tenant-scoped transactions may not call it.
+func ProtectAuditLog(db *gorm.DB) error {
+	statements := []string{
+		`CREATE OR REPLACE FUNCTION audit_logs_append_only() RETURNS trigger AS $$
+		BEGIN
+			IF TG_OP = 'UPDATE' AND current_setting('` + auditScrubSetting + `', true) = 'on' THEN
+				RETURN NULL;
+			END IF;
+			RAISE EXCEPTION 'audit_logs is append-only';
+		END $$ LANGUAGE plpgsql`,
+		"DROP TRIGGER IF EXISTS audit_logs_append_only ON audit_logs",
+		"CREATE TRIGGER audit_logs_append_only BEFORE UPDATE OR DELETE OR TRUNCATE ON audit_logs FOR EACH STATEMENT EXECUTE FUNCTION audit_logs_append_only()",
+		`CREATE OR REPLACE FUNCTION scrub_audit_log_user(erased bigint) RETURNS void AS $$
+		BEGIN
+			PERFORM set_config('` + auditScrubSetting + `', 'on', true);
+			UPDATE audit_logs SET ip = '', actor_id = NULL WHERE actor_id = erased;
+			UPDATE audit_logs SET changes = NULL WHERE target_type = 'user' AND target_id = erased;
+			PERFORM set_config('` + auditScrubSetting + `', '', true);
+		END $$ LANGUAGE plpgsql SECURITY DEFINER SET search_path = public`,
+		"REVOKE ALL ON FUNCTION scrub_audit_log_user(bigint) FROM PUBLIC",
+	}
+	for _, stmt := range statements {
+		if err := db.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package database
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+)
+
+// TestAuditLogIsAppendOnlyExceptForErasure needs a Postgres database it may
+// migrate, see TestRowLevelSecurityIsolatesTenants.
+func TestAuditLogIsAppendOnlyExceptForErasure(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN not set")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, Migrate(db))
+
+	suffix := time.Now().UnixNano()
+	erased := &model.User{Name: "Erased", Email: fmt.Sprintf("erased%d@test.com", suffix)}
+	kept := &model.User{Name: "Kept", Email: fmt.Sprintf("kept%d@test.com", suffix)}
+	require.NoError(t, db.Create(erased).Error)
+	require.NoError(t, db.Create(kept).Error)
+
+	made := &model.AuditLog{ActorID: &erased.ID, Action: "article.create", TargetType: "article", TargetID: 1, IP: "192.0.2.1"}
+	about := &model.AuditLog{
+		ActorID: &kept.ID, Action: "user.update", TargetType: "user", TargetID: erased.ID, IP: "192.0.2.2",
+		Changes: map[string]model.AuditChange{"email": {From: erased.Email, To: "new@test.com"}},
+	}
+	other := &model.AuditLog{ActorID: &kept.ID, Action: "article.create", TargetType: "article", TargetID: 2, IP: "192.0.2.3"}
+	for _, entry := range []*model.AuditLog{made, about, other} {
+		require.NoError(t, db.Create(entry).Error)
+	}
+
+	assert.Error(t, db.Model(made).Update("ip", "").Error)
+	assert.Error(t, db.Delete(made).Error)
+
+	require.NoError(t, db.Transaction(func(tx *gorm.DB) error {
+		return tx.Exec("SELECT scrub_audit_log_user(?)", erased.ID).Error
+	}))
+
+	var got model.AuditLog
+	require.NoError(t, db.First(&got, made.ID).Error)
+	assert.Nil(t, got.ActorID)
+	assert.Empty(t, got.IP)
+
+	require.NoError(t, db.First(&got, about.ID).Error)
+	assert.Nil(t, got.Changes)
+	assert.Equal(t, kept.ID, *got.ActorID)
+	assert.Equal(t, "192.0.2.2", got.IP)
+
+	require.NoError(t, db.First(&got, other.ID).Error)
+	assert.Equal(t, kept.ID, *got.ActorID)
+	assert.Equal(t, "192.0.2.3", got.IP)
+
+	assert.Error(t, db.Model(other).Update("ip", "").Error, "the scrub does not leave the log writable")
+}
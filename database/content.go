@@ -0,0 +1,33 @@
+package database
+
+import (
+	"gorm.io/gorm"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/markup"
+)
+
+// BackfillContentHTML renders the HTML of articles saved before it was
+// cached with them. Like BackfillSlugs, it works on tx's search_path.
+func BackfillContentHTML(tx *gorm.DB) error {
+	var articles []model.Article
+	return tx.Unscoped().Select("id", "content", "content_format").
+		Where("(content_html = '' OR content_html IS NULL) AND content <> ''").
+		FindInBatches(&articles, 100, func(batch *gorm.DB, _ int) error {
+			for _, article := range articles {
+				html := markup.Render(article.ContentFormat, article.Content)
+				if err := tx.Unscoped().Model(&model.Article{}).Where("id = ?", article.ID).Update("content_html", html).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+}
+
+// BackfillPublishedAt dates articles published before publication dates
+// were recorded by when they were created.
+func BackfillPublishedAt(tx *gorm.DB) error {
+	return tx.Unscoped().Model(&model.Article{}).
+		Where("status = ? AND published_at IS NULL", "published").
+		UpdateColumn("published_at", gorm.Expr("created_at")).Error
+}
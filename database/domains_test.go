@@ -0,0 +1,52 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/repository"
+)
+
+// TestOnlyOneOrganizationVerifiesAHost needs a Postgres database it may
+// migrate, see TestRowLevelSecurityIsolatesTenants.
+func TestOnlyOneOrganizationVerifiesAHost(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN not set")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, Migrate(db))
+
+	ctx := context.Background()
+	suffix := time.Now().UnixNano()
+	host := fmt.Sprintf("blog%d.example.org", suffix)
+	orgA := &model.Organization{Name: fmt.Sprintf("Domain Tenant A %d", suffix)}
+	orgB := &model.Organization{Name: fmt.Sprintf("Domain Tenant B %d", suffix)}
+	require.NoError(t, db.Create(orgA).Error)
+	require.NoError(t, db.Create(orgB).Error)
+
+	domains := repository.NewDomainRepository(db)
+	claimA, err := domains.CreateDomain(ctx, &model.OrganizationDomain{OrganizationID: orgA.ID, Host: host, VerificationToken: "a"})
+	require.NoError(t, err)
+	claimB, err := domains.CreateDomain(ctx, &model.OrganizationDomain{OrganizationID: orgB.ID, Host: host, VerificationToken: "b"})
+	require.NoError(t, err, "pending claims of different organizations may coexist")
+
+	_, err = domains.CreateDomain(ctx, &model.OrganizationDomain{OrganizationID: orgA.ID, Host: host, VerificationToken: "c"})
+	assert.ErrorIs(t, err, repository.ErrDomainClaimed)
+
+	_, err = domains.MarkDomainVerified(ctx, claimB)
+	require.NoError(t, err)
+	_, err = domains.MarkDomainVerified(ctx, claimA)
+	assert.ErrorIs(t, err, repository.ErrDomainTaken)
+}
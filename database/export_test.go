@@ -0,0 +1,117 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/storage"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/tenantexport"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/repository"
+)
+
+// TestExportImportRoundTrip needs a Postgres database it may migrate, see
+// TestRowLevelSecurityIsolatesTenants.
+func TestExportImportRoundTrip(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN not set")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, Migrate(db))
+
+	ctx := context.Background()
+	suffix := time.Now().UnixNano()
+	owner := &model.User{Name: "Export Owner", Email: fmt.Sprintf("export%d@test.com", suffix)}
+	require.NoError(t, db.Create(owner).Error)
+
+	org, err := repository.NewOrgRepository(db).CreateOrganization(ctx, &model.Organization{Name: fmt.Sprintf("Export Source %d", suffix)}, owner.ID)
+	require.NoError(t, err)
+	tag, err := repository.NewTagRepository(db).CreateTag(ctx, &model.Tag{OrganizationID: org.ID, Name: "Release Notes"})
+	require.NoError(t, err)
+	articles := repository.NewArticleRepository(db, repository.TenantStorage{})
+	article, err := articles.CreateArticle(ctx, &model.Article{
+		OrganizationID: org.ID, UserID: owner.ID, Title: "Round Trip", Content: "Exported *and* imported",
+		Status: "published", Tags: []model.Tag{*tag},
+	})
+	require.NoError(t, err)
+	_, err = articles.CreateComment(ctx, &model.Comment{ArticleID: article.ID, AuthorID: owner.ID, Content: "Still here"})
+	require.NoError(t, err)
+
+	store, err := storage.NewLocal(t.TempDir())
+	require.NoError(t, err)
+	exports := repository.NewExportRepository(db, repository.TenantStorage{})
+	var archive bytes.Buffer
+	require.NoError(t, tenantexport.Write(ctx, &archive, exports, store, org.ID))
+
+	data, _, err := tenantexport.Read(bytes.NewReader(archive.Bytes()), int64(archive.Len()))
+	require.NoError(t, err)
+	data.Organization.Name = fmt.Sprintf("Export Target %d", suffix)
+
+	t.Run("Dry Run Creates Nothing", func(t *testing.T) {
+		result, err := exports.ImportTenant(ctx, data, repository.ImportOptions{OwnerID: owner.ID, DryRun: true})
+		require.NoError(t, err)
+		assert.True(t, result.DryRun)
+		assert.Nil(t, result.Organization)
+		assert.Equal(t, 1, result.Created["articles"])
+		assert.Equal(t, 1, result.Created["comments"])
+		assert.Equal(t, 1, result.Created["tags"])
+
+		var count int64
+		require.NoError(t, db.Unscoped().Model(&model.Organization{}).Where("name = ?", data.Organization.Name).Count(&count).Error)
+		assert.Zero(t, count)
+	})
+
+	t.Run("Import Recreates The Content", func(t *testing.T) {
+		result, err := exports.ImportTenant(ctx, data, repository.ImportOptions{OwnerID: owner.ID})
+		require.NoError(t, err)
+		require.NotNil(t, result.Organization)
+		assert.NotEqual(t, org.ID, result.Organization.ID)
+
+		var rows []interface{}
+		require.NoError(t, exports.ReadTenant(ctx, result.Organization.ID, func(record interface{}) error {
+			rows = append(rows, record)
+			return nil
+		}))
+
+		var imported []*model.Article
+		var comments []*model.Comment
+		var tags []*model.Tag
+		for _, row := range rows {
+			switch row := row.(type) {
+			case *model.Article:
+				imported = append(imported, row)
+			case *model.Comment:
+				comments = append(comments, row)
+			case *model.Tag:
+				tags = append(tags, row)
+			}
+		}
+		require.Len(t, imported, 1)
+		assert.NotEqual(t, article.ID, imported[0].ID)
+		assert.Equal(t, article.Title, imported[0].Title)
+		assert.Equal(t, article.Content, imported[0].Content)
+		assert.Equal(t, article.Slug, imported[0].Slug)
+		assert.Equal(t, "published", imported[0].Status)
+
+		require.Len(t, tags, 1)
+		assert.Equal(t, tag.Slug, tags[0].Slug)
+		require.Len(t, imported[0].Tags, 1)
+		assert.Equal(t, tags[0].ID, imported[0].Tags[0].ID)
+
+		require.Len(t, comments, 1)
+		assert.Equal(t, imported[0].ID, comments[0].ArticleID)
+		assert.Equal(t, "Still here", comments[0].Content)
+	})
+}
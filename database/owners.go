@@ -0,0 +1,54 @@
+package database
+
+import (
+	"log"
+
+	"gorm.io/gorm"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+)
+
+// BackfillOwners gives organizations created before ownership existed an
+// owner: their longest-standing admin, or failing that their longest-standing
+// member. Organizations without members stay ownerless until someone joins
+// and a platform admin assigns them.
+func BackfillOwners(db *gorm.DB) error {
+	var orgIDs []uint
+	if err := db.Unscoped().Model(&model.Organization{}).
+		Where("NOT EXISTS (SELECT 1 FROM user_organizations WHERE user_organizations.organization_id = organizations.id AND user_organizations.role = ?)", model.RoleOwner).
+		Pluck("id", &orgIDs).Error; err != nil {
+		return err
+	}
+
+	for _, orgID := range orgIDs {
+		var membership model.UserOrganization
+		err := db.Where("organization_id = ?", orgID).
+			Order(gorm.Expr("role = ? DESC, created_at, user_id", model.RoleAdmin)).
+			First(&membership).Error
+		if err == gorm.ErrRecordNotFound {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&model.UserOrganization{}).
+				Where("user_id = ? AND organization_id = ?", membership.UserID, orgID).
+				Update("role", model.RoleOwner).Error; err != nil {
+				return err
+			}
+			return tx.Create(&model.RoleChange{
+				UserID:         membership.UserID,
+				OrganizationID: &orgID,
+				OldRole:        membership.Role,
+				NewRole:        model.RoleOwner,
+			}).Error
+		})
+		if err != nil {
+			return err
+		}
+		log.Printf("Made user %d the owner of organization %d", membership.UserID, orgID)
+	}
+	return nil
+}
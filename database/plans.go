@@ -0,0 +1,48 @@
+package database
+
+import (
+	"gorm.io/gorm"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/repository"
+)
+
+// defaultPlans are created when missing. Plans that already exist are left
+// alone, so their limits can be tuned in the database.
+var defaultPlans = []model.Plan{
+	{Name: model.PlanFree, IsDefault: true, MaxMembers: 5, MaxArticles: 100, MaxStorageBytes: 100 << 20, MaxAPIRequestsPerMonth: 10000},
+	{Name: model.PlanPro, MaxMembers: 50, MaxArticles: 10000, MaxStorageBytes: 10 << 30, MaxAPIRequestsPerMonth: 1000000},
+	{Name: model.PlanEnterprise},
+}
+
+// SeedPlans creates the default plans and puts organizations that predate
+// plans on the default one.
+func SeedPlans(db *gorm.DB) error {
+	for _, plan := range defaultPlans {
+		if err := db.Where("name = ?", plan.Name).FirstOrCreate(&plan).Error; err != nil {
+			return err
+		}
+	}
+
+	var plan model.Plan
+	if err := db.Where("is_default = ?", true).Order("id").First(&plan).Error; err != nil {
+		return err
+	}
+	return db.Unscoped().Model(&model.Organization{}).Where("plan_id IS NULL").Update("plan_id", plan.ID).Error
+}
+
+// BackfillUsage recounts the members, articles and stored files of every
+// organization, creating the usage counters of organizations that predate
+// them.
+func BackfillUsage(tx *gorm.DB) error {
+	var orgIDs []uint
+	if err := tx.Unscoped().Model(&model.Organization{}).Pluck("id", &orgIDs).Error; err != nil {
+		return err
+	}
+	for _, orgID := range orgIDs {
+		if err := repository.RecountUsage(tx, orgID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -21,11 +21,63 @@ func ConnectDatabase() (*gorm.DB, error) {
 		return nil, err
 	}
 
-	err = db.AutoMigrate(&model.Organization{}, &model.User{}, &model.Article{}, &model.Comment{})
-	if err != nil {
+	if err := Migrate(db); err != nil {
 		log.Fatalf("failed to migrate database: %v", err)
 		return nil, err
 	}
 
+	if err := ConfigureRowLevelSecurity(db, cfg.TenantRLS); err != nil {
+		log.Fatalf("failed to configure row-level security: %v", err)
+		return nil, err
+	}
+
+	if cfg.TenancyMode != TenancyShared && cfg.TenancyMode != TenancySchema {
+		log.Fatalf("unknown tenancy mode %q", cfg.TenancyMode)
+		return nil, fmt.Errorf("unknown tenancy mode %q", cfg.TenancyMode)
+	}
+
+	if cfg.TenancyMode == TenancySchema {
+		if err := MigrateTenantSchemas(db); err != nil {
+			log.Fatalf("failed to migrate tenant schemas: %v", err)
+			return nil, err
+		}
+	}
+
 	return db, nil
 }
+
+func Migrate(db *gorm.DB) error {
+	if err := db.SetupJoinTable(&model.User{}, "Organizations", &model.UserOrganization{}); err != nil {
+		return err
+	}
+	if err := db.SetupJoinTable(&model.Organization{}, "Users", &model.UserOrganization{}); err != nil {
+		return err
+	}
+
+	if err := db.AutoMigrate(&model.Organization{}, &model.User{}, &model.Tag{}, &model.Category{}, &model.Article{}, &model.Comment{}, &model.UserOrganization{}, &model.RoleChange{}, &model.PolicyRule{}, &model.OrganizationRole{}, &model.ArticleCollaborator{}, &model.Team{}, &model.OrganizationDomain{}, &model.SlugRedirect{}, &model.OrganizationDeletion{}, &model.OwnershipTransfer{}, &model.Plan{}, &model.OrganizationUsage{}, &model.OrganizationSettings{}, &model.AuditLog{}, &model.OrganizationExport{}, &model.AccountDeletion{}, &model.Attachment{}, &model.AttachmentVariant{}); err != nil {
+		return err
+	}
+
+	if err := BackfillSlugs(db); err != nil {
+		return err
+	}
+	if err := BackfillContentHTML(db); err != nil {
+		return err
+	}
+	if err := BackfillPublishedAt(db); err != nil {
+		return err
+	}
+	if err := BackfillOwners(db); err != nil {
+		return err
+	}
+	if err := DemoteLegacyAdmins(db); err != nil {
+		return err
+	}
+	if err := ProtectAuditLog(db); err != nil {
+		return err
+	}
+	if err := SeedPlans(db); err != nil {
+		return err
+	}
+	return BackfillUsage(db)
+}
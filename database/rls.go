@@ -0,0 +1,122 @@
+package database
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// TenantRole is the database role tenant-scoped transactions switch to. It
+// is not a superuser and does not own the tables, so row-level security
+// applies to it even when the application connects as the table owner.
+const TenantRole = "app_tenant"
+
+// CurrentOrgSetting is the session variable that row-level security policies
+// compare organization_id against.
+const CurrentOrgSetting = "app.current_org"
+
+// orgScopedTables carry an organization_id column. Rows of audit_logs and
+// role_changes without an organization record platform-wide events and are
+// only visible outside tenant-scoped transactions.
+var orgScopedTables = []string{
+	"articles", "teams", "policy_rules", "organization_roles", "attachments", "tags", "categories",
+	"organization_domains", "organization_settings", "organization_usages", "organization_exports",
+	"organization_deletions", "user_organizations", "audit_logs", "role_changes",
+}
+
+// slug_redirects scopes article slugs by organization, while redirects of
+// renamed organizations use organization_id 0 and stay visible to everyone:
+// they are resolved before the organization is known.
+const slugRedirectsTable = "slug_redirects"
+
+// articleScopedTables inherit their tenant from the article they belong to.
+var articleScopedTables = []string{"comments", "article_collaborators", "article_tags"}
+
+// ConfigureRowLevelSecurity installs or removes the tenant isolation policies.
+// Rows are only visible when they belong to the organization in
+// app.current_org. When the variable is unset, e.g. on routes that are not
+// tenant-scoped, the policies do not restrict anything.
+func ConfigureRowLevelSecurity(db *gorm.DB, enabled bool) error {
+	if !enabled {
+		for _, table := range append(append(orgScopedTables, articleScopedTables...), slugRedirectsTable) {
+			if err := db.Exec(fmt.Sprintf("ALTER TABLE %s DISABLE ROW LEVEL SECURITY", table)).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := ensureTenantRole(db); err != nil {
+		return err
+	}
+
+	currentOrg := fmt.Sprintf("current_setting('%s', true)", CurrentOrgSetting)
+	unset := fmt.Sprintf("coalesce(%s, '') = ''", currentOrg)
+
+	for _, table := range orgScopedTables {
+		check := fmt.Sprintf("%s OR organization_id = (%s)::bigint", unset, currentOrg)
+		if err := installPolicy(db, table, check); err != nil {
+			return err
+		}
+	}
+
+	redirectCheck := fmt.Sprintf("%s OR organization_id = 0 OR organization_id = (%s)::bigint", unset, currentOrg)
+	if err := installPolicy(db, slugRedirectsTable, redirectCheck); err != nil {
+		return err
+	}
+
+	for _, table := range articleScopedTables {
+		// The articles policy also applies inside the subquery, so rows of
+		// articles from other tenants are filtered out.
+		check := fmt.Sprintf("%s OR EXISTS (SELECT 1 FROM articles WHERE articles.id = %s.article_id)", unset, table)
+		if err := installPolicy(db, table, check); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func ensureTenantRole(db *gorm.DB) error {
+	statements := []string{
+		fmt.Sprintf(`DO $$ BEGIN
+			IF NOT EXISTS (SELECT 1 FROM pg_roles WHERE rolname = '%[1]s') THEN
+				CREATE ROLE %[1]s NOLOGIN;
+			END IF;
+		END $$`, TenantRole),
+		fmt.Sprintf("GRANT %s TO CURRENT_USER", TenantRole),
+		fmt.Sprintf("GRANT USAGE ON SCHEMA public TO %s", TenantRole),
+		fmt.Sprintf("GRANT SELECT, INSERT, UPDATE, DELETE ON ALL TABLES IN SCHEMA public TO %s", TenantRole),
+		fmt.Sprintf("GRANT USAGE, SELECT ON ALL SEQUENCES IN SCHEMA public TO %s", TenantRole),
+	}
+	for _, stmt := range statements {
+		if err := db.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func installPolicy(db *gorm.DB, table, check string) error {
+	statements := []string{
+		fmt.Sprintf("ALTER TABLE %s ENABLE ROW LEVEL SECURITY", table),
+		fmt.Sprintf("ALTER TABLE %s FORCE ROW LEVEL SECURITY", table),
+		fmt.Sprintf("DROP POLICY IF EXISTS tenant_isolation ON %s", table),
+		fmt.Sprintf("CREATE POLICY tenant_isolation ON %s USING (%s) WITH CHECK (%s)", table, check, check),
+	}
+	for _, stmt := range statements {
+		if err := db.Exec(stmt).Error; err != nil {
+			return fmt.Errorf("install row-level security on %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+// ScopeToOrganization prepares tx for tenant-scoped work: it drops to the
+// tenant role and sets app.current_org for the rest of the transaction.
+func ScopeToOrganization(tx *gorm.DB, orgID uint) error {
+	if err := tx.Exec(fmt.Sprintf("SET LOCAL ROLE %s", TenantRole)).Error; err != nil {
+		return err
+	}
+	return tx.Exec("SELECT set_config(?, ?, true)", CurrentOrgSetting, fmt.Sprintf("%d", orgID)).Error
+}
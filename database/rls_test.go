@@ -0,0 +1,107 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/repository"
+)
+
+// TestRowLevelSecurityIsolatesTenants needs a Postgres database it may
+// migrate, e.g.
+//
+//	TEST_DATABASE_DSN="host=localhost user=pg password=gp dbname=mtba port=5432 sslmode=disable" go test ./database/
+func TestRowLevelSecurityIsolatesTenants(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN not set")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, Migrate(db))
+	require.NoError(t, ConfigureRowLevelSecurity(db, true))
+
+	suffix := time.Now().UnixNano()
+	author := &model.User{Name: "RLS Author", Email: fmt.Sprintf("rls%d@test.com", suffix)}
+	require.NoError(t, db.Create(author).Error)
+
+	orgA := &model.Organization{Name: fmt.Sprintf("RLS Tenant A %d", suffix)}
+	orgB := &model.Organization{Name: fmt.Sprintf("RLS Tenant B %d", suffix)}
+	require.NoError(t, db.Create(orgA).Error)
+	require.NoError(t, db.Create(orgB).Error)
+
+	articleA := &model.Article{Title: "A", Content: "tenant A", OrganizationID: orgA.ID, UserID: author.ID}
+	articleB := &model.Article{Title: "B", Content: "tenant B", OrganizationID: orgB.ID, UserID: author.ID}
+	require.NoError(t, db.Create(articleA).Error)
+	require.NoError(t, db.Create(articleB).Error)
+
+	commentB := &model.Comment{Content: "on B", ArticleID: articleB.ID, AuthorID: author.ID}
+	require.NoError(t, db.Create(commentB).Error)
+
+	require.NoError(t, db.Create(&model.UserOrganization{UserID: author.ID, OrganizationID: orgA.ID, Role: model.RoleMember}).Error)
+	require.NoError(t, db.Create(&model.UserOrganization{UserID: author.ID, OrganizationID: orgB.ID, Role: model.RoleMember}).Error)
+	renamedOrg := &model.SlugRedirect{Resource: model.SlugResourceOrganization, Slug: fmt.Sprintf("rls-old-%d", suffix), TargetID: orgB.ID}
+	require.NoError(t, db.Create(renamedOrg).Error)
+
+	tx := db.Begin()
+	require.NoError(t, tx.Error)
+	defer tx.Rollback()
+	require.NoError(t, ScopeToOrganization(tx, orgA.ID))
+
+	t.Run("Unfiltered Query Only Sees Own Tenant", func(t *testing.T) {
+		var articles []model.Article
+		require.NoError(t, tx.Find(&articles).Error)
+		assert.NotEmpty(t, articles)
+		for _, article := range articles {
+			assert.Equal(t, orgA.ID, article.OrganizationID)
+		}
+	})
+
+	t.Run("Repository Without Organization Filter Only Sees Own Tenant", func(t *testing.T) {
+		ctx := repository.ContextWithTx(context.Background(), tx)
+		articles, err := repository.NewArticleRepository(db, repository.TenantStorage{}).GetAllArticles(ctx)
+		require.NoError(t, err)
+		for _, article := range articles {
+			assert.Equal(t, orgA.ID, article.OrganizationID)
+		}
+
+		_, err = repository.NewArticleRepository(db, repository.TenantStorage{}).GetArticleByID(ctx, articleB.ID)
+		assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+	})
+
+	t.Run("Comments Of Other Tenants Are Hidden", func(t *testing.T) {
+		var comment model.Comment
+		err := tx.First(&comment, commentB.ID).Error
+		assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+	})
+
+	t.Run("Memberships Of Other Tenants Are Hidden", func(t *testing.T) {
+		var memberships []model.UserOrganization
+		require.NoError(t, tx.Where("user_id = ?", author.ID).Find(&memberships).Error)
+		require.Len(t, memberships, 1)
+		assert.Equal(t, orgA.ID, memberships[0].OrganizationID)
+	})
+
+	t.Run("Organization Slug Redirects Stay Visible", func(t *testing.T) {
+		var redirect model.SlugRedirect
+		assert.NoError(t, tx.First(&redirect, renamedOrg.ID).Error)
+	})
+
+	t.Run("Writes Into Other Tenants Are Rejected", func(t *testing.T) {
+		err := tx.Transaction(func(nested *gorm.DB) error {
+			return nested.Create(&model.Article{Title: "X", OrganizationID: orgB.ID, UserID: author.ID}).Error
+		})
+		assert.Error(t, err)
+	})
+}
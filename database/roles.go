@@ -0,0 +1,40 @@
+package database
+
+import (
+	"log"
+
+	"gorm.io/gorm"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+)
+
+// DemoteLegacyAdmins resets the global role of users who made themselves
+// platform admins back when registration accepted a role. Admins a super
+// admin appointed have the grant in their role history and are kept.
+func DemoteLegacyAdmins(db *gorm.DB) error {
+	var userIDs []uint
+	if err := db.Model(&model.User{}).
+		Where("role = ?", model.RoleAdmin).
+		Where("NOT EXISTS (SELECT 1 FROM role_changes WHERE role_changes.user_id = users.id AND role_changes.organization_id IS NULL AND role_changes.new_role = ? AND role_changes.deleted_at IS NULL)", model.RoleAdmin).
+		Pluck("id", &userIDs).Error; err != nil {
+		return err
+	}
+
+	for _, userID := range userIDs {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&model.User{}).Where("id = ?", userID).Update("role", model.RoleMember).Error; err != nil {
+				return err
+			}
+			return tx.Create(&model.RoleChange{
+				UserID:  userID,
+				OldRole: model.RoleAdmin,
+				NewRole: model.RoleMember,
+			}).Error
+		})
+		if err != nil {
+			return err
+		}
+		log.Printf("Demoted self-assigned platform admin %d to member", userID)
+	}
+	return nil
+}
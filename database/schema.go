@@ -0,0 +1,204 @@
+package database
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/repository"
+)
+
+const (
+	TenancyShared = "shared"
+	TenancySchema = "schema"
+)
+
+// tenantModels are the tables that live in each tenant's own schema when
+// running schema-per-tenant. Users, organizations, memberships and tenant
+// configuration stay in the public schema, which is kept on the search_path
+// so tenant tables can still reference them.
+var tenantModels = []interface{}{&model.Tag{}, &model.Category{}, &model.Article{}, &model.Comment{}, &model.ArticleCollaborator{}, &model.Team{}}
+
+// tenantRows says which rows of each tenant table in the public schema
+// belong to an organization, parents before the rows referencing them. They
+// are where organizations keep their content in shared mode.
+var tenantRows = []struct {
+	table   string
+	belongs string
+}{
+	{"teams", "organization_id = ?"},
+	{"team_members", "team_id IN (SELECT id FROM public.teams WHERE organization_id = ?)"},
+	{"categories", "organization_id = ?"},
+	{"tags", "organization_id = ?"},
+	{"articles", "organization_id = ?"},
+	{"article_tags", "article_id IN (SELECT id FROM public.articles WHERE organization_id = ?)"},
+	{"comments", "article_id IN (SELECT id FROM public.articles WHERE organization_id = ?)"},
+	{"article_collaborators", "article_id IN (SELECT id FROM public.articles WHERE organization_id = ?)"},
+}
+
+func TenantSchemaName(orgID uint) string {
+	return fmt.Sprintf("tenant_%d", orgID)
+}
+
+// ProvisionTenantSchema creates the organization's schema, migrates the
+// tenant tables into it and moves over whatever the organization kept in
+// the public tenant tables. It is idempotent and meant to run inside a
+// transaction, so a failed provisioning leaves no half-created tenant behind.
+func ProvisionTenantSchema(tx *gorm.DB, org *model.Organization) error {
+	schema := TenantSchemaName(org.ID)
+	if org.SchemaName != schema {
+		org.SchemaName = schema
+		if err := tx.Model(org).Update("schema_name", schema).Error; err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Exec(fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %s", quoteIdentifier(schema))).Error; err != nil {
+		return fmt.Errorf("create schema %s: %w", schema, err)
+	}
+
+	if err := ScopeToSchema(tx, schema); err != nil {
+		return err
+	}
+	if err := tx.AutoMigrate(tenantModels...); err != nil {
+		return fmt.Errorf("migrate schema %s: %w", schema, err)
+	}
+	if err := moveSharedRows(tx, schema, org.ID); err != nil {
+		return fmt.Errorf("move content into schema %s: %w", schema, err)
+	}
+	if err := BackfillSlugs(tx); err != nil {
+		return fmt.Errorf("backfill slugs in schema %s: %w", schema, err)
+	}
+	if err := BackfillContentHTML(tx); err != nil {
+		return fmt.Errorf("render content in schema %s: %w", schema, err)
+	}
+	if err := BackfillPublishedAt(tx); err != nil {
+		return fmt.Errorf("date published articles in schema %s: %w", schema, err)
+	}
+	if err := repository.RecountUsage(tx, org.ID); err != nil {
+		return fmt.Errorf("count usage in schema %s: %w", schema, err)
+	}
+
+	// Let the tenant role used by row-level security work in this schema too.
+	grants := fmt.Sprintf(`DO $$ BEGIN
+		IF EXISTS (SELECT 1 FROM pg_roles WHERE rolname = '%[1]s') THEN
+			GRANT USAGE ON SCHEMA %[2]s TO %[1]s;
+			GRANT SELECT, INSERT, UPDATE, DELETE ON ALL TABLES IN SCHEMA %[2]s TO %[1]s;
+			GRANT USAGE, SELECT ON ALL SEQUENCES IN SCHEMA %[2]s TO %[1]s;
+		END IF;
+	END $$`, TenantRole, quoteIdentifier(schema))
+	if err := tx.Exec(grants).Error; err != nil {
+		return err
+	}
+
+	return tx.Exec("SET LOCAL search_path TO DEFAULT").Error
+}
+
+// moveSharedRows moves the organization's rows out of the public tenant
+// tables into its schema, keeping their IDs, so content written in shared
+// mode stays visible after switching to schema-per-tenant.
+func moveSharedRows(tx *gorm.DB, schema string, orgID uint) error {
+	for _, rows := range tenantRows {
+		var columns []string
+		if err := tx.Raw(`SELECT column_name FROM information_schema.columns
+			WHERE table_schema = ? AND table_name = ? AND column_name IN (
+				SELECT column_name FROM information_schema.columns WHERE table_schema = 'public' AND table_name = ?)
+			ORDER BY ordinal_position`, schema, rows.table, rows.table).Scan(&columns).Error; err != nil {
+			return err
+		}
+		if len(columns) == 0 {
+			continue
+		}
+
+		list := strings.Join(columns, ", ")
+		target := quoteIdentifier(schema) + "." + rows.table
+		insert := fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM public.%s WHERE %s", target, list, list, rows.table, rows.belongs)
+		if err := tx.Exec(insert, orgID).Error; err != nil {
+			return fmt.Errorf("copy %s: %w", rows.table, err)
+		}
+		// The copied IDs were drawn from the public sequence, so the
+		// schema's own one has to continue after them.
+		for _, column := range columns {
+			if column != "id" {
+				continue
+			}
+			if err := tx.Exec(fmt.Sprintf("SELECT setval(pg_get_serial_sequence('%s', 'id'), COALESCE((SELECT MAX(id) FROM %s), 0) + 1, false)",
+				target, target)).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	for i := len(tenantRows) - 1; i >= 0; i-- {
+		rows := tenantRows[i]
+		var exists bool
+		if err := tx.Raw("SELECT to_regclass(?) IS NOT NULL", "public."+rows.table).Scan(&exists).Error; err != nil {
+			return err
+		}
+		if !exists {
+			continue
+		}
+		if err := tx.Exec(fmt.Sprintf("DELETE FROM public.%s WHERE %s", rows.table, rows.belongs), orgID).Error; err != nil {
+			return fmt.Errorf("remove shared %s: %w", rows.table, err)
+		}
+	}
+	return nil
+}
+
+// MigrateTenantSchemas provisions and migrates the schema of every
+// organization, including soft-deleted ones that may still be restored.
+func MigrateTenantSchemas(db *gorm.DB) error {
+	var orgs []model.Organization
+	if err := db.Unscoped().Find(&orgs).Error; err != nil {
+		return err
+	}
+
+	for i := range orgs {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			return ProvisionTenantSchema(tx, &orgs[i])
+		})
+		if err != nil {
+			return fmt.Errorf("organization %d: %w", orgs[i].ID, err)
+		}
+		log.Printf("Migrated schema %s", orgs[i].SchemaName)
+	}
+	return nil
+}
+
+// ScopeToSchema points unqualified table names in tx at the tenant schema,
+// falling back to public for shared tables, until the transaction ends.
+func ScopeToSchema(tx *gorm.DB, schema string) error {
+	return tx.Exec(fmt.Sprintf("SET LOCAL search_path TO %s, public", quoteIdentifier(schema))).Error
+}
+
+// DropTenantSchema removes the organization's schema with everything in it.
+func DropTenantSchema(tx *gorm.DB, org *model.Organization) error {
+	if org.SchemaName == "" {
+		return nil
+	}
+	return tx.Exec(fmt.Sprintf("DROP SCHEMA IF EXISTS %s CASCADE", quoteIdentifier(org.SchemaName))).Error
+}
+
+// TenantStorage tells repositories doing maintenance outside tenant requests
+// where the tables of an organization live in the given tenancy mode.
+func TenantStorage(mode string) repository.TenantStorage {
+	if mode != TenancySchema {
+		return repository.TenantStorage{}
+	}
+	return repository.TenantStorage{
+		Scope: func(tx *gorm.DB, org *model.Organization) error {
+			if org.SchemaName == "" {
+				return fmt.Errorf("organization %d has no schema", org.ID)
+			}
+			return ScopeToSchema(tx, org.SchemaName)
+		},
+		Teardown: DropTenantSchema,
+	}
+}
+
+func quoteIdentifier(name string) string {
+	return `"` + name + `"`
+}
@@ -0,0 +1,123 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/repository"
+)
+
+// TestSchemaTenancyListsArticlesAcrossTenants needs a Postgres database it
+// may migrate, see TestRowLevelSecurityIsolatesTenants.
+func TestSchemaTenancyListsArticlesAcrossTenants(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN not set")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, Migrate(db))
+
+	suffix := time.Now().UnixNano()
+	author := &model.User{Name: "Schema Author", Email: fmt.Sprintf("schema%d@test.com", suffix)}
+	require.NoError(t, db.Create(author).Error)
+
+	var orgs []*model.Organization
+	for _, name := range []string{"A", "B"} {
+		org := &model.Organization{Name: fmt.Sprintf("Schema Tenant %s %d", name, suffix)}
+		require.NoError(t, db.Create(org).Error)
+		require.NoError(t, db.Transaction(func(tx *gorm.DB) error {
+			if err := ProvisionTenantSchema(tx, org); err != nil {
+				return err
+			}
+			if err := ScopeToSchema(tx, org.SchemaName); err != nil {
+				return err
+			}
+			return tx.Create(&model.Article{Title: "In " + name, Content: name, OrganizationID: org.ID, UserID: author.ID}).Error
+		}))
+		orgs = append(orgs, org)
+	}
+	defer func() {
+		for _, org := range orgs {
+			db.Exec(fmt.Sprintf("DROP SCHEMA IF EXISTS %s CASCADE", quoteIdentifier(org.SchemaName)))
+		}
+	}()
+
+	t.Run("Shared Storage Only Sees Public Tables", func(t *testing.T) {
+		articles, err := repository.NewArticleRepository(db, repository.TenantStorage{}).GetArticlesByUserID(context.Background(), author.ID)
+		require.NoError(t, err)
+		assert.Empty(t, articles)
+	})
+
+	t.Run("Schema Storage Sees Every Tenant", func(t *testing.T) {
+		articles, err := repository.NewArticleRepository(db, TenantStorage(TenancySchema)).GetArticlesByUserID(context.Background(), author.ID)
+		require.NoError(t, err)
+		require.Len(t, articles, 2)
+		assert.ElementsMatch(t, []uint{orgs[0].ID, orgs[1].ID}, []uint{articles[0].OrganizationID, articles[1].OrganizationID})
+		for _, article := range articles {
+			assert.Equal(t, author.ID, article.User.ID)
+		}
+	})
+}
+
+// TestSchemaTenancyKeepsSharedContent needs a Postgres database it may
+// migrate, see TestRowLevelSecurityIsolatesTenants.
+func TestSchemaTenancyKeepsSharedContent(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN not set")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, Migrate(db))
+
+	suffix := time.Now().UnixNano()
+	author := &model.User{Name: "Shared Author", Email: fmt.Sprintf("shared%d@test.com", suffix)}
+	require.NoError(t, db.Create(author).Error)
+	org := &model.Organization{Name: fmt.Sprintf("Shared Tenant %d", suffix)}
+	require.NoError(t, db.Create(org).Error)
+
+	article := &model.Article{Title: "Written Shared", Content: "kept", OrganizationID: org.ID, UserID: author.ID}
+	require.NoError(t, db.Create(article).Error)
+	require.NoError(t, db.Create(&model.Comment{ArticleID: article.ID, AuthorID: author.ID, Content: "also kept"}).Error)
+
+	require.NoError(t, db.Transaction(func(tx *gorm.DB) error {
+		return ProvisionTenantSchema(tx, org)
+	}))
+	defer db.Exec(fmt.Sprintf("DROP SCHEMA IF EXISTS %s CASCADE", quoteIdentifier(org.SchemaName)))
+
+	var shared int64
+	require.NoError(t, db.Unscoped().Model(&model.Article{}).Where("organization_id = ?", org.ID).Count(&shared).Error)
+	assert.Zero(t, shared)
+
+	require.NoError(t, db.Transaction(func(tx *gorm.DB) error {
+		if err := ScopeToSchema(tx, org.SchemaName); err != nil {
+			return err
+		}
+		var moved model.Article
+		if err := tx.Preload("Comments").First(&moved, article.ID).Error; err != nil {
+			return err
+		}
+		assert.Equal(t, "Written Shared", moved.Title)
+		require.Len(t, moved.Comments, 1)
+		assert.Equal(t, "also kept", moved.Comments[0].Content)
+
+		next := &model.Article{Title: "Written Later", OrganizationID: org.ID, UserID: author.ID}
+		if err := tx.Create(next).Error; err != nil {
+			return err
+		}
+		assert.Greater(t, next.ID, article.ID)
+		return nil
+	}))
+}
@@ -0,0 +1,51 @@
+package database
+
+import (
+	"gorm.io/gorm"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/slug"
+)
+
+// BackfillSlugs gives organizations and articles created before slugs
+// existed one generated from their name or title. Articles are looked up on
+// tx's search_path, so it also backfills a tenant schema when scoped to one.
+func BackfillSlugs(tx *gorm.DB) error {
+	var orgs []model.Organization
+	if err := tx.Unscoped().Where("slug = '' OR slug IS NULL").Find(&orgs).Error; err != nil {
+		return err
+	}
+	for _, org := range orgs {
+		orgSlug, err := slug.Unique(slug.Make(org.Name, "org"), func(candidate string) (bool, error) {
+			var count int64
+			err := tx.Unscoped().Model(&model.Organization{}).Where("slug = ?", candidate).Count(&count).Error
+			return count > 0, err
+		})
+		if err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&model.Organization{}).Where("id = ?", org.ID).Update("slug", orgSlug).Error; err != nil {
+			return err
+		}
+	}
+
+	var articles []model.Article
+	if err := tx.Unscoped().Where("slug = '' OR slug IS NULL").Find(&articles).Error; err != nil {
+		return err
+	}
+	for _, article := range articles {
+		articleSlug, err := slug.Unique(slug.Make(article.Title, "article"), func(candidate string) (bool, error) {
+			var count int64
+			err := tx.Unscoped().Model(&model.Article{}).
+				Where("organization_id = ? AND slug = ?", article.OrganizationID, candidate).Count(&count).Error
+			return count > 0, err
+		})
+		if err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&model.Article{}).Where("id = ?", article.ID).Update("slug", articleSlug).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/repository"
+)
+
+// TestRestoredOrganizationKeepsItsMembers needs a Postgres database it may
+// migrate, see TestRowLevelSecurityIsolatesTenants.
+func TestRestoredOrganizationKeepsItsMembers(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN not set")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, Migrate(db))
+
+	ctx := context.Background()
+	suffix := time.Now().UnixNano()
+	org := &model.Organization{Name: fmt.Sprintf("Trashed Tenant %d", suffix)}
+	require.NoError(t, db.Create(org).Error)
+	admin := &model.User{Name: "Admin", Email: fmt.Sprintf("trash-admin%d@test.com", suffix)}
+	member := &model.User{Name: "Member", Email: fmt.Sprintf("trash-member%d@test.com", suffix)}
+	require.NoError(t, db.Create(admin).Error)
+	require.NoError(t, db.Create(member).Error)
+	require.NoError(t, db.Create(&model.UserOrganization{UserID: admin.ID, OrganizationID: org.ID, Role: model.RoleAdmin}).Error)
+	require.NoError(t, db.Create(&model.UserOrganization{UserID: member.ID, OrganizationID: org.ID, Role: model.RoleMember}).Error)
+
+	require.NoError(t, repository.NewOrgRepository(db).DeleteOrganization(ctx, org.ID))
+	var members int64
+	require.NoError(t, db.Model(&model.UserOrganization{}).Where("organization_id = ?", org.ID).Count(&members).Error)
+	assert.Zero(t, members, "a deleted organization has no members")
+
+	_, err = repository.NewTrashRepository(db, repository.TenantStorage{}).RestoreOrganization(ctx, org.ID)
+	require.NoError(t, err)
+	var restored []model.UserOrganization
+	require.NoError(t, db.Where("organization_id = ?", org.ID).Order("user_id").Find(&restored).Error)
+	require.Len(t, restored, 2)
+	assert.Equal(t, model.RoleAdmin, restored[0].Role)
+	assert.Equal(t, model.RoleMember, restored[1].Role)
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/audit"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/middleware"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/privacy"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/repository"
+)
+
+type AccountHandler struct {
+	accountRepo repository.AccountRepository
+	gracePeriod time.Duration
+}
+
+func NewAccountHandler(accountRepo repository.AccountRepository, gracePeriod time.Duration) *AccountHandler {
+	return &AccountHandler{
+		accountRepo: accountRepo,
+		gracePeriod: gracePeriod,
+	}
+}
+
+type DeleteAccountRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// ExportProfile sends the caller everything held about them across all
+// organizations as a zip archive.
+func (h *AccountHandler) ExportProfile(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var archive bytes.Buffer
+	if err := privacy.WriteUserArchive(c.Request.Context(), &archive, h.accountRepo, userID.(uint)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export data"})
+		return
+	}
+	audit.Record(c, audit.Entry{Action: "user.export", TargetType: "user", TargetID: userID.(uint)})
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="user-%d-data.zip"`, userID.(uint)))
+	c.Data(http.StatusOK, "application/zip", archive.Bytes())
+}
+
+// RequestDeletion schedules the caller's account for deletion after the
+// grace period. Owners have to hand over or delete their organizations
+// first.
+func (h *AccountHandler) RequestDeletion(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+	userModel := user.(*model.User)
+
+	var req DeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !middleware.CheckPassword(userModel.Password, req.Password) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid password"})
+		return
+	}
+
+	deletion, err := h.accountRepo.RequestDeletion(c.Request.Context(), userModel.ID, time.Now().Add(h.gracePeriod))
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrOwnsOrganization):
+			owned, _ := h.accountRepo.GetOwnedOrganizations(c.Request.Context(), userModel.ID)
+			organizations := make([]gin.H, 0, len(owned))
+			for _, org := range owned {
+				organizations = append(organizations, gin.H{"id": org.ID, "name": org.Name})
+			}
+			c.JSON(http.StatusConflict, gin.H{
+				"error":         "Transfer ownership of or delete your organizations first",
+				"organizations": organizations,
+			})
+		case errors.Is(err, repository.ErrDeletionPending):
+			c.JSON(http.StatusConflict, gin.H{"error": "Account deletion is already pending"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to schedule account deletion"})
+		}
+		return
+	}
+	audit.Record(c, audit.Entry{Action: "user.deletion_request", TargetType: "account_deletion", TargetID: deletion.ID, After: deletion})
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":  "Account deletion scheduled",
+		"deletion": deletion,
+	})
+}
+
+func (h *AccountHandler) GetDeletion(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	deletion, err := h.accountRepo.GetLatestDeletion(c.Request.Context(), userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No account deletion requested"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"deletion": deletion,
+	})
+}
+
+// CancelDeletion keeps the account when called within the grace period.
+func (h *AccountHandler) CancelDeletion(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	deletion, err := h.accountRepo.CancelDeletion(c.Request.Context(), userID.(uint))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No pending account deletion"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel account deletion"})
+		return
+	}
+	audit.Record(c, audit.Entry{Action: "user.deletion_cancel", TargetType: "account_deletion", TargetID: deletion.ID})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Account deletion cancelled",
+		"deletion": deletion,
+	})
+}
@@ -1,39 +1,90 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/gin-gonic/gin"
 
 	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/audit"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/markup"
 	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/middleware"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/policy"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/settings"
 	"github.com/adityadeshlahre/multi-tenant-backend-app/repository"
 )
 
 type ArticleHandler struct {
-	articleRepo repository.ArticleRepository
+	articleRepo      repository.ArticleRepository
+	collaboratorRepo repository.CollaboratorRepository
+	teamRepo         repository.TeamRepository
+	categoryRepo     repository.CategoryRepository
 }
 
-func NewArticleHandler(articleRepo repository.ArticleRepository) *ArticleHandler {
+func NewArticleHandler(articleRepo repository.ArticleRepository, collaboratorRepo repository.CollaboratorRepository, teamRepo repository.TeamRepository, categoryRepo repository.CategoryRepository) *ArticleHandler {
 	return &ArticleHandler{
-		articleRepo: articleRepo,
+		articleRepo:      articleRepo,
+		collaboratorRepo: collaboratorRepo,
+		teamRepo:         teamRepo,
+		categoryRepo:     categoryRepo,
 	}
 }
 
+// CreateArticleRequest takes an optional slug; without one it is generated
+// from the title. Content is Markdown unless content_format says otherwise.
+// Tags are given by name; ones the organization does not have yet are
+// created.
 type CreateArticleRequest struct {
-	Title   string `json:"title" binding:"required"`
-	Content string `json:"content" binding:"required"`
-	Status  string `json:"status"`
+	Title         string   `json:"title" binding:"required"`
+	Slug          string   `json:"slug"`
+	Content       string   `json:"content" binding:"required"`
+	ContentFormat string   `json:"content_format"`
+	Status        string   `json:"status"`
+	Visibility    string   `json:"visibility"`
+	TeamID        *uint    `json:"team_id"`
+	CategoryID    *uint    `json:"category_id"`
+	Tags          []string `json:"tags"`
 }
 
+// UpdateArticleRequest leaves fields that are not set unchanged. A team_id or
+// category_id of 0 removes the article from its team or category, and an
+// empty tags list removes its tags. A new slug keeps the old one redirecting
+// to the article.
 type UpdateArticleRequest struct {
-	Title   string `json:"title"`
-	Content string `json:"content"`
-	Status  string `json:"status"`
+	Title         string   `json:"title"`
+	Slug          string   `json:"slug"`
+	Content       string   `json:"content"`
+	ContentFormat string   `json:"content_format"`
+	Status        string   `json:"status"`
+	Visibility    string   `json:"visibility"`
+	TeamID        *uint    `json:"team_id"`
+	CategoryID    *uint    `json:"category_id"`
+	Tags          []string `json:"tags"`
 }
 
+const (
+	invalidContentFormatMessage = "Content format must be markdown, html or plain"
+	invalidVisibilityMessage    = "Visibility must be public, organization or private"
+)
+
+const (
+	maxArticleTags = 20
+	maxTagName     = 50
+)
+
+var invalidTagNameMessage = fmt.Sprintf("Tag names must be 1 to %d characters", maxTagName)
+
 func (h *ArticleHandler) CreateArticle(c *gin.Context) {
+	if !middleware.CanInOrganization(c, policy.ActionCreateArticle) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to create articles in this organization"})
+		return
+	}
+
 	var req CreateArticleRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -57,20 +108,66 @@ func (h *ArticleHandler) CreateArticle(c *gin.Context) {
 	if req.Status == "" {
 		req.Status = "draft"
 	}
+	if req.ContentFormat == "" {
+		req.ContentFormat = model.ContentFormatMarkdown
+	}
+	if !markup.ValidFormat(req.ContentFormat) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": invalidContentFormatMessage})
+		return
+	}
+	if req.Visibility == "" {
+		req.Visibility = middleware.GetSettingsFromContext(c).DefaultArticleVisibility
+	}
+	if !validVisibility(req.Visibility) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": invalidVisibilityMessage})
+		return
+	}
+
+	if req.TeamID != nil && !h.canAssignTeam(c, orgModel.ID, *req.TeamID) {
+		return
+	}
+
+	if req.CategoryID != nil && !h.canAssignCategory(c, orgModel.ID, *req.CategoryID) {
+		return
+	}
+
+	if req.Slug != "" && !validSlug(req.Slug) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": invalidSlugMessage})
+		return
+	}
+
+	tags, ok := articleTags(c, req.Tags)
+	if !ok {
+		return
+	}
 
 	article := &model.Article{
 		Title:          req.Title,
+		Slug:           req.Slug,
 		Content:        req.Content,
+		ContentFormat:  req.ContentFormat,
 		Status:         req.Status,
+		Visibility:     req.Visibility,
 		UserID:         userID.(uint),
 		OrganizationID: orgModel.ID,
+		TeamID:         req.TeamID,
+		CategoryID:     req.CategoryID,
+		Tags:           tags,
 	}
 
 	createdArticle, err := h.articleRepo.CreateArticle(c.Request.Context(), article)
+	if errors.Is(err, repository.ErrSlugTaken) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Slug is already taken"})
+		return
+	}
+	if respondQuotaExceeded(c, err) {
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create article"})
 		return
 	}
+	audit.Record(c, audit.Entry{Action: "article.create", TargetType: "article", TargetID: createdArticle.ID, After: createdArticle})
 
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "Article created successfully",
@@ -106,23 +203,67 @@ func (h *ArticleHandler) GetAllArticles(c *gin.Context) {
 	}
 
 	orgModel := org.(*model.Organization)
-	articles, err := h.articleRepo.GetArticlesByOrganization(c.Request.Context(), orgModel.ID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch articles"})
-		return
-	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"articles": articles,
-	})
-}
-
-func (h *ArticleHandler) GetPublishedArticles(c *gin.Context) {
-	articles, err := h.articleRepo.GetPublishedArticles(c.Request.Context())
+	var filter repository.ArticleFilter
+	if teamIDStr := c.Query("team_id"); teamIDStr != "" {
+		teamID, err := strconv.ParseUint(teamIDStr, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid team ID"})
+			return
+		}
+		filter.TeamIDs = []uint{uint(teamID)}
+	} else if c.Query("my_teams") == "true" {
+		userID, _ := c.Get("userID")
+		teamIDs, err := h.teamRepo.GetTeamIDsForUser(c.Request.Context(), orgModel.ID, userID.(uint))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch articles"})
+			return
+		}
+		filter.TeamIDs = teamIDs
+	}
+	if categoryIDStr := c.Query("category_id"); categoryIDStr != "" {
+		categoryID, err := strconv.ParseUint(categoryIDStr, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category ID"})
+			return
+		}
+		// Articles filed in subcategories are in the category too.
+		filter.CategoryIDs, err = h.categoryRepo.GetCategorySubtreeIDs(c.Request.Context(), uint(categoryID))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch articles"})
+			return
+		}
+	}
+	filter.Tags = c.QueryArray("tag")
+
+	articles, err := h.articleRepo.GetArticlesByOrganization(c.Request.Context(), orgModel.ID, filter)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch published articles"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch articles"})
 		return
 	}
+	// Collaborator grants and team ownership are only loaded when the policy
+	// alone does not list an article, and then once for the whole listing.
+	p := middleware.GetPolicyFromContext(c)
+	sub := middleware.CurrentSubject(c)
+	var access *middleware.ArticleAccess
+	listed := articles[:0]
+	for i := range articles {
+		article := &articles[i]
+		if !listedFor(article, p.Allowed(sub, middleware.ArticleResource(article))) {
+			if access == nil {
+				access, err = middleware.LoadArticleAccess(c, h.collaboratorRepo, h.teamRepo, orgModel.ID)
+				if err != nil {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch articles"})
+					return
+				}
+			}
+			if !listedFor(article, access.Actions(c, article)) {
+				continue
+			}
+		}
+		listed = append(listed, *article)
+	}
+	articles = listed
 
 	c.JSON(http.StatusOK, gin.H{
 		"articles": articles,
@@ -147,21 +288,80 @@ func (h *ArticleHandler) UpdateArticle(c *gin.Context) {
 		return
 	}
 
+	before := *article
+	// Tags are only saved when the request sets them.
+	article.Tags = nil
+	if req.Tags != nil {
+		tags, ok := articleTags(c, req.Tags)
+		if !ok {
+			return
+		}
+		article.Tags = tags
+	}
 	if req.Title != "" {
 		article.Title = req.Title
 	}
+	if req.Slug != "" {
+		if !validSlug(req.Slug) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": invalidSlugMessage})
+			return
+		}
+		article.Slug = req.Slug
+	}
 	if req.Content != "" {
 		article.Content = req.Content
 	}
+	if req.ContentFormat != "" {
+		if !markup.ValidFormat(req.ContentFormat) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": invalidContentFormatMessage})
+			return
+		}
+		article.ContentFormat = req.ContentFormat
+	}
 	if req.Status != "" {
 		article.Status = req.Status
 	}
+	if req.Visibility != "" {
+		if !validVisibility(req.Visibility) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": invalidVisibilityMessage})
+			return
+		}
+		article.Visibility = req.Visibility
+	}
+	if req.TeamID != nil {
+		if *req.TeamID == 0 {
+			article.TeamID = nil
+		} else {
+			if !h.canAssignTeam(c, article.OrganizationID, *req.TeamID) {
+				return
+			}
+			article.TeamID = req.TeamID
+		}
+	}
+	if req.CategoryID != nil {
+		if *req.CategoryID == 0 {
+			article.CategoryID = nil
+		} else {
+			if !h.canAssignCategory(c, article.OrganizationID, *req.CategoryID) {
+				return
+			}
+			article.CategoryID = req.CategoryID
+		}
+	}
 
 	updatedArticle, err := h.articleRepo.UpdateArticle(c.Request.Context(), article)
+	if errors.Is(err, repository.ErrSlugTaken) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Slug is already taken"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update article"})
 		return
 	}
+	if req.Tags == nil {
+		updatedArticle.Tags = before.Tags
+	}
+	audit.Record(c, audit.Entry{Action: "article.update", TargetType: "article", TargetID: updatedArticle.ID, Before: &before, After: updatedArticle})
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Article updated successfully",
@@ -170,8 +370,8 @@ func (h *ArticleHandler) UpdateArticle(c *gin.Context) {
 }
 
 func (h *ArticleHandler) DeleteArticle(c *gin.Context) {
-	if !middleware.IsArticleOwner(c) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Only the article owner can delete this article"})
+	if !middleware.CanDeleteArticle(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to delete this article"})
 		return
 	}
 
@@ -186,6 +386,7 @@ func (h *ArticleHandler) DeleteArticle(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete article"})
 		return
 	}
+	audit.Record(c, audit.Entry{Action: "article.delete", TargetType: "article", TargetID: article.ID, Before: article})
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Article deleted successfully",
@@ -215,6 +416,10 @@ func (h *ArticleHandler) CreateComment(c *gin.Context) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to comment on this article"})
 		return
 	}
+	if middleware.GetSettingsFromContext(c).CommentPolicy == settings.CommentsClosed {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Comments are closed in this organization"})
+		return
+	}
 
 	article, exists := middleware.GetArticleFromContext(c)
 	if !exists {
@@ -248,6 +453,7 @@ func (h *ArticleHandler) CreateComment(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create comment"})
 		return
 	}
+	audit.Record(c, audit.Entry{Action: "comment.create", TargetType: "comment", TargetID: createdComment.ID, After: createdComment})
 
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "Comment created successfully",
@@ -279,16 +485,13 @@ func (h *ArticleHandler) GetComments(c *gin.Context) {
 }
 
 func (h *ArticleHandler) UpdateComment(c *gin.Context) {
-	commentIDStr := c.Param("commentId")
-	commentID, err := strconv.ParseUint(commentIDStr, 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid comment ID"})
+	comment, ok := h.commentFromParam(c)
+	if !ok {
 		return
 	}
 
-	userID, exists := c.Get("userID")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+	if !middleware.CanOnComment(c, policy.ActionEdit, comment) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to edit this comment"})
 		return
 	}
 
@@ -301,17 +504,15 @@ func (h *ArticleHandler) UpdateComment(c *gin.Context) {
 		return
 	}
 
-	comment := &model.Comment{
-		Content:  req.Content,
-		AuthorID: userID.(uint),
-	}
-	comment.ID = uint(commentID)
+	before := *comment
+	comment.Content = req.Content
 
 	updatedComment, err := h.articleRepo.UpdateComment(c.Request.Context(), comment)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update comment"})
 		return
 	}
+	audit.Record(c, audit.Entry{Action: "comment.update", TargetType: "comment", TargetID: updatedComment.ID, Before: &before, After: updatedComment})
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Comment updated successfully",
@@ -320,25 +521,132 @@ func (h *ArticleHandler) UpdateComment(c *gin.Context) {
 }
 
 func (h *ArticleHandler) DeleteComment(c *gin.Context) {
-	commentIDStr := c.Param("commentId")
-	commentID, err := strconv.ParseUint(commentIDStr, 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid comment ID"})
+	comment, ok := h.commentFromParam(c)
+	if !ok {
 		return
 	}
 
-	if !middleware.IsArticleOwner(c) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Only the article owner can delete comments"})
+	if !middleware.CanModerateComments(c) && !middleware.CanOnComment(c, policy.ActionDelete, comment) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to delete this comment"})
 		return
 	}
 
-	err = h.articleRepo.DeleteComment(c.Request.Context(), uint(commentID))
+	err := h.articleRepo.DeleteComment(c.Request.Context(), comment.ID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete comment"})
 		return
 	}
+	audit.Record(c, audit.Entry{Action: "comment.delete", TargetType: "comment", TargetID: comment.ID, Before: comment})
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Comment deleted successfully",
 	})
 }
+
+// commentFromParam loads the :commentId comment and makes sure it belongs to
+// the article in context. It writes the error response itself.
+func (h *ArticleHandler) commentFromParam(c *gin.Context) (*model.Comment, bool) {
+	commentID, err := strconv.ParseUint(c.Param("commentId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid comment ID"})
+		return nil, false
+	}
+
+	article, exists := middleware.GetArticleFromContext(c)
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Article not found in context"})
+		return nil, false
+	}
+
+	comment, err := h.articleRepo.GetCommentByID(c.Request.Context(), uint(commentID))
+	if err != nil || comment.ArticleID != article.ID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
+		return nil, false
+	}
+
+	return comment, true
+}
+
+// canAssignTeam checks that a team exists in the organization and that the
+// caller may hand articles to it: team members and team managers can. It
+// writes the error response itself.
+func (h *ArticleHandler) canAssignTeam(c *gin.Context, orgID, teamID uint) bool {
+	team, err := h.teamRepo.GetTeamByID(c.Request.Context(), teamID)
+	if err != nil || team.OrganizationID != orgID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Team not found in this organization"})
+		return false
+	}
+
+	if middleware.CanInOrganization(c, policy.ActionManageTeams) {
+		return true
+	}
+
+	userID, _ := c.Get("userID")
+	isMember, err := h.teamRepo.IsTeamMember(c.Request.Context(), teamID, userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check team membership"})
+		return false
+	}
+	if !isMember {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You are not a member of this team"})
+		return false
+	}
+	return true
+}
+
+func (h *ArticleHandler) canAssignCategory(c *gin.Context, orgID, categoryID uint) bool {
+	category, err := h.categoryRepo.GetCategoryByID(c.Request.Context(), categoryID)
+	if err != nil || category.OrganizationID != orgID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Category not found in this organization"})
+		return false
+	}
+	return true
+}
+
+// articleTags turns the tag names of a request into tags for the
+// repository to look up or create. It writes the error response itself.
+func articleTags(c *gin.Context, names []string) ([]model.Tag, bool) {
+	if names == nil {
+		return nil, true
+	}
+	if len(names) > maxArticleTags {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("An article can have at most %d tags", maxArticleTags)})
+		return nil, false
+	}
+
+	tags := make([]model.Tag, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" || utf8.RuneCountInString(name) > maxTagName {
+			c.JSON(http.StatusBadRequest, gin.H{"error": invalidTagNameMessage})
+			return nil, false
+		}
+		tags = append(tags, model.Tag{Name: name})
+	}
+	return tags, true
+}
+
+func validVisibility(visibility string) bool {
+	switch visibility {
+	case model.VisibilityPublic, model.VisibilityOrganization, model.VisibilityPrivate:
+		return true
+	}
+	return false
+}
+
+// listedFor reports whether the article shows up when a caller who may do
+// actions with it lists the organization's articles. They have to be allowed
+// to view it, and private articles are unlisted for everyone but those who
+// can edit them.
+func listedFor(article *model.Article, actions []string) bool {
+	var view, edit bool
+	for _, action := range actions {
+		switch action {
+		case policy.ActionView:
+			view = true
+		case policy.ActionEdit:
+			edit = true
+		}
+	}
+	return view && (article.Visibility != model.VisibilityPrivate || edit)
+}
@@ -0,0 +1,348 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/audit"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/media"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/middleware"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/storage"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/repository"
+)
+
+// multipartOverhead is allowed on top of the maximum file size for the
+// rest of an upload request's body.
+const multipartOverhead = 1 << 20
+
+type AttachmentHandler struct {
+	attachmentRepo repository.AttachmentRepository
+	store          storage.Storage
+	signer         *media.Signer
+	processor      *media.Processor
+	maxSize        int64
+}
+
+func NewAttachmentHandler(attachmentRepo repository.AttachmentRepository, store storage.Storage, signer *media.Signer, processor *media.Processor, maxSize int64) *AttachmentHandler {
+	return &AttachmentHandler{
+		attachmentRepo: attachmentRepo,
+		store:          store,
+		signer:         signer,
+		processor:      processor,
+		maxSize:        maxSize,
+	}
+}
+
+// AttachmentResponse is an attachment together with signed URLs it and its
+// image variants can be downloaded from for a limited time. WebP variants
+// are listed by their name with a ".webp" suffix.
+type AttachmentResponse struct {
+	model.Attachment
+	URL         string            `json:"url"`
+	VariantURLs map[string]string `json:"variant_urls,omitempty"`
+}
+
+// UploadAttachment stores the file sent in the "file" field of a multipart
+// form and attaches it to the article. The content type is sniffed from
+// the file itself and has to be one of the allowed ones. Metadata is
+// stripped from images, which are then processed in the background.
+func (h *AttachmentHandler) UploadAttachment(c *gin.Context) {
+	if !middleware.CanEditArticle(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to add attachments to this article"})
+		return
+	}
+
+	article, exists := middleware.GetArticleFromContext(c)
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Article not found in context"})
+		return
+	}
+	userID, _ := c.Get("userID")
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxSize+multipartOverhead)
+	header, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			h.respondTooLarge(c)
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A file is required"})
+		return
+	}
+	if header.Size > h.maxSize {
+		h.respondTooLarge(c)
+		return
+	}
+	if header.Size == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The file is empty"})
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file"})
+		return
+	}
+	defer file.Close()
+
+	head := make([]byte, media.SniffLength)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file"})
+		return
+	}
+	contentType, allowed := media.Sniff(head[:n])
+	if !allowed {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": fmt.Sprintf("Files of type %s cannot be attached", contentType)})
+		return
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read file"})
+		return
+	}
+
+	var body io.Reader = file
+	size := header.Size
+	if media.CanStripMetadata(contentType) {
+		data, err := io.ReadAll(file)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file"})
+			return
+		}
+		if data, err = media.StripMetadata(contentType, data); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "The image is malformed"})
+			return
+		}
+		body, size = bytes.NewReader(data), int64(len(data))
+	}
+
+	key, err := media.NewKey()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store file"})
+		return
+	}
+	checksum := sha256.New()
+	if err := h.store.Put(c.Request.Context(), key, io.TeeReader(body, checksum), size, contentType); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store file"})
+		return
+	}
+
+	attachment := &model.Attachment{
+		OrganizationID: article.OrganizationID,
+		ArticleID:      article.ID,
+		UploadedBy:     userID.(uint),
+		FileName:       media.CleanFileName(header.Filename),
+		ContentType:    contentType,
+		Size:           size,
+		Checksum:       hex.EncodeToString(checksum.Sum(nil)),
+		StorageKey:     key,
+	}
+	if media.Processable(contentType) {
+		attachment.ProcessingStatus = model.ProcessingPending
+	}
+	attachment, err = h.attachmentRepo.CreateAttachment(c.Request.Context(), attachment)
+	if err != nil {
+		h.store.Delete(c.Request.Context(), key)
+		if respondQuotaExceeded(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create attachment"})
+		return
+	}
+	audit.Record(c, audit.Entry{Action: "attachment.create", TargetType: "attachment", TargetID: attachment.ID, After: attachment})
+	if attachment.ProcessingStatus == model.ProcessingPending {
+		h.processor.Enqueue(attachment.ID)
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":    "Attachment uploaded successfully",
+		"attachment": h.response(attachment),
+	})
+}
+
+func (h *AttachmentHandler) GetAttachments(c *gin.Context) {
+	if !middleware.CanViewArticle(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to view this article"})
+		return
+	}
+
+	article, exists := middleware.GetArticleFromContext(c)
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Article not found in context"})
+		return
+	}
+
+	attachments, err := h.attachmentRepo.GetAttachments(c.Request.Context(), article.OrganizationID, article.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch attachments"})
+		return
+	}
+
+	responses := make([]AttachmentResponse, 0, len(attachments))
+	for i := range attachments {
+		responses = append(responses, h.response(&attachments[i]))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"attachments": responses,
+	})
+}
+
+func (h *AttachmentHandler) GetAttachment(c *gin.Context) {
+	if !middleware.CanViewArticle(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to view this article"})
+		return
+	}
+
+	attachment, ok := h.loadAttachment(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"attachment": h.response(attachment),
+	})
+}
+
+func (h *AttachmentHandler) DeleteAttachment(c *gin.Context) {
+	if !middleware.CanEditArticle(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to remove attachments from this article"})
+		return
+	}
+
+	attachment, ok := h.loadAttachment(c)
+	if !ok {
+		return
+	}
+
+	if err := h.attachmentRepo.DeleteAttachment(c.Request.Context(), attachment); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete attachment"})
+		return
+	}
+	audit.Record(c, audit.Entry{Action: "attachment.delete", TargetType: "attachment", TargetID: attachment.ID, Before: attachment})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Attachment deleted successfully",
+	})
+}
+
+// DownloadAttachment streams an attachment to whoever holds a valid signed
+// URL for it. It needs no API token, so links can be embedded in articles
+// and handed to browsers directly. For images, ?variant= picks a resized
+// copy, in WebP with ?format=webp; the original is sent while it is still
+// being processed or when it is smaller than the variant.
+func (h *AttachmentHandler) DownloadAttachment(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("attachmentId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attachment ID"})
+		return
+	}
+	if !h.signer.Verify(uint(id), c.Query("expires"), c.Query("signature"), time.Now()) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "The download link is invalid or has expired"})
+		return
+	}
+
+	variantName := c.Query("variant")
+	if variantName != "" && !media.LookupVariant(variantName) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown variant"})
+		return
+	}
+	webp := c.Query("format") == media.FormatWebP
+	if format := c.Query("format"); format != "" && !webp {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown format"})
+		return
+	}
+
+	attachment, err := h.attachmentRepo.GetAttachment(c.Request.Context(), uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attachment not found"})
+		return
+	}
+	key, size, contentType := attachment.StorageKey, attachment.Size, attachment.ContentType
+	for _, variant := range attachment.Variants {
+		if variant.Name == variantName && (variant.ContentType == "image/webp") == webp {
+			key, size, contentType = variant.StorageKey, variant.Size, variant.ContentType
+		}
+	}
+
+	body, err := h.store.Get(c.Request.Context(), key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Attachment not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read attachment"})
+		return
+	}
+	defer body.Close()
+
+	// Only images are shown inline; anything else is downloaded so that it
+	// cannot run in the API's origin.
+	disposition := "attachment"
+	if strings.HasPrefix(contentType, "image/") {
+		disposition = "inline"
+	}
+	c.Header("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": attachment.FileName}))
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Cache-Control", "private, max-age=300")
+	c.DataFromReader(http.StatusOK, size, contentType, body, nil)
+}
+
+func (h *AttachmentHandler) loadAttachment(c *gin.Context) (*model.Attachment, bool) {
+	article, exists := middleware.GetArticleFromContext(c)
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Article not found in context"})
+		return nil, false
+	}
+
+	attachmentID, err := strconv.ParseUint(c.Param("attachmentId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attachment ID"})
+		return nil, false
+	}
+
+	attachment, err := h.attachmentRepo.GetAttachmentByID(c.Request.Context(), article.OrganizationID, article.ID, uint(attachmentID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attachment not found"})
+		return nil, false
+	}
+	return attachment, true
+}
+
+func (h *AttachmentHandler) response(attachment *model.Attachment) AttachmentResponse {
+	response := AttachmentResponse{
+		Attachment: *attachment,
+		URL:        h.signer.URL(attachment.ID, time.Now()),
+	}
+	for _, variant := range attachment.Variants {
+		if response.VariantURLs == nil {
+			response.VariantURLs = make(map[string]string)
+		}
+		name, url := variant.Name, response.URL+"&variant="+variant.Name
+		if variant.ContentType == "image/webp" {
+			name, url = name+"."+media.FormatWebP, url+"&format="+media.FormatWebP
+		}
+		response.VariantURLs[name] = url
+	}
+	return response
+}
+
+func (h *AttachmentHandler) respondTooLarge(c *gin.Context) {
+	c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+		"error":    fmt.Sprintf("Attachments can be at most %d bytes", h.maxSize),
+		"max_size": h.maxSize,
+	})
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/audit"
 	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/middleware"
 	"github.com/adityadeshlahre/multi-tenant-backend-app/repository"
 )
@@ -15,12 +16,14 @@ import (
 type AuthHandler struct {
 	userRepo repository.UserRepository
 	orgRepo  repository.OrgRepository
+	roleRepo repository.RoleRepository
 }
 
-func NewAuthHandler(userRepo repository.UserRepository, orgRepo repository.OrgRepository) *AuthHandler {
+func NewAuthHandler(userRepo repository.UserRepository, orgRepo repository.OrgRepository, roleRepo repository.RoleRepository) *AuthHandler {
 	return &AuthHandler{
 		userRepo: userRepo,
 		orgRepo:  orgRepo,
+		roleRepo: roleRepo,
 	}
 }
 
@@ -29,7 +32,6 @@ type RegisterRequest struct {
 	Email          string `json:"email" binding:"required,email"`
 	Password       string `json:"password" binding:"required,min=6"`
 	OrganizationID uint   `json:"organization_id"`
-	Role           string `json:"role"`
 }
 
 type LoginRequest struct {
@@ -44,17 +46,16 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
-	if req.Role == "" {
-		req.Role = "member"
-	}
-
+	// Registration never grants privileges; roles are changed through the
+	// role-management endpoints, and the first super admin is appointed
+	// from the command line.
 	hashedPassword := middleware.HashPassword(req.Password)
 
 	user := &model.User{
 		Name:     req.Name,
 		Email:    req.Email,
 		Password: hashedPassword,
-		Role:     req.Role,
+		Role:     model.RoleMember,
 	}
 
 	createdUser, err := h.userRepo.CreateUser(c.Request.Context(), user)
@@ -62,6 +63,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
 		return
 	}
+	audit.Record(c, audit.Entry{Action: "auth.register", ActorID: createdUser.ID, TargetType: "user", TargetID: createdUser.ID})
 
 	if req.OrganizationID != 0 {
 		org, err := h.orgRepo.GetOrganizationByID(c.Request.Context(), req.OrganizationID)
@@ -69,6 +71,8 @@ func (h *AuthHandler) Register(c *gin.Context) {
 			// Use GORM's association mode to properly add the organization
 			if err := h.userRepo.AddUserToOrganization(c.Request.Context(), createdUser.ID, org.ID); err != nil {
 				log.Printf("Failed to add user to organization: %v", err)
+			} else {
+				audit.Record(c, audit.Entry{Action: "member.join", OrganizationID: org.ID, ActorID: createdUser.ID, TargetType: "user", TargetID: createdUser.ID})
 			}
 		}
 	}
@@ -95,11 +99,13 @@ func (h *AuthHandler) Login(c *gin.Context) {
 
 	user, err := h.userRepo.GetUserByEmail(c.Request.Context(), req.Email)
 	if err != nil {
+		audit.Record(c, audit.Entry{Action: "auth.login_failed", TargetType: "user"})
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
 		return
 	}
 
 	if !middleware.CheckPassword(user.Password, req.Password) {
+		audit.Record(c, audit.Entry{Action: "auth.login_failed", TargetType: "user", TargetID: user.ID})
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
 		return
 	}
@@ -109,6 +115,7 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
 	}
+	audit.Record(c, audit.Entry{Action: "auth.login", ActorID: user.ID, TargetType: "user", TargetID: user.ID})
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Login successful",
@@ -161,6 +168,7 @@ func (h *AuthHandler) UpdateProfile(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
 		return
 	}
+	audit.Record(c, audit.Entry{Action: "user.update", TargetType: "user", TargetID: updatedUser.ID})
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Profile updated successfully",
@@ -175,14 +183,14 @@ func (h *AuthHandler) JoinOrganization(c *gin.Context) {
 		return
 	}
 
+	var org *model.Organization
 	orgIDStr := c.Param("orgId")
 	orgID, err := strconv.ParseUint(orgIDStr, 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid organization ID"})
-		return
+	if err == nil {
+		org, err = h.orgRepo.GetOrganizationByID(c.Request.Context(), uint(orgID))
+	} else {
+		org, err = h.orgRepo.GetOrganizationBySlug(c.Request.Context(), orgIDStr)
 	}
-
-	org, err := h.orgRepo.GetOrganizationByID(c.Request.Context(), uint(orgID))
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Organization not found"})
 		return
@@ -191,13 +199,17 @@ func (h *AuthHandler) JoinOrganization(c *gin.Context) {
 	userModel := user.(*model.User)
 
 	err = h.userRepo.AddUserToOrganization(c.Request.Context(), userModel.ID, org.ID)
+	if respondQuotaExceeded(c, err) {
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to join organization"})
 		return
 	}
+	audit.Record(c, audit.Entry{Action: "member.join", OrganizationID: org.ID, TargetType: "user", TargetID: userModel.ID})
 
 	c.JSON(http.StatusOK, gin.H{
 		"message":      "Successfully joined organization",
-		"organization": gin.H{"id": org.ID, "name": org.Name},
+		"organization": gin.H{"id": org.ID, "name": org.Name, "slug": org.Slug},
 	})
 }
@@ -0,0 +1,260 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/audit"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/middleware"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/policy"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/repository"
+)
+
+type CategoryHandler struct {
+	categoryRepo repository.CategoryRepository
+}
+
+func NewCategoryHandler(categoryRepo repository.CategoryRepository) *CategoryHandler {
+	return &CategoryHandler{
+		categoryRepo: categoryRepo,
+	}
+}
+
+// CreateCategoryRequest takes an optional slug; without one it is generated
+// from the name. Without a parent_id the category is top-level.
+type CreateCategoryRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Slug        string `json:"slug"`
+	Description string `json:"description"`
+	ParentID    *uint  `json:"parent_id"`
+}
+
+// UpdateCategoryRequest leaves fields that are not set unchanged. A
+// parent_id of 0 makes the category top-level.
+type UpdateCategoryRequest struct {
+	Name        string `json:"name"`
+	Slug        string `json:"slug"`
+	Description string `json:"description"`
+	ParentID    *uint  `json:"parent_id"`
+}
+
+// categoryNode is a category with its subcategories, as listed by
+// GetCategories.
+type categoryNode struct {
+	model.Category
+	Children []*categoryNode `json:"children"`
+}
+
+// GetCategories returns the organization's categories as a tree.
+func (h *CategoryHandler) GetCategories(c *gin.Context) {
+	orgID, _ := c.Get(middleware.OrganizationKey)
+	categories, err := h.categoryRepo.GetCategoriesByOrganization(c.Request.Context(), orgID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch categories"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"categories": categoryTree(categories),
+	})
+}
+
+func (h *CategoryHandler) GetCategory(c *gin.Context) {
+	category, ok := h.categoryFromParam(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"category": category,
+	})
+}
+
+func (h *CategoryHandler) CreateCategory(c *gin.Context) {
+	if !middleware.CanInOrganization(c, policy.ActionManageTaxonomy) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to manage categories"})
+		return
+	}
+
+	var req CreateCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Slug != "" && !validSlug(req.Slug) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": invalidSlugMessage})
+		return
+	}
+
+	orgID, _ := c.Get(middleware.OrganizationKey)
+	if req.ParentID != nil && !h.validParent(c, orgID.(uint), *req.ParentID) {
+		return
+	}
+
+	category := &model.Category{
+		OrganizationID: orgID.(uint),
+		ParentID:       req.ParentID,
+		Name:           req.Name,
+		Slug:           req.Slug,
+		Description:    req.Description,
+	}
+
+	createdCategory, err := h.categoryRepo.CreateCategory(c.Request.Context(), category)
+	if errors.Is(err, repository.ErrSlugTaken) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Slug is already taken"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create category"})
+		return
+	}
+	audit.Record(c, audit.Entry{Action: "category.create", TargetType: "category", TargetID: createdCategory.ID, After: createdCategory})
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "Category created successfully",
+		"category": createdCategory,
+	})
+}
+
+func (h *CategoryHandler) UpdateCategory(c *gin.Context) {
+	if !middleware.CanInOrganization(c, policy.ActionManageTaxonomy) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to manage categories"})
+		return
+	}
+
+	category, ok := h.categoryFromParam(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	before := *category
+	if req.Name != "" {
+		category.Name = req.Name
+	}
+	if req.Slug != "" {
+		if !validSlug(req.Slug) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": invalidSlugMessage})
+			return
+		}
+		category.Slug = req.Slug
+	}
+	if req.Description != "" {
+		category.Description = req.Description
+	}
+	if req.ParentID != nil {
+		if *req.ParentID == 0 {
+			category.ParentID = nil
+		} else {
+			if !h.validParent(c, category.OrganizationID, *req.ParentID) {
+				return
+			}
+			category.ParentID = req.ParentID
+		}
+	}
+
+	updatedCategory, err := h.categoryRepo.UpdateCategory(c.Request.Context(), category)
+	if errors.Is(err, repository.ErrSlugTaken) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Slug is already taken"})
+		return
+	}
+	if errors.Is(err, repository.ErrCategoryCycle) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A category cannot be moved into itself or one of its subcategories"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update category"})
+		return
+	}
+	audit.Record(c, audit.Entry{Action: "category.update", TargetType: "category", TargetID: updatedCategory.ID, Before: &before, After: updatedCategory})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Category updated successfully",
+		"category": updatedCategory,
+	})
+}
+
+// DeleteCategory removes the category. Its subcategories and articles move
+// up to its parent.
+func (h *CategoryHandler) DeleteCategory(c *gin.Context) {
+	if !middleware.CanInOrganization(c, policy.ActionManageTaxonomy) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to manage categories"})
+		return
+	}
+
+	category, ok := h.categoryFromParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.categoryRepo.DeleteCategory(c.Request.Context(), category); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete category"})
+		return
+	}
+	audit.Record(c, audit.Entry{Action: "category.delete", TargetType: "category", TargetID: category.ID, Before: category})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Category deleted successfully",
+	})
+}
+
+// categoryFromParam loads the :categoryId category and makes sure it
+// belongs to the organization in context. It writes the error response
+// itself.
+func (h *CategoryHandler) categoryFromParam(c *gin.Context) (*model.Category, bool) {
+	categoryID, err := strconv.ParseUint(c.Param("categoryId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category ID"})
+		return nil, false
+	}
+
+	orgID, _ := c.Get(middleware.OrganizationKey)
+	category, err := h.categoryRepo.GetCategoryByID(c.Request.Context(), uint(categoryID))
+	if err != nil || category.OrganizationID != orgID.(uint) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+		return nil, false
+	}
+
+	return category, true
+}
+
+func (h *CategoryHandler) validParent(c *gin.Context, orgID, parentID uint) bool {
+	parent, err := h.categoryRepo.GetCategoryByID(c.Request.Context(), parentID)
+	if err != nil || parent.OrganizationID != orgID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Parent category not found in this organization"})
+		return false
+	}
+	return true
+}
+
+// categoryTree nests the categories under their parents. Categories whose
+// parent is missing are treated as top-level.
+func categoryTree(categories []model.Category) []*categoryNode {
+	nodes := make(map[uint]*categoryNode, len(categories))
+	for _, category := range categories {
+		nodes[category.ID] = &categoryNode{Category: category, Children: []*categoryNode{}}
+	}
+
+	roots := []*categoryNode{}
+	for _, category := range categories {
+		node := nodes[category.ID]
+		if category.ParentID != nil {
+			if parent, ok := nodes[*category.ParentID]; ok {
+				parent.Children = append(parent.Children, node)
+				continue
+			}
+		}
+		roots = append(roots, node)
+	}
+	return roots
+}
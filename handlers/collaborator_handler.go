@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/audit"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/middleware"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/repository"
+)
+
+var collaboratorPermissions = map[string]bool{
+	middleware.PermissionView:    true,
+	middleware.PermissionComment: true,
+	middleware.PermissionEdit:    true,
+}
+
+type CollaboratorHandler struct {
+	collaboratorRepo repository.CollaboratorRepository
+	roleRepo         repository.RoleRepository
+	teamRepo         repository.TeamRepository
+}
+
+func NewCollaboratorHandler(collaboratorRepo repository.CollaboratorRepository, roleRepo repository.RoleRepository, teamRepo repository.TeamRepository) *CollaboratorHandler {
+	return &CollaboratorHandler{
+		collaboratorRepo: collaboratorRepo,
+		roleRepo:         roleRepo,
+		teamRepo:         teamRepo,
+	}
+}
+
+// AddCollaboratorRequest grants Permission to exactly one of UserID, Role or
+// TeamID.
+type AddCollaboratorRequest struct {
+	UserID     uint   `json:"user_id"`
+	Role       string `json:"role"`
+	TeamID     uint   `json:"team_id"`
+	Permission string `json:"permission" binding:"required"`
+}
+
+func (h *CollaboratorHandler) GetCollaborators(c *gin.Context) {
+	if !middleware.CanViewArticle(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to view this article"})
+		return
+	}
+
+	article, exists := middleware.GetArticleFromContext(c)
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Article not found in context"})
+		return
+	}
+
+	collaborators, err := h.collaboratorRepo.GetCollaboratorsByArticleID(c.Request.Context(), article.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch collaborators"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"collaborators": collaborators,
+	})
+}
+
+func (h *CollaboratorHandler) AddCollaborator(c *gin.Context) {
+	if !middleware.CanShareArticle(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to share this article"})
+		return
+	}
+
+	article, exists := middleware.GetArticleFromContext(c)
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Article not found in context"})
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	var req AddCollaboratorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !collaboratorPermissions[req.Permission] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Permission must be one of view, comment or edit"})
+		return
+	}
+
+	grantees := 0
+	for _, set := range []bool{req.UserID != 0, req.Role != "", req.TeamID != 0} {
+		if set {
+			grantees++
+		}
+	}
+	if grantees != 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Provide exactly one of user_id, role or team_id"})
+		return
+	}
+
+	collaborator := &model.ArticleCollaborator{
+		ArticleID:  article.ID,
+		Permission: req.Permission,
+		GrantedBy:  userID.(uint),
+	}
+
+	if req.UserID != 0 {
+		if _, err := h.roleRepo.GetMembership(c.Request.Context(), req.UserID, article.OrganizationID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "User is not a member of this organization"})
+			return
+		}
+		collaborator.UserID = &req.UserID
+	} else if req.TeamID != 0 {
+		team, err := h.teamRepo.GetTeamByID(c.Request.Context(), req.TeamID)
+		if err != nil || team.OrganizationID != article.OrganizationID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Team not found in this organization"})
+			return
+		}
+		collaborator.TeamID = &req.TeamID
+	} else {
+		if !organizationRoles[req.Role] {
+			if _, err := h.roleRepo.GetOrganizationRoleByName(c.Request.Context(), article.OrganizationID, req.Role); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid organization role"})
+				return
+			}
+		}
+		collaborator.Role = req.Role
+	}
+
+	createdCollaborator, err := h.collaboratorRepo.AddCollaborator(c.Request.Context(), collaborator)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add collaborator"})
+		return
+	}
+	audit.Record(c, audit.Entry{Action: "collaborator.add", TargetType: "article_collaborator", TargetID: createdCollaborator.ID, After: createdCollaborator})
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":      "Collaborator added successfully",
+		"collaborator": createdCollaborator,
+	})
+}
+
+func (h *CollaboratorHandler) RemoveCollaborator(c *gin.Context) {
+	if !middleware.CanShareArticle(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to share this article"})
+		return
+	}
+
+	article, exists := middleware.GetArticleFromContext(c)
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Article not found in context"})
+		return
+	}
+
+	collaboratorID, err := strconv.ParseUint(c.Param("collaboratorId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid collaborator ID"})
+		return
+	}
+
+	collaborator, err := h.collaboratorRepo.GetCollaboratorByID(c.Request.Context(), uint(collaboratorID))
+	if err != nil || collaborator.ArticleID != article.ID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Collaborator not found"})
+		return
+	}
+
+	if err := h.collaboratorRepo.DeleteCollaborator(c.Request.Context(), collaborator.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove collaborator"})
+		return
+	}
+	audit.Record(c, audit.Entry{Action: "collaborator.remove", TargetType: "article_collaborator", TargetID: collaborator.ID, Before: collaborator})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Collaborator removed successfully",
+	})
+}
@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/audit"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/domainverify"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/middleware"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/policy"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/repository"
+)
+
+var hostName = regexp.MustCompile(`^([a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?\.)+[a-z]{2,63}$`)
+
+type DomainHandler struct {
+	domainRepo repository.DomainRepository
+	verifier   *domainverify.Verifier
+	baseDomain string
+}
+
+func NewDomainHandler(domainRepo repository.DomainRepository, verifier *domainverify.Verifier, baseDomain string) *DomainHandler {
+	return &DomainHandler{
+		domainRepo: domainRepo,
+		verifier:   verifier,
+		baseDomain: baseDomain,
+	}
+}
+
+type AddDomainRequest struct {
+	Host string `json:"host" binding:"required"`
+}
+
+func (h *DomainHandler) GetDomains(c *gin.Context) {
+	if !middleware.CanInOrganization(c, policy.ActionManageDomains) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to manage domains"})
+		return
+	}
+
+	orgID, _ := c.Get(middleware.OrganizationKey)
+	domains, err := h.domainRepo.GetDomainsByOrganization(c.Request.Context(), orgID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch domains"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"domains": domains,
+	})
+}
+
+// AddDomain registers a custom domain for the organization. The domain only
+// resolves to the organization after the returned TXT record is published
+// and VerifyDomain succeeds.
+func (h *DomainHandler) AddDomain(c *gin.Context) {
+	if !middleware.CanInOrganization(c, policy.ActionManageDomains) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to manage domains"})
+		return
+	}
+
+	var req AddDomainRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(req.Host)), ".")
+	if !hostName.MatchString(host) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid host name"})
+		return
+	}
+	if h.baseDomain != "" && (host == h.baseDomain || strings.HasSuffix(host, "."+h.baseDomain)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Hosts under " + h.baseDomain + " are assigned through the organization's subdomain"})
+		return
+	}
+
+	token, err := domainverify.NewToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate verification token"})
+		return
+	}
+
+	orgID, _ := c.Get(middleware.OrganizationKey)
+	domain := &model.OrganizationDomain{
+		OrganizationID:    orgID.(uint),
+		Host:              host,
+		VerificationToken: token,
+	}
+
+	createdDomain, err := h.domainRepo.CreateDomain(c.Request.Context(), domain)
+	if errors.Is(err, repository.ErrDomainClaimed) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Domain is already registered"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add domain"})
+		return
+	}
+	audit.Record(c, audit.Entry{Action: "domain.create", TargetType: "domain", TargetID: createdDomain.ID, After: createdDomain})
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":      "Domain added, publish the TXT record and verify it",
+		"domain":       createdDomain,
+		"verification": verificationRecord(createdDomain),
+	})
+}
+
+func (h *DomainHandler) VerifyDomain(c *gin.Context) {
+	if !middleware.CanInOrganization(c, policy.ActionManageDomains) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to manage domains"})
+		return
+	}
+
+	domain, ok := h.domainFromParam(c)
+	if !ok {
+		return
+	}
+
+	if domain.VerifiedAt == nil {
+		verified, err := h.verifier.Verify(c.Request.Context(), domain.Host, domain.VerificationToken)
+		if err != nil || !verified {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error":        "Verification record not found",
+				"verification": verificationRecord(domain),
+			})
+			return
+		}
+
+		before := *domain
+		if domain, err = h.domainRepo.MarkDomainVerified(c.Request.Context(), domain); err != nil {
+			if errors.Is(err, repository.ErrDomainTaken) {
+				c.JSON(http.StatusConflict, gin.H{"error": "Domain is verified by another organization"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify domain"})
+			}
+			return
+		}
+		audit.Record(c, audit.Entry{Action: "domain.verify", TargetType: "domain", TargetID: domain.ID, Before: &before, After: domain})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Domain verified successfully",
+		"domain":  domain,
+	})
+}
+
+func (h *DomainHandler) DeleteDomain(c *gin.Context) {
+	if !middleware.CanInOrganization(c, policy.ActionManageDomains) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to manage domains"})
+		return
+	}
+
+	domain, ok := h.domainFromParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.domainRepo.DeleteDomain(c.Request.Context(), domain.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete domain"})
+		return
+	}
+	audit.Record(c, audit.Entry{Action: "domain.delete", TargetType: "domain", TargetID: domain.ID, Before: domain})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Domain deleted successfully",
+	})
+}
+
+func (h *DomainHandler) domainFromParam(c *gin.Context) (*model.OrganizationDomain, bool) {
+	domainID, err := strconv.ParseUint(c.Param("domainId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid domain ID"})
+		return nil, false
+	}
+
+	orgID, _ := c.Get(middleware.OrganizationKey)
+	domain, err := h.domainRepo.GetDomainByID(c.Request.Context(), uint(domainID))
+	if err != nil || domain.OrganizationID != orgID.(uint) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Domain not found"})
+		return nil, false
+	}
+	return domain, true
+}
+
+func verificationRecord(domain *model.OrganizationDomain) gin.H {
+	return gin.H{
+		"type":  "TXT",
+		"name":  domainverify.RecordName(domain.Host),
+		"value": domainverify.RecordValue(domain.VerificationToken),
+	}
+}
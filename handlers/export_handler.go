@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/audit"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/middleware"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/policy"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/storage"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/tenantexport"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/repository"
+)
+
+// maxImportSize bounds the archives ImportOrganization accepts.
+const maxImportSize = 256 << 20
+
+type ExportHandler struct {
+	exportRepo repository.ExportRepository
+	userRepo   repository.UserRepository
+	usageRepo  repository.UsageRepository
+	exports    *tenantexport.Worker
+	store      storage.Storage
+}
+
+func NewExportHandler(exportRepo repository.ExportRepository, userRepo repository.UserRepository, usageRepo repository.UsageRepository, exports *tenantexport.Worker, store storage.Storage) *ExportHandler {
+	return &ExportHandler{
+		exportRepo: exportRepo,
+		userRepo:   userRepo,
+		usageRepo:  usageRepo,
+		exports:    exports,
+		store:      store,
+	}
+}
+
+// RequestExport queues an export of the organization's data. Its progress
+// can be followed through GetExport, and the archive downloaded once it has
+// completed.
+func (h *ExportHandler) RequestExport(c *gin.Context) {
+	if !middleware.CanInOrganization(c, policy.ActionExportData) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only organization admins can export data"})
+		return
+	}
+
+	orgID, _ := c.Get(middleware.OrganizationKey)
+	userID, _ := c.Get("userID")
+	export, err := h.exportRepo.CreateExport(c.Request.Context(), &model.OrganizationExport{
+		OrganizationID: orgID.(uint),
+		RequestedBy:    userID.(uint),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start export"})
+		return
+	}
+	audit.Record(c, audit.Entry{Action: "organization.export", TargetType: "organization_export", TargetID: export.ID})
+	h.exports.Enqueue(export.ID)
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "Export started",
+		"export":  export,
+	})
+}
+
+func (h *ExportHandler) GetExports(c *gin.Context) {
+	if !middleware.CanInOrganization(c, policy.ActionExportData) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only organization admins can export data"})
+		return
+	}
+
+	orgID, _ := c.Get(middleware.OrganizationKey)
+	exports, err := h.exportRepo.GetExports(c.Request.Context(), orgID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch exports"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"exports": exports,
+	})
+}
+
+func (h *ExportHandler) GetExport(c *gin.Context) {
+	export, ok := h.export(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"export": export,
+	})
+}
+
+// DownloadExport sends the archive of a completed export.
+func (h *ExportHandler) DownloadExport(c *gin.Context) {
+	export, ok := h.export(c)
+	if !ok {
+		return
+	}
+	if export.Status != model.ExportCompleted {
+		c.JSON(http.StatusConflict, gin.H{"error": "Export is " + export.Status})
+		return
+	}
+
+	c.FileAttachment(h.exports.Path(export), export.FileName)
+}
+
+func (h *ExportHandler) export(c *gin.Context) (*model.OrganizationExport, bool) {
+	if !middleware.CanInOrganization(c, policy.ActionExportData) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only organization admins can export data"})
+		return nil, false
+	}
+
+	exportID, err := strconv.ParseUint(c.Param("exportId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid export ID"})
+		return nil, false
+	}
+
+	orgID, _ := c.Get(middleware.OrganizationKey)
+	export, err := h.exportRepo.GetExportByID(c.Request.Context(), orgID.(uint), uint(exportID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Export not found"})
+		return nil, false
+	}
+	return export, true
+}
+
+// ImportOrganization recreates an organization from an export archive sent
+// as the multipart field "archive". The form may rename it with "name",
+// pick its owner by "owner_email" (the caller by default) and its plan by
+// "plan". With "dry_run" set to true the archive is checked and the import
+// rehearsed without keeping anything.
+func (h *ExportHandler) ImportOrganization(c *gin.Context) {
+	header, err := c.FormFile("archive")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "An archive file is required"})
+		return
+	}
+	if header.Size > maxImportSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Archives may be at most %d bytes", maxImportSize)})
+		return
+	}
+	dryRun, err := strconv.ParseBool(c.DefaultPostForm("dry_run", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dry_run"})
+		return
+	}
+
+	opts := repository.ImportOptions{DryRun: dryRun}
+	if email := c.PostForm("owner_email"); email != "" {
+		owner, err := h.userRepo.GetUserByEmail(c.Request.Context(), email)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Owner not found"})
+			return
+		}
+		opts.OwnerID = owner.ID
+	} else {
+		userID, _ := c.Get("userID")
+		opts.OwnerID = userID.(uint)
+	}
+	if name := c.PostForm("plan"); name != "" {
+		plan, err := h.usageRepo.GetPlanByName(c.Request.Context(), name)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown plan"})
+			return
+		}
+		opts.PlanID = &plan.ID
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read archive"})
+		return
+	}
+	defer file.Close()
+
+	data, manifest, err := tenantexport.Read(file, header.Size)
+	if err != nil {
+		var invalid *tenantexport.ValidationError
+		if errors.As(err, &invalid) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Invalid archive", "problems": invalid.Problems})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read archive"})
+		return
+	}
+	if name := c.PostForm("name"); name != "" {
+		data.Organization.Name = name
+	}
+
+	var storedFiles []string
+	if !dryRun {
+		storedFiles, err = tenantexport.StoreFiles(c.Request.Context(), file, header.Size, data, h.store)
+		if err != nil {
+			var invalid *tenantexport.ValidationError
+			if errors.As(err, &invalid) {
+				c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Invalid archive", "problems": invalid.Problems})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store attachments"})
+			return
+		}
+	}
+
+	result, err := h.exportRepo.ImportTenant(c.Request.Context(), data, opts)
+	if err != nil {
+		tenantexport.RemoveFiles(c.Request.Context(), h.store, storedFiles)
+		if errors.Is(err, repository.ErrOrganizationNameTaken) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Organization name is already taken"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import organization"})
+		return
+	}
+
+	if dryRun {
+		c.JSON(http.StatusOK, gin.H{
+			"message":  "Archive is valid",
+			"manifest": manifest,
+			"result":   result,
+		})
+		return
+	}
+	audit.Record(c, audit.Entry{Action: "organization.import", OrganizationID: result.Organization.ID, TargetType: "organization", TargetID: result.Organization.ID, After: result.Organization})
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "Organization imported successfully",
+		"manifest": manifest,
+		"result":   result,
+	})
+}
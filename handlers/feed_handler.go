@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/feed"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/markup"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/middleware"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/repository"
+)
+
+const (
+	defaultFeedEntries = 20
+	maxFeedEntries     = 100
+)
+
+type FeedHandler struct {
+	articleRepo repository.ArticleRepository
+	tagRepo     repository.TagRepository
+	userRepo    repository.UserRepository
+	roleRepo    repository.RoleRepository
+}
+
+func NewFeedHandler(articleRepo repository.ArticleRepository, tagRepo repository.TagRepository, userRepo repository.UserRepository, roleRepo repository.RoleRepository) *FeedHandler {
+	return &FeedHandler{
+		articleRepo: articleRepo,
+		tagRepo:     tagRepo,
+		userRepo:    userRepo,
+		roleRepo:    roleRepo,
+	}
+}
+
+// feedScope is the part of an organization's published articles a feed
+// covers. path is appended to the organization's public URL.
+type feedScope struct {
+	title       string
+	description string
+	path        string
+	filter      repository.PublishedFilter
+}
+
+// feedLink describes a feed for autodiscovery.
+type feedLink struct {
+	Rel   string `json:"rel"`
+	Type  string `json:"type"`
+	Title string `json:"title"`
+	Href  string `json:"href"`
+}
+
+// GetFeeds lists the organization's feeds, in the body and as Link headers,
+// so readers can discover them.
+func (h *FeedHandler) GetFeeds(c *gin.Context) {
+	org := c.MustGet("organization").(*model.Organization)
+	links := feedLinks(c, org, h.organizationScope(org))
+	setFeedLinkHeader(c, links)
+
+	c.JSON(http.StatusOK, gin.H{
+		"feeds": links,
+	})
+}
+
+// GetOrganizationFeed serves the organization's latest published articles.
+func (h *FeedHandler) GetOrganizationFeed(c *gin.Context) {
+	org := c.MustGet("organization").(*model.Organization)
+	h.serveFeed(c, org, h.organizationScope(org))
+}
+
+// GetTagFeed serves the organization's latest published articles with the
+// :tag tag.
+func (h *FeedHandler) GetTagFeed(c *gin.Context) {
+	org := c.MustGet("organization").(*model.Organization)
+	tag, err := h.tagRepo.GetTagBySlug(c.Request.Context(), org.ID, c.Param("tag"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tag not found"})
+		return
+	}
+
+	h.serveFeed(c, org, feedScope{
+		title:       fmt.Sprintf("%s: %s", org.Name, tag.Name),
+		description: fmt.Sprintf("Articles tagged %s on %s", tag.Name, org.Name),
+		path:        "/tags/" + tag.Slug,
+		filter:      repository.PublishedFilter{Tag: tag.Slug},
+	})
+}
+
+// GetAuthorFeed serves the latest articles the :authorId user published in
+// the organization.
+func (h *FeedHandler) GetAuthorFeed(c *gin.Context) {
+	org := c.MustGet("organization").(*model.Organization)
+	author, ok := authorFromParam(c, h.articleRepo, h.userRepo, h.roleRepo, org.ID)
+	if !ok {
+		return
+	}
+
+	h.serveFeed(c, org, feedScope{
+		title:       fmt.Sprintf("%s: %s", org.Name, author.Name),
+		description: fmt.Sprintf("Articles by %s on %s", author.Name, org.Name),
+		path:        fmt.Sprintf("/authors/%d", author.ID),
+		filter:      repository.PublishedFilter{AuthorID: author.ID},
+	})
+}
+
+func (h *FeedHandler) organizationScope(org *model.Organization) feedScope {
+	return feedScope{
+		title:       org.Name,
+		description: "Latest articles from " + org.Name,
+	}
+}
+
+// serveFeed answers with the scope's feed in the :format format. Articles
+// carry their full content unless ?content=summary asks for summaries only.
+func (h *FeedHandler) serveFeed(c *gin.Context, org *model.Organization, scope feedScope) {
+	format := c.Param("format")
+	if !feed.ValidFormat(format) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Feed format must be rss or atom"})
+		return
+	}
+	full := true
+	switch c.DefaultQuery("content", "full") {
+	case "full":
+	case "summary":
+		full = false
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Content must be full or summary"})
+		return
+	}
+	limit, ok := queryLimit(c, defaultFeedEntries, maxFeedEntries)
+	if !ok {
+		return
+	}
+
+	scope.filter.Page, scope.filter.PageSize = 1, limit
+	articles, _, err := h.articleRepo.GetPublishedArticlesByOrganization(c.Request.Context(), org.ID, scope.filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch articles"})
+		return
+	}
+
+	// Dates are given in the organization's time zone.
+	s := middleware.GetSettingsFromContext(c)
+	loc := s.Location()
+	base := publicURL(c, org)
+	f := &feed.Feed{
+		ID:          tagURI(c, org.CreatedAt, "organizations/"+strconv.FormatUint(uint64(org.ID), 10)+scope.path),
+		Title:       scope.title,
+		Description: scope.description,
+		Link:        base + scope.path + "/articles",
+		Self:        base + scope.path + "/feeds/" + format,
+		Logo:        s.Branding.LogoURL,
+		Language:    s.Locale,
+		Updated:     org.UpdatedAt,
+	}
+	for _, article := range articles {
+		if article.UpdatedAt.After(f.Updated) {
+			f.Updated = article.UpdatedAt
+		}
+		entry := feed.Entry{
+			ID:        tagURI(c, article.CreatedAt, "articles/"+strconv.FormatUint(uint64(article.ID), 10)),
+			Title:     article.Title,
+			Link:      base + "/articles/" + article.Slug,
+			Author:    article.User.Name,
+			Published: publishedAt(article).In(loc),
+			Updated:   article.UpdatedAt.In(loc),
+			Summary:   markup.Summary(article.ContentHTML, summaryLength),
+		}
+		for _, tag := range article.Tags {
+			entry.Categories = append(entry.Categories, tag.Name)
+		}
+		if full {
+			entry.Content = article.ContentHTML
+		}
+		f.Entries = append(f.Entries, entry)
+	}
+	f.Updated = f.Updated.In(loc)
+
+	var body bytes.Buffer
+	if err := feed.Encode(&body, format, f); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode feed"})
+		return
+	}
+	setFeedLinkHeader(c, feedLinks(c, org, scope))
+	serveCached(c, feed.ContentType(format), body.Bytes(), f.Updated)
+}
+
+func feedLinks(c *gin.Context, org *model.Organization, scope feedScope) []feedLink {
+	base := publicURL(c, org) + scope.path + "/feeds/"
+	return []feedLink{
+		{Rel: "alternate", Type: "application/rss+xml", Title: scope.title + " (RSS)", Href: base + feed.FormatRSS},
+		{Rel: "alternate", Type: "application/atom+xml", Title: scope.title + " (Atom)", Href: base + feed.FormatAtom},
+	}
+}
+
+func setFeedLinkHeader(c *gin.Context, links []feedLink) {
+	values := make([]string, 0, len(links))
+	for _, link := range links {
+		values = append(values, fmt.Sprintf("<%s>; rel=%q; type=%q; title=%q", link.Href, link.Rel, link.Type, link.Title))
+	}
+	c.Header("Link", strings.Join(values, ", "))
+}
+
+// tagURI builds a tag: URI (RFC 4151) that stays the same when slugs or
+// titles change, for the IDs of feeds and entries.
+func tagURI(c *gin.Context, created time.Time, specific string) string {
+	host := c.Request.Host
+	if i := strings.LastIndexByte(host, ':'); i >= 0 && !strings.Contains(host[i:], "]") {
+		host = host[:i]
+	}
+	return fmt.Sprintf("tag:%s,%s:%s", host, created.UTC().Format("2006-01-02"), specific)
+}
+
+// queryLimit reads ?limit=, which defaults to def and may not exceed max.
+// It writes the error response itself.
+func queryLimit(c *gin.Context, def, max int) (int, bool) {
+	raw := c.Query("limit")
+	if raw == "" {
+		return def, true
+	}
+	limit, err := strconv.Atoi(raw)
+	if err != nil || limit < 1 || limit > max {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Limit must be between 1 and %d", max)})
+		return 0, false
+	}
+	return limit, true
+}
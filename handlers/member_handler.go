@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/audit"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/middleware"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/policy"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/repository"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+type MemberHandler struct {
+	roleRepo repository.RoleRepository
+}
+
+func NewMemberHandler(roleRepo repository.RoleRepository) *MemberHandler {
+	return &MemberHandler{
+		roleRepo: roleRepo,
+	}
+}
+
+// GetMembers lists the organization's members, optionally only those with
+// the given ?role, a page at a time. Email addresses are only shown to those
+// who manage members.
+func (h *MemberHandler) GetMembers(c *gin.Context) {
+	if !middleware.CanInOrganization(c, policy.ActionView) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only members can list the organization's members"})
+		return
+	}
+
+	page, pageSize, ok := pagination(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "page and page_size must be positive integers"})
+		return
+	}
+
+	orgID, _ := c.Get(middleware.OrganizationKey)
+	filter := repository.MemberFilter{Role: c.Query("role"), Page: page, PageSize: pageSize}
+	members, total, err := h.roleRepo.GetMembers(c.Request.Context(), orgID.(uint), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch members"})
+		return
+	}
+	if members == nil {
+		members = []repository.Member{}
+	}
+	if !middleware.CanInOrganization(c, policy.ActionManageMembers) {
+		for i := range members {
+			members[i].Email = ""
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"members":   members,
+		"page":      page,
+		"page_size": pageSize,
+		"total":     total,
+	})
+}
+
+func (h *MemberHandler) RemoveMember(c *gin.Context) {
+	if !middleware.CanInOrganization(c, policy.ActionManageMembers) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only organization admins can remove members"})
+		return
+	}
+
+	targetID, err := strconv.ParseUint(c.Param("userId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	actorID, _ := c.Get("userID")
+	if uint(targetID) == actorID.(uint) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Use the leave endpoint to leave the organization"})
+		return
+	}
+
+	h.removeMember(c, actorID.(uint), uint(targetID), "member.remove", "Member removed successfully")
+}
+
+// LeaveOrganization ends the caller's own membership. The owner has to
+// transfer ownership first.
+func (h *MemberHandler) LeaveOrganization(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	h.removeMember(c, userID.(uint), userID.(uint), "member.leave", "You left the organization")
+}
+
+func (h *MemberHandler) removeMember(c *gin.Context, actorID, targetID uint, action, message string) {
+	orgID, _ := c.Get(middleware.OrganizationKey)
+	err := h.roleRepo.RemoveMember(c.Request.Context(), actorID, targetID, orgID.(uint))
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "User is not a member of this organization"})
+		return
+	case errors.Is(err, repository.ErrOwnerRole):
+		c.JSON(http.StatusConflict, gin.H{"error": "The owner has to transfer ownership before leaving the organization"})
+		return
+	case errors.Is(err, repository.ErrLastAdmin):
+		c.JSON(http.StatusConflict, gin.H{"error": "The organization's last admin cannot leave or be removed"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove member"})
+		return
+	}
+	audit.Record(c, audit.Entry{Action: action, TargetType: "user", TargetID: targetID})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+	})
+}
+
+// pagination reads ?page and ?page_size, defaulting to the first page of
+// defaultPageSize and capping the size at maxPageSize.
+func pagination(c *gin.Context) (page, pageSize int, ok bool) {
+	page, pageSize = 1, defaultPageSize
+	if raw := c.Query("page"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			return 0, 0, false
+		}
+		page = n
+	}
+	if raw := c.Query("page_size"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			return 0, 0, false
+		}
+		pageSize = min(n, maxPageSize)
+	}
+	return page, pageSize, true
+}
@@ -1,29 +1,67 @@
 package handlers
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"net/http"
+	"regexp"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
 	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/audit"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/middleware"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/orgdeletion"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/policy"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/slug"
 	"github.com/adityadeshlahre/multi-tenant-backend-app/repository"
 )
 
+var subdomainLabel = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+const invalidSlugMessage = "Slug must be lowercase letters, digits and single hyphens, and not only digits"
+
+// validSlug accepts requested slugs that are already normalized and cannot be
+// mistaken for numeric IDs.
+func validSlug(s string) bool {
+	return slug.Valid(s) && !slug.IsNumeric(s)
+}
+
+// deletionConfirmationTTL is how long an owner has to confirm a deletion.
+const deletionConfirmationTTL = 15 * time.Minute
+
 type OrganizationHandler struct {
-	orgRepo repository.OrgRepository
+	orgRepo      repository.OrgRepository
+	deletionRepo repository.DeletionRepository
+	deletions    *orgdeletion.Worker
 }
 
-func NewOrganizationHandler(orgRepo repository.OrgRepository) *OrganizationHandler {
+func NewOrganizationHandler(orgRepo repository.OrgRepository, deletionRepo repository.DeletionRepository, deletions *orgdeletion.Worker) *OrganizationHandler {
 	return &OrganizationHandler{
-		orgRepo: orgRepo,
+		orgRepo:      orgRepo,
+		deletionRepo: deletionRepo,
+		deletions:    deletions,
 	}
 }
 
+type DeleteOrganizationRequest struct {
+	ConfirmationToken string `json:"confirmation_token" binding:"required"`
+}
+
+// CreateOrganizationRequest takes an optional slug; without one it is
+// generated from the name.
 type CreateOrganizationRequest struct {
 	Name string `json:"name" binding:"required"`
+	Slug string `json:"slug"`
 }
 
+// CreateOrganization creates an organization owned by the caller.
 func (h *OrganizationHandler) CreateOrganization(c *gin.Context) {
 	var req CreateOrganizationRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -31,19 +69,39 @@ func (h *OrganizationHandler) CreateOrganization(c *gin.Context) {
 		return
 	}
 
+	if req.Slug != "" && !validSlug(req.Slug) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": invalidSlugMessage})
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
 	org := &model.Organization{
 		Name: req.Name,
+		Slug: req.Slug,
 	}
 
-	createdOrg, err := h.orgRepo.CreateOrganization(c.Request.Context(), org)
+	createdOrg, err := h.orgRepo.CreateOrganization(c.Request.Context(), org, userID.(uint))
+	if errors.Is(err, repository.ErrSlugTaken) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Slug is already taken"})
+		return
+	}
+	if respondQuotaExceeded(c, err) {
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create organization"})
 		return
 	}
+	audit.Record(c, audit.Entry{Action: "organization.create", OrganizationID: createdOrg.ID, TargetType: "organization", TargetID: createdOrg.ID, After: createdOrg})
 
 	c.JSON(http.StatusCreated, gin.H{
 		"message":      "Organization created successfully",
-		"organization": gin.H{"id": createdOrg.ID, "name": createdOrg.Name},
+		"organization": gin.H{"id": createdOrg.ID, "name": createdOrg.Name, "slug": createdOrg.Slug},
 	})
 }
 
@@ -56,8 +114,10 @@ func (h *OrganizationHandler) GetOrganization(c *gin.Context) {
 
 	orgModel := org.(*model.Organization)
 	c.JSON(http.StatusOK, gin.H{
-		"id":   orgModel.ID,
-		"name": orgModel.Name,
+		"id":        orgModel.ID,
+		"name":      orgModel.Name,
+		"slug":      orgModel.Slug,
+		"subdomain": orgModel.Subdomain,
 	})
 }
 
@@ -83,7 +143,9 @@ func (h *OrganizationHandler) UpdateOrganization(c *gin.Context) {
 	orgModel := org.(*model.Organization)
 
 	var updateData struct {
-		Name string `json:"name"`
+		Name      string  `json:"name"`
+		Slug      string  `json:"slug"`
+		Subdomain *string `json:"subdomain"`
 	}
 
 	if err := c.ShouldBindJSON(&updateData); err != nil {
@@ -91,37 +153,173 @@ func (h *OrganizationHandler) UpdateOrganization(c *gin.Context) {
 		return
 	}
 
+	// Old slugs keep redirecting, so renaming moves the organization's
+	// public URLs and is up to those who manage its settings.
+	if (updateData.Name != "" || updateData.Slug != "") && !middleware.CanInOrganization(c, policy.ActionManageSettings) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to rename the organization"})
+		return
+	}
+
+	before := *orgModel
 	if updateData.Name != "" {
 		orgModel.Name = updateData.Name
 	}
+	if updateData.Slug != "" {
+		if !validSlug(updateData.Slug) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": invalidSlugMessage})
+			return
+		}
+		orgModel.Slug = updateData.Slug
+	}
+
+	// An empty subdomain releases the organization's current one.
+	if updateData.Subdomain != nil {
+		if !middleware.CanInOrganization(c, policy.ActionManageDomains) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to change the subdomain"})
+			return
+		}
+
+		subdomain := strings.ToLower(strings.TrimSpace(*updateData.Subdomain))
+		switch {
+		case subdomain == "":
+			orgModel.Subdomain = nil
+		case !subdomainLabel.MatchString(subdomain):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Subdomain must be a DNS label of lowercase letters, digits and hyphens"})
+			return
+		default:
+			if existing, err := h.orgRepo.GetOrganizationBySubdomain(c.Request.Context(), subdomain); err == nil && existing.ID != orgModel.ID {
+				c.JSON(http.StatusConflict, gin.H{"error": "Subdomain is already taken"})
+				return
+			}
+			orgModel.Subdomain = &subdomain
+		}
+	}
 
 	updatedOrg, err := h.orgRepo.UpdateOrganization(c.Request.Context(), orgModel)
+	if errors.Is(err, repository.ErrSlugTaken) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Slug is already taken"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update organization"})
 		return
 	}
+	audit.Record(c, audit.Entry{Action: "organization.update", TargetType: "organization", TargetID: updatedOrg.ID, Before: &before, After: updatedOrg})
 
 	c.JSON(http.StatusOK, gin.H{
 		"message":      "Organization updated successfully",
-		"organization": gin.H{"id": updatedOrg.ID, "name": updatedOrg.Name},
+		"organization": gin.H{"id": updatedOrg.ID, "name": updatedOrg.Name, "slug": updatedOrg.Slug, "subdomain": updatedOrg.Subdomain},
 	})
 }
 
-func (h *OrganizationHandler) DeleteOrganization(c *gin.Context) {
-	orgIDStr := c.Param("id")
-	orgID, err := strconv.ParseUint(orgIDStr, 10, 32)
+// RequestDeletion starts deleting the organization. Only its owner may do
+// so; the returned token must be sent back to DeleteOrganization before it
+// expires to confirm.
+func (h *OrganizationHandler) RequestDeletion(c *gin.Context) {
+	if !canDeleteOrganization(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the organization's owner can delete it"})
+		return
+	}
+
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate confirmation token"})
+		return
+	}
+	token := hex.EncodeToString(buf)
+
+	orgID, _ := c.Get(middleware.OrganizationKey)
+	userID, _ := c.Get("userID")
+	deletion := &model.OrganizationDeletion{
+		OrganizationID: orgID.(uint),
+		RequestedBy:    userID.(uint),
+		TokenHash:      hashConfirmationToken(token),
+		ExpiresAt:      time.Now().Add(deletionConfirmationTTL),
+		Status:         model.DeletionPendingConfirmation,
+	}
+
+	createdDeletion, err := h.deletionRepo.CreateDeletion(c.Request.Context(), deletion)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid organization ID"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to request deletion"})
+		return
+	}
+	audit.Record(c, audit.Entry{Action: "organization.deletion_request", TargetType: "organization_deletion", TargetID: createdDeletion.ID, After: createdDeletion})
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":            "Confirm the deletion by sending the confirmation token to DELETE on the organization",
+		"confirmation_token": token,
+		"expires_at":         createdDeletion.ExpiresAt,
+	})
+}
+
+// DeleteOrganization confirms a deletion requested through RequestDeletion
+// and hands it to the background worker. Progress can be polled at
+// /organization-deletions/:deletionId.
+func (h *OrganizationHandler) DeleteOrganization(c *gin.Context) {
+	if !canDeleteOrganization(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the organization's owner can delete it"})
 		return
 	}
 
-	err = h.orgRepo.DeleteOrganization(c.Request.Context(), uint(orgID))
+	var req DeleteOrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	orgID, _ := c.Get(middleware.OrganizationKey)
+	deletion, err := h.deletionRepo.ConfirmDeletion(c.Request.Context(), orgID.(uint), hashConfirmationToken(req.ConfirmationToken))
+	if errors.Is(err, repository.ErrInvalidConfirmation) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired confirmation token"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete organization"})
 		return
 	}
+	audit.Record(c, audit.Entry{Action: "organization.delete", TargetType: "organization", TargetID: orgID.(uint)})
+
+	c.Header("Location", fmt.Sprintf("/api/v1/organization-deletions/%d", deletion.ID))
+	h.deletions.Enqueue(deletion.ID)
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":  "Organization deletion scheduled",
+		"deletion": deletion,
+	})
+}
+
+// GetDeletion reports the progress of an organization deletion to the user
+// who requested it.
+func (h *OrganizationHandler) GetDeletion(c *gin.Context) {
+	deletionID, err := strconv.ParseUint(c.Param("deletionId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid deletion ID"})
+		return
+	}
+
+	deletion, err := h.deletionRepo.GetDeletionByID(c.Request.Context(), uint(deletionID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Deletion not found"})
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	userRole, _ := c.Get("userRole")
+	if deletion.RequestedBy != userID.(uint) && userRole != model.RoleSuperAdmin {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Deletion not found"})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Organization deleted successfully",
+		"deletion": deletion,
 	})
 }
+
+func canDeleteOrganization(c *gin.Context) bool {
+	role := middleware.EffectiveRole(c)
+	return role == model.RoleOwner || role == model.RoleSuperAdmin
+}
+
+func hashConfirmationToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,258 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/audit"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/middleware"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/policy"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/repository"
+)
+
+type OwnershipHandler struct {
+	ownershipRepo repository.OwnershipRepository
+}
+
+func NewOwnershipHandler(ownershipRepo repository.OwnershipRepository) *OwnershipHandler {
+	return &OwnershipHandler{
+		ownershipRepo: ownershipRepo,
+	}
+}
+
+type TransferOwnershipRequest struct {
+	UserID uint `json:"user_id" binding:"required"`
+}
+
+func (h *OwnershipHandler) GetOwner(c *gin.Context) {
+	if !middleware.CanInOrganization(c, policy.ActionView) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only members can view the organization's owner"})
+		return
+	}
+
+	orgID, _ := c.Get(middleware.OrganizationKey)
+	owner, err := h.ownershipRepo.GetOwner(c.Request.Context(), orgID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Organization has no owner"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"owner": owner,
+	})
+}
+
+// TransferOwnership offers ownership to another member. The caller stays
+// owner until the member accepts.
+func (h *OwnershipHandler) TransferOwnership(c *gin.Context) {
+	if middleware.EffectiveRole(c) != model.RoleOwner {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the organization's owner can transfer ownership"})
+		return
+	}
+
+	var req TransferOwnershipRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	if req.UserID == userID.(uint) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You already own this organization"})
+		return
+	}
+
+	orgID, _ := c.Get(middleware.OrganizationKey)
+	transfer := &model.OwnershipTransfer{
+		OrganizationID: orgID.(uint),
+		FromUserID:     userID.(uint),
+		ToUserID:       req.UserID,
+	}
+
+	createdTransfer, err := h.ownershipRepo.CreateTransfer(c.Request.Context(), transfer)
+	if errors.Is(err, repository.ErrNotMember) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Ownership can only be transferred to a member of the organization"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create ownership transfer"})
+		return
+	}
+	audit.Record(c, audit.Entry{Action: "ownership.transfer_offer", TargetType: "ownership_transfer", TargetID: createdTransfer.ID, After: createdTransfer})
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "Ownership transfer offered, waiting for the new owner to accept",
+		"transfer": createdTransfer,
+	})
+}
+
+func (h *OwnershipHandler) GetPendingTransfers(c *gin.Context) {
+	if !middleware.CanInOrganization(c, policy.ActionManageMembers) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only organization admins can view ownership transfers"})
+		return
+	}
+
+	orgID, _ := c.Get(middleware.OrganizationKey)
+	transfers, err := h.ownershipRepo.GetPendingTransfers(c.Request.Context(), orgID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch ownership transfers"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"transfers": transfers,
+	})
+}
+
+func (h *OwnershipHandler) CancelTransfer(c *gin.Context) {
+	if middleware.EffectiveRole(c) != model.RoleOwner {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the organization's owner can cancel ownership transfers"})
+		return
+	}
+
+	transfer, ok := h.transferFromParam(c)
+	if !ok {
+		return
+	}
+	orgID, _ := c.Get(middleware.OrganizationKey)
+	if transfer.OrganizationID != orgID.(uint) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ownership transfer not found"})
+		return
+	}
+
+	h.closeTransfer(c, transfer, model.TransferCancelled)
+}
+
+// GetIncomingTransfers lists the ownership offers waiting for the caller.
+func (h *OwnershipHandler) GetIncomingTransfers(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	transfers, err := h.ownershipRepo.GetIncomingTransfers(c.Request.Context(), userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch ownership transfers"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"transfers": transfers,
+	})
+}
+
+func (h *OwnershipHandler) AcceptTransfer(c *gin.Context) {
+	transfer, ok := h.incomingTransferFromParam(c)
+	if !ok {
+		return
+	}
+
+	before := *transfer
+	accepted, err := h.ownershipRepo.AcceptTransfer(c.Request.Context(), transfer)
+	switch {
+	case errors.Is(err, repository.ErrOwnerChanged):
+		c.JSON(http.StatusConflict, gin.H{"error": "The organization's owner has changed since the transfer was offered"})
+		return
+	case errors.Is(err, repository.ErrNotMember):
+		c.JSON(http.StatusConflict, gin.H{"error": "You are no longer a member of the organization"})
+		return
+	case err != nil:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ownership transfer not found"})
+		return
+	}
+	audit.Record(c, audit.Entry{Action: "ownership.transfer_accept", OrganizationID: accepted.OrganizationID, TargetType: "ownership_transfer", TargetID: accepted.ID, Before: &before, After: accepted})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "You are now the owner of the organization",
+		"transfer": accepted,
+	})
+}
+
+func (h *OwnershipHandler) DeclineTransfer(c *gin.Context) {
+	transfer, ok := h.incomingTransferFromParam(c)
+	if !ok {
+		return
+	}
+
+	h.closeTransfer(c, transfer, model.TransferDeclined)
+}
+
+// AssignOwner lets platform super-admins give an organization a new owner
+// directly, e.g. when its owner's account is gone.
+func (h *OwnershipHandler) AssignOwner(c *gin.Context) {
+	orgID, err := strconv.ParseUint(c.Param("orgId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid organization ID"})
+		return
+	}
+
+	var req TransferOwnershipRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	actorID, _ := c.Get("userID")
+	err = h.ownershipRepo.AssignOwner(c.Request.Context(), actorID.(uint), uint(orgID), req.UserID)
+	if errors.Is(err, repository.ErrNotMember) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The new owner must be a member of the organization"})
+		return
+	}
+	if respondQuotaExceeded(c, err) {
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to assign owner"})
+		return
+	}
+	audit.Record(c, audit.Entry{Action: "ownership.assign", OrganizationID: uint(orgID), TargetType: "user", TargetID: req.UserID})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Owner assigned successfully",
+	})
+}
+
+func (h *OwnershipHandler) closeTransfer(c *gin.Context, transfer *model.OwnershipTransfer, status string) {
+	before := *transfer
+	closed, err := h.ownershipRepo.CloseTransfer(c.Request.Context(), transfer, status)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ownership transfer not found"})
+		return
+	}
+	audit.Record(c, audit.Entry{Action: "ownership.transfer_" + status, OrganizationID: closed.OrganizationID, TargetType: "ownership_transfer", TargetID: closed.ID, Before: &before, After: closed})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Ownership transfer " + status,
+		"transfer": closed,
+	})
+}
+
+func (h *OwnershipHandler) transferFromParam(c *gin.Context) (*model.OwnershipTransfer, bool) {
+	transferID, err := strconv.ParseUint(c.Param("transferId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transfer ID"})
+		return nil, false
+	}
+
+	transfer, err := h.ownershipRepo.GetTransferByID(c.Request.Context(), uint(transferID))
+	if err != nil || transfer.Status != model.TransferPending {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ownership transfer not found"})
+		return nil, false
+	}
+	return transfer, true
+}
+
+// incomingTransferFromParam loads a pending transfer offered to the caller.
+func (h *OwnershipHandler) incomingTransferFromParam(c *gin.Context) (*model.OwnershipTransfer, bool) {
+	transfer, ok := h.transferFromParam(c)
+	if !ok {
+		return nil, false
+	}
+
+	userID, _ := c.Get("userID")
+	if transfer.ToUserID != userID.(uint) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ownership transfer not found"})
+		return nil, false
+	}
+	return transfer, true
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/audit"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/middleware"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/policy"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/repository"
+)
+
+var introspectedResources = []string{policy.ResourceOrganization, policy.ResourceArticle, policy.ResourceComment}
+
+type PermissionHandler struct {
+	articleRepo      repository.ArticleRepository
+	policyRepo       repository.PolicyRepository
+	roleRepo         repository.RoleRepository
+	collaboratorRepo repository.CollaboratorRepository
+	teamRepo         repository.TeamRepository
+	policies         *policy.Store
+}
+
+func NewPermissionHandler(articleRepo repository.ArticleRepository, policyRepo repository.PolicyRepository, roleRepo repository.RoleRepository, collaboratorRepo repository.CollaboratorRepository, teamRepo repository.TeamRepository, policies *policy.Store) *PermissionHandler {
+	return &PermissionHandler{
+		articleRepo:      articleRepo,
+		policyRepo:       policyRepo,
+		roleRepo:         roleRepo,
+		collaboratorRepo: collaboratorRepo,
+		teamRepo:         teamRepo,
+		policies:         policies,
+	}
+}
+
+type UpdatePolicyRequest struct {
+	Rules []policy.Rule `json:"rules"`
+}
+
+// GetPermissions tells the caller what they can do in the organization. Each
+// resource type lists the actions granted unconditionally and the ones that
+// depend on conditions. With ?article_id= it also returns the actions the
+// caller has on that specific article.
+func (h *PermissionHandler) GetPermissions(c *gin.Context) {
+	p := middleware.GetPolicyFromContext(c)
+	sub := middleware.CurrentSubject(c)
+
+	resources := gin.H{}
+	for _, resourceType := range introspectedResources {
+		unconditional := p.Allowed(sub, policy.Resource{Type: resourceType})
+		conditional := []policy.Rule{}
+		for _, rule := range p.RulesFor(sub.Role, resourceType) {
+			if len(rule.Conditions) > 0 {
+				conditional = append(conditional, rule)
+			}
+		}
+		resources[resourceType] = gin.H{
+			"actions":     unconditional,
+			"conditional": conditional,
+		}
+	}
+
+	response := gin.H{
+		"role":      sub.Role,
+		"resources": resources,
+	}
+
+	if articleIDStr := c.Query("article_id"); articleIDStr != "" {
+		articleID, err := strconv.ParseUint(articleIDStr, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid article ID"})
+			return
+		}
+
+		orgID, _ := c.Get(middleware.OrganizationKey)
+		article, err := h.articleRepo.GetArticleByID(c.Request.Context(), uint(articleID))
+		if err != nil || article.OrganizationID != orgID.(uint) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Article not found"})
+			return
+		}
+
+		actions, err := middleware.ResolveArticleActions(c, h.collaboratorRepo, h.teamRepo, article)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve article permissions"})
+			return
+		}
+
+		response["article"] = gin.H{
+			"id":      article.ID,
+			"actions": actions,
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *PermissionHandler) GetPolicy(c *gin.Context) {
+	if !middleware.CanInOrganization(c, policy.ActionManagePolicy) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to view this organization's policy"})
+		return
+	}
+
+	orgID, _ := c.Get(middleware.OrganizationKey)
+	rules, err := h.policyRepo.GetPolicyRules(c.Request.Context(), orgID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch policy"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"rules":     rules,
+		"effective": middleware.GetPolicyFromContext(c).Rules,
+	})
+}
+
+// UpdatePolicy replaces the organization's policy overrides. Rules for a role
+// replace the default rules of that role; an empty list restores defaults.
+// Custom roles are managed through the roles endpoints instead.
+func (h *PermissionHandler) UpdatePolicy(c *gin.Context) {
+	if !middleware.CanInOrganization(c, policy.ActionManagePolicy) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to change this organization's policy"})
+		return
+	}
+
+	var req UpdatePolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	requested := policy.Policy{Rules: req.Rules}
+	if err := requested.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	orgID, _ := c.Get(middleware.OrganizationKey)
+	rules := make([]model.PolicyRule, 0, len(req.Rules))
+	for _, rule := range req.Rules {
+		if _, err := h.roleRepo.GetOrganizationRoleByName(c.Request.Context(), orgID.(uint), rule.Role); err == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Rules of custom role " + rule.Role + " are managed through the roles endpoints"})
+			return
+		}
+
+		rules = append(rules, model.PolicyRule{
+			Role:       rule.Role,
+			Resource:   rule.Resource,
+			Actions:    rule.Actions,
+			Conditions: rule.Conditions,
+		})
+	}
+
+	// Rules of custom roles are not replaced, so they stay out of the diff.
+	previous, err := h.policyRepo.GetPolicyRules(c.Request.Context(), orgID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update policy"})
+		return
+	}
+	customRoles, err := h.roleRepo.GetOrganizationRoles(c.Request.Context(), orgID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update policy"})
+		return
+	}
+	custom := make(map[string]bool, len(customRoles))
+	for _, role := range customRoles {
+		custom[role.Name] = true
+	}
+	before := make([]policy.Rule, 0, len(previous))
+	for _, rule := range previous {
+		if !custom[rule.Role] {
+			before = append(before, policy.Rule{Role: rule.Role, Resource: rule.Resource, Actions: rule.Actions, Conditions: rule.Conditions})
+		}
+	}
+
+	saved, err := h.policyRepo.ReplacePolicyRules(c.Request.Context(), orgID.(uint), rules)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update policy"})
+		return
+	}
+	audit.Record(c, audit.Entry{Action: "policy.update", TargetType: "policy", TargetID: orgID.(uint), Before: before, After: req.Rules})
+	middleware.AfterCommit(c, func() { h.policies.Invalidate(orgID.(uint)) })
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Policy updated successfully",
+		"rules":   saved,
+	})
+}
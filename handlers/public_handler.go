@@ -0,0 +1,323 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/markup"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/middleware"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/slug"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/repository"
+)
+
+const (
+	summaryLength = 280
+	// publicMaxAge is how long clients and proxies may reuse public
+	// responses without asking again.
+	publicMaxAge = 5 * time.Minute
+)
+
+// PublicHandler serves an organization's published articles to anyone, as
+// a read-only blog API. Responses only carry the fields readers need.
+type PublicHandler struct {
+	articleRepo repository.ArticleRepository
+	tagRepo     repository.TagRepository
+	userRepo    repository.UserRepository
+	roleRepo    repository.RoleRepository
+}
+
+func NewPublicHandler(articleRepo repository.ArticleRepository, tagRepo repository.TagRepository, userRepo repository.UserRepository, roleRepo repository.RoleRepository) *PublicHandler {
+	return &PublicHandler{
+		articleRepo: articleRepo,
+		tagRepo:     tagRepo,
+		userRepo:    userRepo,
+		roleRepo:    roleRepo,
+	}
+}
+
+// PublicOrganization is the organization articles are published by, with
+// its branding.
+type PublicOrganization struct {
+	Name         string `json:"name"`
+	URL          string `json:"url"`
+	LogoURL      string `json:"logo_url,omitempty"`
+	PrimaryColor string `json:"primary_color,omitempty"`
+}
+
+// PublicArticle is a published article as readers see it. Content is only
+// included when a single article is requested. Times are in the
+// organization's time zone.
+type PublicArticle struct {
+	Slug        string          `json:"slug"`
+	Title       string          `json:"title"`
+	Summary     string          `json:"summary"`
+	ContentHTML string          `json:"content_html,omitempty"`
+	URL         string          `json:"url"`
+	PublishedAt time.Time       `json:"published_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	Author      PublicAuthor    `json:"author"`
+	Category    *PublicCategory `json:"category,omitempty"`
+	Tags        []PublicTag     `json:"tags"`
+}
+
+type PublicAuthor struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+type PublicCategory struct {
+	Slug string `json:"slug"`
+	Name string `json:"name"`
+}
+
+type PublicTag struct {
+	Slug string `json:"slug"`
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// GetArticles lists the organization's published public articles, most
+// recently published first.
+func (h *PublicHandler) GetArticles(c *gin.Context) {
+	org := c.MustGet("organization").(*model.Organization)
+	h.serveArticles(c, org, repository.PublishedFilter{}, nil)
+}
+
+// GetArticle returns the published article with the :slug slug, unless only
+// members may read it. Old slugs of renamed articles redirect to the
+// current one.
+func (h *PublicHandler) GetArticle(c *gin.Context) {
+	org := c.MustGet("organization").(*model.Organization)
+	articleSlug := c.Param("slug")
+	if !slug.Valid(articleSlug) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Article not found"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	article, err := h.articleRepo.GetArticleBySlug(ctx, org.ID, articleSlug)
+	renamed := false
+	if err != nil {
+		article, err = h.articleRepo.GetArticleByOldSlug(ctx, org.ID, articleSlug)
+		renamed = true
+	}
+	// Private articles are unlisted but readable by anyone with their link.
+	if err != nil || article.OrganizationID != org.ID || article.Status != "published" || article.Visibility == model.VisibilityOrganization {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Article not found"})
+		return
+	}
+	if renamed && middleware.RedirectToCurrentSlug(c, "slug", article.Slug) {
+		return
+	}
+
+	public := publicArticle(c, org, article, middleware.GetSettingsFromContext(c).Location())
+	public.ContentHTML = article.ContentHTML
+	serveCachedJSON(c, gin.H{
+		"article":      public,
+		"organization": publicOrganization(c, org),
+	}, article.UpdatedAt)
+}
+
+// GetTagArticles lists the organization's published articles with the :tag
+// tag.
+func (h *PublicHandler) GetTagArticles(c *gin.Context) {
+	org := c.MustGet("organization").(*model.Organization)
+	tag, err := h.tagRepo.GetTagBySlug(c.Request.Context(), org.ID, c.Param("tag"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tag not found"})
+		return
+	}
+
+	h.serveArticles(c, org, repository.PublishedFilter{Tag: tag.Slug}, gin.H{
+		"tag": publicTag(c, org, tag),
+	})
+}
+
+// GetAuthorArticles lists the articles the :authorId user published in the
+// organization.
+func (h *PublicHandler) GetAuthorArticles(c *gin.Context) {
+	org := c.MustGet("organization").(*model.Organization)
+	author, ok := authorFromParam(c, h.articleRepo, h.userRepo, h.roleRepo, org.ID)
+	if !ok {
+		return
+	}
+
+	h.serveArticles(c, org, repository.PublishedFilter{AuthorID: author.ID}, gin.H{
+		"author": publicAuthor(c, org, author),
+	})
+}
+
+// serveArticles answers with a page of the published articles filter
+// selects, next to the fields of extra.
+func (h *PublicHandler) serveArticles(c *gin.Context, org *model.Organization, filter repository.PublishedFilter, extra gin.H) {
+	page, pageSize, ok := pagination(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "page and page_size must be positive integers"})
+		return
+	}
+
+	filter.Page, filter.PageSize = page, pageSize
+	articles, total, err := h.articleRepo.GetPublishedArticlesByOrganization(c.Request.Context(), org.ID, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch articles"})
+		return
+	}
+
+	modified := org.UpdatedAt
+	loc := middleware.GetSettingsFromContext(c).Location()
+	public := make([]PublicArticle, 0, len(articles))
+	for i := range articles {
+		if articles[i].UpdatedAt.After(modified) {
+			modified = articles[i].UpdatedAt
+		}
+		public = append(public, publicArticle(c, org, &articles[i], loc))
+	}
+
+	response := gin.H{
+		"organization": publicOrganization(c, org),
+		"articles":     public,
+		"page":         page,
+		"page_size":    pageSize,
+		"total":        total,
+	}
+	for key, value := range extra {
+		response[key] = value
+	}
+	serveCachedJSON(c, response, modified)
+}
+
+func publicOrganization(c *gin.Context, org *model.Organization) PublicOrganization {
+	branding := middleware.GetSettingsFromContext(c).Branding
+	return PublicOrganization{
+		Name:         org.Name,
+		URL:          publicURL(c, org) + "/articles",
+		LogoURL:      branding.LogoURL,
+		PrimaryColor: branding.PrimaryColor,
+	}
+}
+
+func publicArticle(c *gin.Context, org *model.Organization, article *model.Article, loc *time.Location) PublicArticle {
+	base := publicURL(c, org)
+	public := PublicArticle{
+		Slug:        article.Slug,
+		Title:       article.Title,
+		Summary:     markup.Summary(article.ContentHTML, summaryLength),
+		URL:         base + "/articles/" + article.Slug,
+		PublishedAt: publishedAt(*article).In(loc),
+		UpdatedAt:   article.UpdatedAt.In(loc),
+		Author:      publicAuthor(c, org, &article.User),
+		Tags:        make([]PublicTag, 0, len(article.Tags)),
+	}
+	if article.Category != nil {
+		public.Category = &PublicCategory{Slug: article.Category.Slug, Name: article.Category.Name}
+	}
+	for i := range article.Tags {
+		public.Tags = append(public.Tags, publicTag(c, org, &article.Tags[i]))
+	}
+	return public
+}
+
+func publicAuthor(c *gin.Context, org *model.Organization, user *model.User) PublicAuthor {
+	return PublicAuthor{
+		ID:   user.ID,
+		Name: user.Name,
+		URL:  fmt.Sprintf("%s/authors/%d/articles", publicURL(c, org), user.ID),
+	}
+}
+
+func publicTag(c *gin.Context, org *model.Organization, tag *model.Tag) PublicTag {
+	return PublicTag{
+		Slug: tag.Slug,
+		Name: tag.Name,
+		URL:  publicURL(c, org) + "/tags/" + tag.Slug + "/articles",
+	}
+}
+
+// authorFromParam loads the :authorId user if they are a member of the
+// organization or have published in it. It writes the error response
+// itself.
+func authorFromParam(c *gin.Context, articleRepo repository.ArticleRepository, userRepo repository.UserRepository, roleRepo repository.RoleRepository, orgID uint) (*model.User, bool) {
+	authorID, err := strconv.ParseUint(c.Param("authorId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid author ID"})
+		return nil, false
+	}
+
+	ctx := c.Request.Context()
+	published, _, err := articleRepo.GetPublishedArticlesByOrganization(ctx, orgID, repository.PublishedFilter{AuthorID: uint(authorID), Page: 1, PageSize: 1})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch author"})
+		return nil, false
+	}
+	if len(published) > 0 {
+		return &published[0].User, true
+	}
+
+	if _, err := roleRepo.GetMembership(ctx, uint(authorID), orgID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Author not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch author"})
+		}
+		return nil, false
+	}
+	author, err := userRepo.GetUserByID(ctx, uint(authorID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Author not found"})
+		return nil, false
+	}
+	return author, true
+}
+
+// publicURL is the absolute URL of the organization's public API, as seen
+// by the client.
+func publicURL(c *gin.Context, org *model.Organization) string {
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	key := org.Slug
+	if key == "" {
+		key = strconv.FormatUint(uint64(org.ID), 10)
+	}
+	return fmt.Sprintf("%s://%s/api/v1/public/%s", scheme, c.Request.Host, key)
+}
+
+func publishedAt(article model.Article) time.Time {
+	if article.PublishedAt != nil {
+		return *article.PublishedAt
+	}
+	return article.CreatedAt
+}
+
+func serveCachedJSON(c *gin.Context, response gin.H, modified time.Time) {
+	body, err := json.Marshal(response)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode response"})
+		return
+	}
+	serveCached(c, "application/json; charset=utf-8", body, modified)
+}
+
+// serveCached writes a public response with an ETag and Last-Modified time,
+// and answers conditional requests whose copy is still current with 304 Not
+// Modified.
+func serveCached(c *gin.Context, contentType string, body []byte, modified time.Time) {
+	sum := sha256.Sum256(body)
+	c.Header("Content-Type", contentType)
+	c.Header("ETag", `"`+hex.EncodeToString(sum[:16])+`"`)
+	c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(publicMaxAge.Seconds())))
+	http.ServeContent(c.Writer, c.Request, "", modified, bytes.NewReader(body))
+}
@@ -0,0 +1,401 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/audit"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/middleware"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/policy"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/repository"
+)
+
+var (
+	organizationRoles = map[string]bool{model.RoleAdmin: true, model.RoleEditor: true, model.RoleMember: true}
+	globalRoles       = map[string]bool{model.RoleSuperAdmin: true, model.RoleAdmin: true, model.RoleMember: true}
+
+	customRoleName = regexp.MustCompile(`^[a-z][a-z0-9_-]{1,49}$`)
+)
+
+type RoleHandler struct {
+	roleRepo repository.RoleRepository
+	policies *policy.Store
+}
+
+func NewRoleHandler(roleRepo repository.RoleRepository, policies *policy.Store) *RoleHandler {
+	return &RoleHandler{
+		roleRepo: roleRepo,
+		policies: policies,
+	}
+}
+
+type UpdateRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+type RolePermission struct {
+	Resource   string   `json:"resource" binding:"required"`
+	Actions    []string `json:"actions" binding:"required"`
+	Conditions []string `json:"conditions"`
+}
+
+type SaveOrganizationRoleRequest struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description"`
+	Permissions []RolePermission `json:"permissions" binding:"dive"`
+}
+
+func (h *RoleHandler) UpdateMemberRole(c *gin.Context) {
+	if !middleware.CanInOrganization(c, policy.ActionManageMembers) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only organization admins can change member roles"})
+		return
+	}
+
+	org, exists := c.Get("organization")
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Organization not found in context"})
+		return
+	}
+
+	actorID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	targetID, err := strconv.ParseUint(c.Param("userId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if uint(targetID) == actorID.(uint) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You cannot change your own role"})
+		return
+	}
+
+	var req UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	orgModel := org.(*model.Organization)
+	if !organizationRoles[req.Role] {
+		if _, err := h.roleRepo.GetOrganizationRoleByName(c.Request.Context(), orgModel.ID, req.Role); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid organization role"})
+			return
+		}
+	}
+
+	change, err := h.roleRepo.SetOrganizationRole(c.Request.Context(), actorID.(uint), uint(targetID), orgModel.ID, req.Role)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User is not a member of this organization"})
+			return
+		}
+		if errors.Is(err, repository.ErrOwnerRole) {
+			c.JSON(http.StatusConflict, gin.H{"error": "The owner's role can only change through an ownership transfer"})
+			return
+		}
+		if errors.Is(err, repository.ErrLastAdmin) {
+			c.JSON(http.StatusConflict, gin.H{"error": "The organization's last admin cannot be demoted"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update member role"})
+		return
+	}
+	audit.Record(c, audit.Entry{Action: "member.role_change", TargetType: "user", TargetID: uint(targetID),
+		Before: gin.H{"role": change.OldRole}, After: gin.H{"role": change.NewRole}})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Member role updated successfully",
+		"role_change": change,
+	})
+}
+
+func (h *RoleHandler) GetOrganizationRoleChanges(c *gin.Context) {
+	if !middleware.CanInOrganization(c, policy.ActionManageMembers) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only organization admins can view role changes"})
+		return
+	}
+
+	org, exists := c.Get("organization")
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Organization not found in context"})
+		return
+	}
+
+	orgModel := org.(*model.Organization)
+	changes, err := h.roleRepo.GetRoleChangesByOrganization(c.Request.Context(), orgModel.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch role changes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"role_changes": changes,
+	})
+}
+
+func (h *RoleHandler) UpdateGlobalRole(c *gin.Context) {
+	actorID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	targetID, err := strconv.ParseUint(c.Param("userId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if uint(targetID) == actorID.(uint) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You cannot change your own role"})
+		return
+	}
+
+	var req UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !globalRoles[req.Role] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid global role"})
+		return
+	}
+
+	change, err := h.roleRepo.SetGlobalRole(c.Request.Context(), actorID.(uint), uint(targetID), req.Role)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user role"})
+		return
+	}
+	audit.Record(c, audit.Entry{Action: "user.role_change", TargetType: "user", TargetID: uint(targetID),
+		Before: gin.H{"role": change.OldRole}, After: gin.H{"role": change.NewRole}})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "User role updated successfully",
+		"role_change": change,
+	})
+}
+
+func (h *RoleHandler) GetGlobalRoleChanges(c *gin.Context) {
+	changes, err := h.roleRepo.GetGlobalRoleChanges(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch role changes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"role_changes": changes,
+	})
+}
+
+// GetOrganizationRoles lists the built-in roles and the organization's custom
+// roles together with the rules each custom role grants.
+func (h *RoleHandler) GetOrganizationRoles(c *gin.Context) {
+	if !middleware.CanInOrganization(c, policy.ActionView) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only organization members can view its roles"})
+		return
+	}
+
+	org, exists := c.Get("organization")
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Organization not found in context"})
+		return
+	}
+
+	orgModel := org.(*model.Organization)
+	roles, err := h.roleRepo.GetOrganizationRoles(c.Request.Context(), orgModel.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch roles"})
+		return
+	}
+
+	p := middleware.GetPolicyFromContext(c)
+	custom := make([]gin.H, 0, len(roles))
+	for _, role := range roles {
+		custom = append(custom, gin.H{
+			"name":        role.Name,
+			"description": role.Description,
+			"permissions": customRulesOf(p, role.Name),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"builtin": []string{model.RoleAdmin, model.RoleEditor, model.RoleMember},
+		"custom":  custom,
+	})
+}
+
+func (h *RoleHandler) CreateOrganizationRole(c *gin.Context) {
+	if !canManageRoles(c) {
+		return
+	}
+	h.saveOrganizationRole(c, nil)
+}
+
+func (h *RoleHandler) UpdateOrganizationRole(c *gin.Context) {
+	// Checked before the role is looked up, so callers who may not manage
+	// roles cannot tell which ones exist.
+	if !canManageRoles(c) {
+		return
+	}
+
+	org, exists := c.Get("organization")
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Organization not found in context"})
+		return
+	}
+
+	orgModel := org.(*model.Organization)
+	role, err := h.roleRepo.GetOrganizationRoleByName(c.Request.Context(), orgModel.ID, c.Param("roleName"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Role not found"})
+		return
+	}
+
+	h.saveOrganizationRole(c, role)
+}
+
+func (h *RoleHandler) saveOrganizationRole(c *gin.Context, existing *model.OrganizationRole) {
+	org, exists := c.Get("organization")
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Organization not found in context"})
+		return
+	}
+	orgModel := org.(*model.Organization)
+
+	var req SaveOrganizationRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var before interface{}
+	if existing != nil {
+		before = gin.H{"name": existing.Name, "description": existing.Description, "permissions": customRulesOf(middleware.GetPolicyFromContext(c), existing.Name)}
+	}
+
+	role := existing
+	if role == nil {
+		if organizationRoles[req.Name] || globalRoles[req.Name] || req.Name == model.RoleOwner || !customRoleName.MatchString(req.Name) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Role name must be lowercase letters, digits, '-' or '_' and not a built-in role"})
+			return
+		}
+		if _, err := h.roleRepo.GetOrganizationRoleByName(c.Request.Context(), orgModel.ID, req.Name); err == nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "Role already exists"})
+			return
+		}
+		role = &model.OrganizationRole{OrganizationID: orgModel.ID, Name: req.Name}
+	} else if req.Name != "" && req.Name != role.Name {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Roles cannot be renamed"})
+		return
+	}
+	role.Description = req.Description
+
+	rules := make([]model.PolicyRule, 0, len(req.Permissions))
+	for _, perm := range req.Permissions {
+		rule := policy.Rule{Role: role.Name, Resource: perm.Resource, Actions: perm.Actions, Conditions: perm.Conditions}
+		if err := rule.Validate(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		rules = append(rules, model.PolicyRule{Resource: perm.Resource, Actions: perm.Actions, Conditions: perm.Conditions})
+	}
+
+	saved, err := h.roleRepo.SaveOrganizationRole(c.Request.Context(), role, rules)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save role"})
+		return
+	}
+	middleware.AfterCommit(c, func() { h.policies.Invalidate(orgModel.ID) })
+
+	status, message, action := http.StatusOK, "Role updated successfully", "role.update"
+	if existing == nil {
+		status, message, action = http.StatusCreated, "Role created successfully", "role.create"
+	}
+	after := gin.H{
+		"name":        saved.Name,
+		"description": saved.Description,
+		"permissions": req.Permissions,
+	}
+	audit.Record(c, audit.Entry{Action: action, TargetType: "role", TargetID: saved.ID, Before: before, After: after})
+
+	c.JSON(status, gin.H{
+		"message": message,
+		"role":    after,
+	})
+}
+
+func (h *RoleHandler) DeleteOrganizationRole(c *gin.Context) {
+	if !canManageRoles(c) {
+		return
+	}
+
+	org, exists := c.Get("organization")
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Organization not found in context"})
+		return
+	}
+
+	orgModel := org.(*model.Organization)
+	role, err := h.roleRepo.GetOrganizationRoleByName(c.Request.Context(), orgModel.ID, c.Param("roleName"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Role not found"})
+		return
+	}
+
+	assigned, err := h.roleRepo.CountMembersWithRole(c.Request.Context(), orgModel.ID, role.Name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete role"})
+		return
+	}
+	if assigned > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Role is still assigned to members"})
+		return
+	}
+
+	if err := h.roleRepo.DeleteOrganizationRole(c.Request.Context(), role); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete role"})
+		return
+	}
+	audit.Record(c, audit.Entry{Action: "role.delete", TargetType: "role", TargetID: role.ID, Before: role})
+	middleware.AfterCommit(c, func() { h.policies.Invalidate(orgModel.ID) })
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Role deleted successfully",
+	})
+}
+
+func customRulesOf(p *policy.Policy, role string) []RolePermission {
+	permissions := []RolePermission{}
+	for _, rule := range p.Rules {
+		if rule.Role == role {
+			permissions = append(permissions, RolePermission{Resource: rule.Resource, Actions: rule.Actions, Conditions: rule.Conditions})
+		}
+	}
+	return permissions
+}
+
+// canManageRoles reports whether the caller may create, change and delete
+// the organization's custom roles. It writes the error response itself.
+func canManageRoles(c *gin.Context) bool {
+	if !middleware.CanInOrganization(c, policy.ActionManageMembers) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only organization admins can manage roles"})
+		return false
+	}
+	return true
+}
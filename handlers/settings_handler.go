@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/audit"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/middleware"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/policy"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/settings"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/repository"
+)
+
+type SettingsHandler struct {
+	settingsRepo repository.SettingsRepository
+	settings     *settings.Store
+}
+
+func NewSettingsHandler(settingsRepo repository.SettingsRepository, store *settings.Store) *SettingsHandler {
+	return &SettingsHandler{
+		settingsRepo: settingsRepo,
+		settings:     store,
+	}
+}
+
+// UpdateSettingsRequest changes the fields that are present and leaves the
+// others alone. An empty string resets a field to its default.
+type UpdateSettingsRequest struct {
+	CommentPolicy            *string         `json:"comment_policy"`
+	DefaultArticleVisibility *string         `json:"default_article_visibility"`
+	DeletedAuthorArticles    *string         `json:"deleted_author_articles"`
+	Locale                   *string         `json:"locale"`
+	Timezone                 *string         `json:"timezone"`
+	Branding                 *BrandingUpdate `json:"branding"`
+	Features                 map[string]bool `json:"features"`
+}
+
+type BrandingUpdate struct {
+	LogoURL      *string `json:"logo_url"`
+	PrimaryColor *string `json:"primary_color"`
+}
+
+func (h *SettingsHandler) GetSettings(c *gin.Context) {
+	if !middleware.CanInOrganization(c, policy.ActionView) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only members can view the organization's settings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"settings":           middleware.GetSettingsFromContext(c),
+		"available_features": settings.Features(),
+	})
+}
+
+func (h *SettingsHandler) UpdateSettings(c *gin.Context) {
+	if !middleware.CanInOrganization(c, policy.ActionManageSettings) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only organization admins can change settings"})
+		return
+	}
+
+	var req UpdateSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	orgID, _ := c.Get(middleware.OrganizationKey)
+	stored, err := h.settingsRepo.GetSettings(c.Request.Context(), orgID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch settings"})
+		return
+	}
+	if stored == nil {
+		stored = &model.OrganizationSettings{OrganizationID: orgID.(uint)}
+	}
+	before := *stored
+
+	setIfPresent(&stored.CommentPolicy, req.CommentPolicy)
+	setIfPresent(&stored.DefaultArticleVisibility, req.DefaultArticleVisibility)
+	setIfPresent(&stored.DeletedAuthorArticles, req.DeletedAuthorArticles)
+	setIfPresent(&stored.Locale, req.Locale)
+	setIfPresent(&stored.Timezone, req.Timezone)
+	if req.Branding != nil {
+		setIfPresent(&stored.LogoURL, req.Branding.LogoURL)
+		setIfPresent(&stored.PrimaryColor, req.Branding.PrimaryColor)
+	}
+	if len(req.Features) > 0 {
+		features := make(map[string]bool, len(stored.Features)+len(req.Features))
+		for flag, enabled := range stored.Features {
+			features[flag] = enabled
+		}
+		for flag, enabled := range req.Features {
+			features[flag] = enabled
+		}
+		stored.Features = features
+	}
+
+	// Validate what the organization ends up with, but reject unknown flags
+	// from the request too since Resolve would silently drop them.
+	resolved := settings.Resolve(stored)
+	for flag, enabled := range req.Features {
+		resolved.Features[flag] = enabled
+	}
+	if err := resolved.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, err := h.settingsRepo.SaveSettings(c.Request.Context(), stored); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save settings"})
+		return
+	}
+	audit.Record(c, audit.Entry{Action: "settings.update", TargetType: "settings", TargetID: orgID.(uint), Before: &before, After: stored})
+	middleware.AfterCommit(c, func() { h.settings.Invalidate(orgID.(uint)) })
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Settings updated successfully",
+		"settings": resolved,
+	})
+}
+
+func setIfPresent(field *string, value *string) {
+	if value != nil {
+		*field = *value
+	}
+}
@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/audit"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/middleware"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/policy"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/repository"
+)
+
+type TagHandler struct {
+	tagRepo repository.TagRepository
+}
+
+func NewTagHandler(tagRepo repository.TagRepository) *TagHandler {
+	return &TagHandler{
+		tagRepo: tagRepo,
+	}
+}
+
+type TagRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// GetTags lists the organization's tags with their article counts, for tag
+// clouds. With ?status= only articles in that status are counted and unused
+// tags are left out. Callers who may not view the organization's articles
+// only see tags of published public articles, counting those alone.
+func (h *TagHandler) GetTags(c *gin.Context) {
+	orgID, _ := c.Get(middleware.OrganizationKey)
+	publicOnly := !middleware.CanInOrganization(c, policy.ActionView)
+	tags, err := h.tagRepo.GetTagCounts(c.Request.Context(), orgID.(uint), c.Query("status"), publicOnly)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tags"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tags": tags,
+	})
+}
+
+func (h *TagHandler) CreateTag(c *gin.Context) {
+	if !middleware.CanInOrganization(c, policy.ActionManageTaxonomy) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to manage tags"})
+		return
+	}
+
+	name, ok := bindTagName(c)
+	if !ok {
+		return
+	}
+
+	orgID, _ := c.Get(middleware.OrganizationKey)
+	tag := &model.Tag{
+		OrganizationID: orgID.(uint),
+		Name:           name,
+	}
+
+	createdTag, err := h.tagRepo.CreateTag(c.Request.Context(), tag)
+	if errors.Is(err, repository.ErrSlugTaken) {
+		c.JSON(http.StatusConflict, gin.H{"error": "A tag with this name already exists"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create tag"})
+		return
+	}
+	audit.Record(c, audit.Entry{Action: "tag.create", TargetType: "tag", TargetID: createdTag.ID, After: createdTag})
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Tag created successfully",
+		"tag":     createdTag,
+	})
+}
+
+// UpdateTag renames the tag. Its slug follows the new name.
+func (h *TagHandler) UpdateTag(c *gin.Context) {
+	if !middleware.CanInOrganization(c, policy.ActionManageTaxonomy) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to manage tags"})
+		return
+	}
+
+	tag, ok := h.tagFromParam(c)
+	if !ok {
+		return
+	}
+
+	name, ok := bindTagName(c)
+	if !ok {
+		return
+	}
+
+	before := *tag
+	tag.Name = name
+
+	updatedTag, err := h.tagRepo.UpdateTag(c.Request.Context(), tag)
+	if errors.Is(err, repository.ErrSlugTaken) {
+		c.JSON(http.StatusConflict, gin.H{"error": "A tag with this name already exists"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update tag"})
+		return
+	}
+	audit.Record(c, audit.Entry{Action: "tag.update", TargetType: "tag", TargetID: updatedTag.ID, Before: &before, After: updatedTag})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Tag updated successfully",
+		"tag":     updatedTag,
+	})
+}
+
+func (h *TagHandler) DeleteTag(c *gin.Context) {
+	if !middleware.CanInOrganization(c, policy.ActionManageTaxonomy) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to manage tags"})
+		return
+	}
+
+	tag, ok := h.tagFromParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.tagRepo.DeleteTag(c.Request.Context(), tag.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete tag"})
+		return
+	}
+	audit.Record(c, audit.Entry{Action: "tag.delete", TargetType: "tag", TargetID: tag.ID, Before: tag})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Tag deleted successfully",
+	})
+}
+
+// tagFromParam loads the :tagId tag and makes sure it belongs to the
+// organization in context. It writes the error response itself.
+func (h *TagHandler) tagFromParam(c *gin.Context) (*model.Tag, bool) {
+	tagID, err := strconv.ParseUint(c.Param("tagId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tag ID"})
+		return nil, false
+	}
+
+	orgID, _ := c.Get(middleware.OrganizationKey)
+	tag, err := h.tagRepo.GetTagByID(c.Request.Context(), uint(tagID))
+	if err != nil || tag.OrganizationID != orgID.(uint) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tag not found"})
+		return nil, false
+	}
+
+	return tag, true
+}
+
+func bindTagName(c *gin.Context) (string, bool) {
+	var req TagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return "", false
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" || utf8.RuneCountInString(name) > maxTagName {
+		c.JSON(http.StatusBadRequest, gin.H{"error": invalidTagNameMessage})
+		return "", false
+	}
+	return name, true
+}
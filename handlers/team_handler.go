@@ -0,0 +1,302 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/audit"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/middleware"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/policy"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/repository"
+)
+
+type TeamHandler struct {
+	teamRepo repository.TeamRepository
+	roleRepo repository.RoleRepository
+}
+
+func NewTeamHandler(teamRepo repository.TeamRepository, roleRepo repository.RoleRepository) *TeamHandler {
+	return &TeamHandler{
+		teamRepo: teamRepo,
+		roleRepo: roleRepo,
+	}
+}
+
+type CreateTeamRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+}
+
+type UpdateTeamRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type AddTeamMemberRequest struct {
+	UserID uint `json:"user_id" binding:"required"`
+}
+
+func (h *TeamHandler) CreateTeam(c *gin.Context) {
+	if !middleware.CanInOrganization(c, policy.ActionManageTeams) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to manage teams"})
+		return
+	}
+
+	org, exists := c.Get("organization")
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Organization not found in context"})
+		return
+	}
+
+	var req CreateTeamRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	orgModel := org.(*model.Organization)
+	team := &model.Team{
+		OrganizationID: orgModel.ID,
+		Name:           req.Name,
+		Description:    req.Description,
+	}
+
+	createdTeam, err := h.teamRepo.CreateTeam(c.Request.Context(), team)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create team"})
+		return
+	}
+	audit.Record(c, audit.Entry{Action: "team.create", TargetType: "team", TargetID: createdTeam.ID, After: createdTeam})
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Team created successfully",
+		"team":    createdTeam,
+	})
+}
+
+func (h *TeamHandler) GetTeams(c *gin.Context) {
+	if !middleware.CanInOrganization(c, policy.ActionView) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only members can view the organization's teams"})
+		return
+	}
+
+	org, exists := c.Get("organization")
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Organization not found in context"})
+		return
+	}
+
+	orgModel := org.(*model.Organization)
+	teams, err := h.teamRepo.GetTeamsByOrganization(c.Request.Context(), orgModel.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch teams"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"teams": teams,
+	})
+}
+
+func (h *TeamHandler) GetTeam(c *gin.Context) {
+	if !middleware.CanInOrganization(c, policy.ActionView) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only members can view the organization's teams"})
+		return
+	}
+
+	team, ok := h.teamFromParam(c)
+	if !ok {
+		return
+	}
+
+	members, err := h.teamRepo.GetTeamMembers(c.Request.Context(), team.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch team members"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"team":    team,
+		"members": teamMembersResponse(c, members),
+	})
+}
+
+func (h *TeamHandler) UpdateTeam(c *gin.Context) {
+	if !middleware.CanInOrganization(c, policy.ActionManageTeams) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to manage teams"})
+		return
+	}
+
+	team, ok := h.teamFromParam(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateTeamRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	before := *team
+	if req.Name != "" {
+		team.Name = req.Name
+	}
+	if req.Description != "" {
+		team.Description = req.Description
+	}
+
+	updatedTeam, err := h.teamRepo.UpdateTeam(c.Request.Context(), team)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update team"})
+		return
+	}
+	audit.Record(c, audit.Entry{Action: "team.update", TargetType: "team", TargetID: updatedTeam.ID, Before: &before, After: updatedTeam})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Team updated successfully",
+		"team":    updatedTeam,
+	})
+}
+
+func (h *TeamHandler) DeleteTeam(c *gin.Context) {
+	if !middleware.CanInOrganization(c, policy.ActionManageTeams) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to manage teams"})
+		return
+	}
+
+	team, ok := h.teamFromParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.teamRepo.DeleteTeam(c.Request.Context(), team.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete team"})
+		return
+	}
+	audit.Record(c, audit.Entry{Action: "team.delete", TargetType: "team", TargetID: team.ID, Before: team})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Team deleted successfully",
+	})
+}
+
+func (h *TeamHandler) GetTeamMembers(c *gin.Context) {
+	if !middleware.CanInOrganization(c, policy.ActionView) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only members can view the organization's teams"})
+		return
+	}
+
+	team, ok := h.teamFromParam(c)
+	if !ok {
+		return
+	}
+
+	members, err := h.teamRepo.GetTeamMembers(c.Request.Context(), team.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch team members"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"members": teamMembersResponse(c, members),
+	})
+}
+
+func (h *TeamHandler) AddTeamMember(c *gin.Context) {
+	if !middleware.CanInOrganization(c, policy.ActionManageTeams) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to manage teams"})
+		return
+	}
+
+	team, ok := h.teamFromParam(c)
+	if !ok {
+		return
+	}
+
+	var req AddTeamMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, err := h.roleRepo.GetMembership(c.Request.Context(), req.UserID, team.OrganizationID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "User is not a member of this organization"})
+		return
+	}
+
+	if err := h.teamRepo.AddTeamMember(c.Request.Context(), team.ID, req.UserID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add team member"})
+		return
+	}
+	audit.Record(c, audit.Entry{Action: "team.member_add", TargetType: "team", TargetID: team.ID, After: gin.H{"user_id": req.UserID}})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Team member added successfully",
+	})
+}
+
+func (h *TeamHandler) RemoveTeamMember(c *gin.Context) {
+	if !middleware.CanInOrganization(c, policy.ActionManageTeams) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to manage teams"})
+		return
+	}
+
+	team, ok := h.teamFromParam(c)
+	if !ok {
+		return
+	}
+
+	userID, err := strconv.ParseUint(c.Param("userId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if err := h.teamRepo.RemoveTeamMember(c.Request.Context(), team.ID, uint(userID)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove team member"})
+		return
+	}
+	audit.Record(c, audit.Entry{Action: "team.member_remove", TargetType: "team", TargetID: team.ID, Before: gin.H{"user_id": userID}})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Team member removed successfully",
+	})
+}
+
+// teamFromParam loads the :teamId team and makes sure it belongs to the
+// organization in context. It writes the error response itself.
+func (h *TeamHandler) teamFromParam(c *gin.Context) (*model.Team, bool) {
+	teamID, err := strconv.ParseUint(c.Param("teamId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid team ID"})
+		return nil, false
+	}
+
+	orgID, _ := c.Get(middleware.OrganizationKey)
+	team, err := h.teamRepo.GetTeamByID(c.Request.Context(), uint(teamID))
+	if err != nil || team.OrganizationID != orgID.(uint) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Team not found"})
+		return nil, false
+	}
+
+	return team, true
+}
+
+// teamMembersResponse lists the team's members. Email addresses are only
+// shown to those who manage teams.
+func teamMembersResponse(c *gin.Context, users []model.User) []gin.H {
+	withEmail := middleware.CanInOrganization(c, policy.ActionManageTeams)
+	members := make([]gin.H, 0, len(users))
+	for _, user := range users {
+		member := gin.H{"id": user.ID, "name": user.Name}
+		if withEmail {
+			member["email"] = user.Email
+		}
+		members = append(members, member)
+	}
+	return members
+}
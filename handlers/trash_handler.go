@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/audit"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/middleware"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/policy"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/repository"
+)
+
+type TrashHandler struct {
+	trashRepo     repository.TrashRepository
+	retentionDays int
+}
+
+func NewTrashHandler(trashRepo repository.TrashRepository, retentionDays int) *TrashHandler {
+	return &TrashHandler{
+		trashRepo:     trashRepo,
+		retentionDays: retentionDays,
+	}
+}
+
+// GetTrash lists the organization's deleted articles and comments. They are
+// purged retention_days after deletion.
+func (h *TrashHandler) GetTrash(c *gin.Context) {
+	if !middleware.CanInOrganization(c, policy.ActionManageTrash) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to manage the trash"})
+		return
+	}
+
+	orgID, _ := c.Get(middleware.OrganizationKey)
+	articles, err := h.trashRepo.GetTrashedArticles(c.Request.Context(), orgID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch trash"})
+		return
+	}
+	comments, err := h.trashRepo.GetTrashedComments(c.Request.Context(), orgID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch trash"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"articles":       articles,
+		"comments":       comments,
+		"retention_days": h.retentionDays,
+	})
+}
+
+func (h *TrashHandler) RestoreArticle(c *gin.Context) {
+	if !middleware.CanInOrganization(c, policy.ActionManageTrash) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to manage the trash"})
+		return
+	}
+
+	articleID, err := strconv.ParseUint(c.Param("articleId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid article ID"})
+		return
+	}
+
+	orgID, _ := c.Get(middleware.OrganizationKey)
+	article, err := h.trashRepo.RestoreArticle(c.Request.Context(), orgID.(uint), uint(articleID))
+	if respondQuotaExceeded(c, err) {
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Article not found in trash"})
+		return
+	}
+	audit.Record(c, audit.Entry{Action: "article.restore", TargetType: "article", TargetID: article.ID})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Article restored successfully",
+		"article": article,
+	})
+}
+
+func (h *TrashHandler) RestoreComment(c *gin.Context) {
+	if !middleware.CanInOrganization(c, policy.ActionManageTrash) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to manage the trash"})
+		return
+	}
+
+	commentID, err := strconv.ParseUint(c.Param("commentId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid comment ID"})
+		return
+	}
+
+	orgID, _ := c.Get(middleware.OrganizationKey)
+	comment, err := h.trashRepo.RestoreComment(c.Request.Context(), orgID.(uint), uint(commentID))
+	if errors.Is(err, repository.ErrParentInTrash) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Restore the comment's article first"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found in trash"})
+		return
+	}
+	audit.Record(c, audit.Entry{Action: "comment.restore", TargetType: "comment", TargetID: comment.ID})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Comment restored successfully",
+		"comment": comment,
+	})
+}
+
+func (h *TrashHandler) GetTrashedOrganizations(c *gin.Context) {
+	orgs, err := h.trashRepo.GetTrashedOrganizations(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch trash"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"organizations":  orgs,
+		"retention_days": h.retentionDays,
+	})
+}
+
+// RestoreOrganization brings back a deleted organization along with the
+// articles, comments and memberships that were deleted with it.
+func (h *TrashHandler) RestoreOrganization(c *gin.Context) {
+	orgID, err := strconv.ParseUint(c.Param("orgId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid organization ID"})
+		return
+	}
+
+	org, err := h.trashRepo.RestoreOrganization(c.Request.Context(), uint(orgID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Organization not found in trash"})
+		return
+	}
+	audit.Record(c, audit.Entry{Action: "organization.restore", OrganizationID: org.ID, TargetType: "organization", TargetID: org.ID})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Organization restored successfully",
+		"organization": gin.H{"id": org.ID, "name": org.Name, "slug": org.Slug},
+	})
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/audit"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/middleware"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/policy"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/repository"
+)
+
+type UsageHandler struct {
+	usageRepo repository.UsageRepository
+}
+
+func NewUsageHandler(usageRepo repository.UsageRepository) *UsageHandler {
+	return &UsageHandler{
+		usageRepo: usageRepo,
+	}
+}
+
+type SetPlanRequest struct {
+	Plan string `json:"plan" binding:"required"`
+}
+
+// GetUsage reports the organization's plan and how much of each limit it
+// uses. A null limit means unlimited.
+func (h *UsageHandler) GetUsage(c *gin.Context) {
+	if !middleware.CanInOrganization(c, policy.ActionViewUsage) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only organization admins can view usage"})
+		return
+	}
+
+	orgID, _ := c.Get(middleware.OrganizationKey)
+	plan, usage, err := h.usageRepo.GetUsage(c.Request.Context(), orgID.(uint), time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch usage"})
+		return
+	}
+
+	if plan == nil {
+		plan = &model.Plan{}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"plan": plan.Name,
+		"usage": gin.H{
+			repository.QuotaMembers:     quotaUsage(usage.Members, plan.MaxMembers),
+			repository.QuotaArticles:    quotaUsage(usage.Articles, plan.MaxArticles),
+			repository.QuotaStorage:     quotaUsage(usage.StorageBytes, plan.MaxStorageBytes),
+			repository.QuotaAPIRequests: quotaUsage(usage.APIRequests, plan.MaxAPIRequestsPerMonth),
+		},
+		"period_start": usage.PeriodStart,
+		"period_end":   usage.PeriodStart.AddDate(0, 1, 0),
+	})
+}
+
+func (h *UsageHandler) GetPlans(c *gin.Context) {
+	plans, err := h.usageRepo.GetPlans(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch plans"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"plans": plans,
+	})
+}
+
+func (h *UsageHandler) SetOrganizationPlan(c *gin.Context) {
+	orgID, err := strconv.ParseUint(c.Param("orgId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid organization ID"})
+		return
+	}
+
+	var req SetPlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	plan, err := h.usageRepo.GetPlanByName(c.Request.Context(), req.Plan)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown plan"})
+		return
+	}
+
+	if err := h.usageRepo.SetOrganizationPlan(c.Request.Context(), uint(orgID), plan.ID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Organization not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change plan"})
+		return
+	}
+	audit.Record(c, audit.Entry{Action: "organization.plan_change", OrganizationID: uint(orgID), TargetType: "organization", TargetID: uint(orgID), After: gin.H{"plan": plan.Name}})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Plan changed successfully",
+		"plan":    plan,
+	})
+}
+
+func quotaUsage(used, limit int64) gin.H {
+	if limit == 0 {
+		return gin.H{"used": used, "limit": nil}
+	}
+	return gin.H{"used": used, "limit": limit}
+}
+
+// respondQuotaExceeded answers with 402 when err says the organization's
+// plan does not allow the operation, and reports whether it did.
+func respondQuotaExceeded(c *gin.Context, err error) bool {
+	var quotaErr *repository.QuotaExceededError
+	if !errors.As(err, &quotaErr) {
+		return false
+	}
+	c.JSON(http.StatusPaymentRequired, gin.H{
+		"error": fmt.Sprintf("The organization's plan allows at most %d %s; upgrade the plan to add more", quotaErr.Limit, quotaErr.Quota),
+		"quota": quotaErr.Quota,
+		"limit": quotaErr.Limit,
+	})
+	return true
+}
@@ -1,15 +1,31 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
+	"os"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/adityadeshlahre/multi-tenant-backend-app/config"
 	"github.com/adityadeshlahre/multi-tenant-backend-app/database"
 	"github.com/adityadeshlahre/multi-tenant-backend-app/handlers"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/audit"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/domainverify"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/media"
 	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/middleware"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/orgdeletion"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/policy"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/privacy"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/settings"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/storage"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/tenantexport"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/trash"
 	"github.com/adityadeshlahre/multi-tenant-backend-app/repository"
 )
 
@@ -19,15 +35,92 @@ func main() {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
 
+	if handled, err := runCommand(db, os.Args[1:]); handled {
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	cfg := config.LoadConfig()
+	schemaPerTenant := cfg.TenancyMode == database.TenancySchema
+
+	var provisioners []repository.TenantProvisioner
+	if schemaPerTenant {
+		provisioners = append(provisioners, database.ProvisionTenantSchema)
+	}
+
 	userRepo := repository.NewUserRepository(db)
-	orgRepo := repository.NewOrgRepository(db)
-	articleRepo := repository.NewArticleRepository(db)
+	orgRepo := repository.NewOrgRepository(db, provisioners...)
+	articleRepo := repository.NewArticleRepository(db, database.TenantStorage(cfg.TenancyMode))
+	roleRepo := repository.NewRoleRepository(db)
+	policyRepo := repository.NewPolicyRepository(db)
+	collaboratorRepo := repository.NewCollaboratorRepository(db)
+	teamRepo := repository.NewTeamRepository(db)
+	domainRepo := repository.NewDomainRepository(db)
+	trashRepo := repository.NewTrashRepository(db, database.TenantStorage(cfg.TenancyMode))
+	ownershipRepo := repository.NewOwnershipRepository(db)
+	deletionRepo := repository.NewDeletionRepository(db, database.TenantStorage(cfg.TenancyMode))
+	usageRepo := repository.NewUsageRepository(db)
+	settingsRepo := repository.NewSettingsRepository(db)
+	auditRepo := repository.NewAuditRepository(db)
+	accountRepo := repository.NewAccountRepository(db, database.TenantStorage(cfg.TenancyMode))
+	exportRepo := repository.NewExportRepository(db, database.TenantStorage(cfg.TenancyMode), provisioners...)
+	attachmentRepo := repository.NewAttachmentRepository(db)
+	tagRepo := repository.NewTagRepository(db)
+	categoryRepo := repository.NewCategoryRepository(db)
 
-	authHandler := handlers.NewAuthHandler(userRepo, orgRepo)
-	orgHandler := handlers.NewOrganizationHandler(orgRepo)
-	articleHandler := handlers.NewArticleHandler(articleRepo)
+	store, err := storage.FromConfig(cfg)
+	if err != nil {
+		log.Fatalf("Failed to open attachment storage: %v", err)
+	}
+
+	defaultPolicy := policy.Default()
+	if policyFile := cfg.PolicyFile; policyFile != "" {
+		defaultPolicy, err = policy.LoadFile(policyFile)
+		if err != nil {
+			log.Fatalf("Failed to load policy file: %v", err)
+		}
+	}
+	policies := policy.NewStore(defaultPolicy, policyRepo)
+	orgSettings := settings.NewStore(settingsRepo)
+
+	authHandler := handlers.NewAuthHandler(userRepo, orgRepo, roleRepo)
+	deletions := orgdeletion.NewWorker(deletionRepo, policies)
+	go deletions.Run(context.Background())
+	exports := tenantexport.NewWorker(exportRepo, store, cfg.ExportDir)
+	go exports.Run(context.Background())
+	images := media.NewProcessor(attachmentRepo, store)
+	go images.Run(context.Background())
+
+	orgHandler := handlers.NewOrganizationHandler(orgRepo, deletionRepo, deletions)
+	articleHandler := handlers.NewArticleHandler(articleRepo, collaboratorRepo, teamRepo, categoryRepo)
+	roleHandler := handlers.NewRoleHandler(roleRepo, policies)
+	collaboratorHandler := handlers.NewCollaboratorHandler(collaboratorRepo, roleRepo, teamRepo)
+	teamHandler := handlers.NewTeamHandler(teamRepo, roleRepo)
+	tagHandler := handlers.NewTagHandler(tagRepo)
+	categoryHandler := handlers.NewCategoryHandler(categoryRepo)
+	feedHandler := handlers.NewFeedHandler(articleRepo, tagRepo, userRepo, roleRepo)
+	publicHandler := handlers.NewPublicHandler(articleRepo, tagRepo, userRepo, roleRepo)
+	domainHandler := handlers.NewDomainHandler(domainRepo, domainverify.NewVerifier(net.DefaultResolver), cfg.TenantBaseDomain)
+	ownershipHandler := handlers.NewOwnershipHandler(ownershipRepo)
+	memberHandler := handlers.NewMemberHandler(roleRepo)
+	usageHandler := handlers.NewUsageHandler(usageRepo)
+	settingsHandler := handlers.NewSettingsHandler(settingsRepo, orgSettings)
+	auditHandler := handlers.NewAuditHandler(auditRepo)
+	accountHandler := handlers.NewAccountHandler(accountRepo, time.Duration(cfg.AccountDeletionGraceDays)*24*time.Hour)
+	exportHandler := handlers.NewExportHandler(exportRepo, userRepo, usageRepo, exports, store)
+	trashHandler := handlers.NewTrashHandler(trashRepo, cfg.TrashRetentionDays)
+	attachmentHandler := handlers.NewAttachmentHandler(attachmentRepo, store, media.NewSigner(cfg.JWTSecret, 15*time.Minute), images, cfg.MaxAttachmentSize)
+	permissionHandler := handlers.NewPermissionHandler(articleRepo, policyRepo, roleRepo, collaboratorRepo, teamRepo, policies)
+
+	go trash.NewPurger(trashRepo, time.Duration(cfg.TrashRetentionDays)*24*time.Hour).Run(context.Background())
+	go privacy.NewEraser(accountRepo).Run(context.Background())
+	go media.NewJanitor(attachmentRepo, store).Run(context.Background())
 
 	router := gin.Default()
+	router.Use(middleware.RequestID())
+	router.Use(audit.Context(auditRepo))
 
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"status": "healthy"})
@@ -41,27 +134,104 @@ func main() {
 			auth.POST("/login", authHandler.Login)
 			auth.GET("/profile", middleware.AuthMiddleware(userRepo), authHandler.GetProfile)
 			auth.PUT("/profile", middleware.AuthMiddleware(userRepo), authHandler.UpdateProfile)
+			auth.GET("/profile/export", middleware.AuthMiddleware(userRepo), accountHandler.ExportProfile)
+			auth.GET("/profile/deletion", middleware.AuthMiddleware(userRepo), accountHandler.GetDeletion)
+			auth.POST("/profile/deletion", middleware.AuthMiddleware(userRepo), accountHandler.RequestDeletion)
+			auth.DELETE("/profile/deletion", middleware.AuthMiddleware(userRepo), accountHandler.CancelDeletion)
 			auth.POST("/join-org/:orgId", middleware.AuthMiddleware(userRepo), authHandler.JoinOrganization)
 		}
 
 		orgs := api.Group("/organizations")
 		{
-			orgs.POST("/", orgHandler.CreateOrganization)
+			orgs.POST("/", middleware.AuthMiddleware(userRepo), orgHandler.CreateOrganization)
 			orgs.GET("/", orgHandler.GetAllOrganizations)
 
 			orgRoutes := orgs.Group("/:orgId")
 			orgRoutes.Use(middleware.AuthMiddleware(userRepo))
-			orgRoutes.Use(middleware.OrganizationContext(orgRepo))
+			orgRoutes.Use(middleware.OrganizationContext(orgRepo, roleRepo, middleware.TenantResolution{
+				Strategies: cfg.TenantStrategies,
+				BaseDomain: cfg.TenantBaseDomain,
+			}))
+			orgRoutes.Use(middleware.RequireMembership(policies, map[string]string{
+				orgRoutes.BasePath():               policy.ResourceOrganization,
+				orgRoutes.BasePath() + "/articles": policy.ResourceArticle,
+			}))
+			orgRoutes.Use(middleware.APIQuota(usageRepo))
+			orgRoutes.Use(middleware.TenantTransaction(db, cfg.TenantRLS, schemaPerTenant))
+			orgRoutes.Use(middleware.PolicyContext(policies))
+			orgRoutes.Use(middleware.SettingsContext(orgSettings))
 			{
 				orgRoutes.GET("/", orgHandler.GetOrganization)
 				orgRoutes.PUT("/", orgHandler.UpdateOrganization)
 				orgRoutes.DELETE("/", orgHandler.DeleteOrganization)
+				orgRoutes.POST("/deletion", orgHandler.RequestDeletion)
+
+				orgRoutes.GET("/owner", ownershipHandler.GetOwner)
+				orgRoutes.GET("/ownership-transfers", ownershipHandler.GetPendingTransfers)
+				orgRoutes.POST("/ownership-transfers", ownershipHandler.TransferOwnership)
+				orgRoutes.DELETE("/ownership-transfers/:transferId", ownershipHandler.CancelTransfer)
+
+				orgRoutes.GET("/members", memberHandler.GetMembers)
+				orgRoutes.POST("/members/leave", memberHandler.LeaveOrganization)
+				orgRoutes.DELETE("/members/:userId", memberHandler.RemoveMember)
+				orgRoutes.PUT("/members/:userId/role", roleHandler.UpdateMemberRole)
+				orgRoutes.GET("/role-changes", roleHandler.GetOrganizationRoleChanges)
+				orgRoutes.GET("/roles", roleHandler.GetOrganizationRoles)
+				orgRoutes.POST("/roles", roleHandler.CreateOrganizationRole)
+				orgRoutes.PUT("/roles/:roleName", roleHandler.UpdateOrganizationRole)
+				orgRoutes.DELETE("/roles/:roleName", roleHandler.DeleteOrganizationRole)
+
+				orgRoutes.GET("/permissions", permissionHandler.GetPermissions)
+				orgRoutes.GET("/policy", permissionHandler.GetPolicy)
+				orgRoutes.PUT("/policy", permissionHandler.UpdatePolicy)
+
+				orgRoutes.GET("/settings", settingsHandler.GetSettings)
+				orgRoutes.PUT("/settings", settingsHandler.UpdateSettings)
+
+				domains := orgRoutes.Group("/domains", middleware.RequireFeature(settings.FeatureCustomDomains))
+				domains.GET("", domainHandler.GetDomains)
+				domains.POST("", domainHandler.AddDomain)
+				domains.POST("/:domainId/verify", domainHandler.VerifyDomain)
+				domains.DELETE("/:domainId", domainHandler.DeleteDomain)
+
+				orgRoutes.GET("/usage", usageHandler.GetUsage)
+				orgRoutes.GET("/audit", auditHandler.GetAuditLog)
+
+				orgRoutes.GET("/exports", exportHandler.GetExports)
+				orgRoutes.POST("/exports", exportHandler.RequestExport)
+				orgRoutes.GET("/exports/:exportId", exportHandler.GetExport)
+				orgRoutes.GET("/exports/:exportId/download", exportHandler.DownloadExport)
+
+				teams := orgRoutes.Group("/teams", middleware.RequireFeature(settings.FeatureTeams))
+				teams.GET("", teamHandler.GetTeams)
+				teams.POST("", teamHandler.CreateTeam)
+				teams.GET("/:teamId", teamHandler.GetTeam)
+				teams.PUT("/:teamId", teamHandler.UpdateTeam)
+				teams.DELETE("/:teamId", teamHandler.DeleteTeam)
+				teams.GET("/:teamId/members", teamHandler.GetTeamMembers)
+				teams.POST("/:teamId/members", teamHandler.AddTeamMember)
+				teams.DELETE("/:teamId/members/:userId", teamHandler.RemoveTeamMember)
+
+				orgRoutes.GET("/tags", tagHandler.GetTags)
+				orgRoutes.POST("/tags", tagHandler.CreateTag)
+				orgRoutes.PUT("/tags/:tagId", tagHandler.UpdateTag)
+				orgRoutes.DELETE("/tags/:tagId", tagHandler.DeleteTag)
+
+				orgRoutes.GET("/categories", categoryHandler.GetCategories)
+				orgRoutes.POST("/categories", categoryHandler.CreateCategory)
+				orgRoutes.GET("/categories/:categoryId", categoryHandler.GetCategory)
+				orgRoutes.PUT("/categories/:categoryId", categoryHandler.UpdateCategory)
+				orgRoutes.DELETE("/categories/:categoryId", categoryHandler.DeleteCategory)
+
+				orgRoutes.GET("/trash", trashHandler.GetTrash)
+				orgRoutes.POST("/trash/articles/:articleId/restore", trashHandler.RestoreArticle)
+				orgRoutes.POST("/trash/comments/:commentId/restore", trashHandler.RestoreComment)
 
 				orgRoutes.POST("/articles", articleHandler.CreateArticle)
 				orgRoutes.GET("/articles", articleHandler.GetAllArticles)
 
 				articleRoutes := orgRoutes.Group("/articles/:id")
-				articleRoutes.Use(middleware.ArticleContext(articleRepo))
+				articleRoutes.Use(middleware.ArticleContext(articleRepo, collaboratorRepo, teamRepo))
 				{
 					articleRoutes.GET("/", articleHandler.GetArticle)
 					articleRoutes.PUT("/", articleHandler.UpdateArticle)
@@ -71,15 +241,67 @@ func main() {
 					articleRoutes.GET("/comments", articleHandler.GetComments)
 					articleRoutes.PUT("/comments/:commentId", articleHandler.UpdateComment)
 					articleRoutes.DELETE("/comments/:commentId", articleHandler.DeleteComment)
+
+					articleRoutes.GET("/attachments", attachmentHandler.GetAttachments)
+					articleRoutes.POST("/attachments", attachmentHandler.UploadAttachment)
+					articleRoutes.GET("/attachments/:attachmentId", attachmentHandler.GetAttachment)
+					articleRoutes.DELETE("/attachments/:attachmentId", attachmentHandler.DeleteAttachment)
+
+					collaborators := articleRoutes.Group("/collaborators", middleware.RequireFeature(settings.FeatureCollaborators))
+					collaborators.GET("", collaboratorHandler.GetCollaborators)
+					collaborators.POST("", collaboratorHandler.AddCollaborator)
+					collaborators.DELETE("/:collaboratorId", collaboratorHandler.RemoveCollaborator)
 				}
 			}
 		}
 
+		public := api.Group("/public/:orgId")
+		public.Use(middleware.OrganizationContext(orgRepo, roleRepo, middleware.TenantResolution{
+			Strategies: []string{middleware.TenantFromPath},
+		}))
+		public.Use(middleware.TenantTransaction(db, cfg.TenantRLS, schemaPerTenant))
+		public.Use(middleware.SettingsContext(orgSettings))
+		{
+			public.GET("/articles", publicHandler.GetArticles)
+			public.GET("/articles/:slug", publicHandler.GetArticle)
+			public.GET("/tags/:tag/articles", publicHandler.GetTagArticles)
+			public.GET("/authors/:authorId/articles", publicHandler.GetAuthorArticles)
+
+			public.GET("/feeds", feedHandler.GetFeeds)
+			public.GET("/feeds/:format", feedHandler.GetOrganizationFeed)
+			public.GET("/tags/:tag/feeds/:format", feedHandler.GetTagFeed)
+			public.GET("/authors/:authorId/feeds/:format", feedHandler.GetAuthorFeed)
+		}
+
+		api.GET("/attachments/:attachmentId/download", attachmentHandler.DownloadAttachment)
+		api.GET("/organization-deletions/:deletionId", middleware.AuthMiddleware(userRepo), orgHandler.GetDeletion)
+
+		transfers := api.Group("/ownership-transfers")
+		transfers.Use(middleware.AuthMiddleware(userRepo))
+		{
+			transfers.GET("/", ownershipHandler.GetIncomingTransfers)
+			transfers.POST("/:transferId/accept", ownershipHandler.AcceptTransfer)
+			transfers.POST("/:transferId/decline", ownershipHandler.DeclineTransfer)
+		}
+
 		articles := api.Group("/articles")
 		{
-			articles.GET("/published", articleHandler.GetPublishedArticles)
 			articles.GET("/my", middleware.AuthMiddleware(userRepo), articleHandler.GetMyArticles)
 		}
+
+		admin := api.Group("/admin")
+		admin.Use(middleware.AuthMiddleware(userRepo))
+		admin.Use(middleware.RequireGlobalRole(model.RoleSuperAdmin))
+		{
+			admin.PUT("/users/:userId/role", roleHandler.UpdateGlobalRole)
+			admin.GET("/role-changes", roleHandler.GetGlobalRoleChanges)
+			admin.GET("/trash/organizations", trashHandler.GetTrashedOrganizations)
+			admin.POST("/organizations/:orgId/restore", trashHandler.RestoreOrganization)
+			admin.PUT("/organizations/:orgId/owner", ownershipHandler.AssignOwner)
+			admin.GET("/plans", usageHandler.GetPlans)
+			admin.PUT("/organizations/:orgId/plan", usageHandler.SetOrganizationPlan)
+			admin.POST("/organizations/import", exportHandler.ImportOrganization)
+		}
 	}
 
 	fmt.Println("🚀 Server starting on http://localhost:8080 ...")
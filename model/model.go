@@ -1,14 +1,30 @@
 package model
 
 import (
+	"time"
+
 	"gorm.io/gorm"
 )
 
+const (
+	RoleSuperAdmin = "super_admin"
+	// RoleOwner is the membership role of the organization's owner.
+	RoleOwner  = "owner"
+	RoleAdmin  = "admin"
+	RoleEditor = "editor"
+	RoleMember = "member"
+)
+
 type Organization struct {
 	gorm.Model
-	Name     string    `json:"name" gorm:"uniqueIndex"`
-	Users    []User    `gorm:"many2many:user_organizations;"`
-	Articles []Article `gorm:"foreignKey:OrganizationID"`
+	Name       string    `json:"name" gorm:"uniqueIndex"`
+	Slug       string    `json:"slug" gorm:"uniqueIndex:idx_organizations_slug,where:slug <> ''"`
+	Subdomain  *string   `json:"subdomain" gorm:"uniqueIndex"`
+	SchemaName string    `json:"-"`
+	PlanID     *uint     `json:"plan_id" gorm:"index"`
+	Plan       *Plan     `json:"plan,omitempty"`
+	Users      []User    `gorm:"many2many:user_organizations;"`
+	Articles   []Article `gorm:"foreignKey:OrganizationID"`
 }
 
 type User struct {
@@ -22,18 +38,55 @@ type User struct {
 	Comments      []Comment      `gorm:"foreignKey:AuthorID"`
 }
 
+// Formats an article's content can be written in. ContentHTML is rendered
+// from the content whenever the article is saved.
+const (
+	ContentFormatMarkdown = "markdown"
+	ContentFormatHTML     = "html"
+	ContentFormatPlain    = "plain"
+)
+
 type Article struct {
 	gorm.Model
 	Title          string       `json:"title"`
+	Slug           string       `json:"slug" gorm:"uniqueIndex:idx_organization_article_slug,where:slug <> ''"`
 	Content        string       `json:"content"`
+	ContentFormat  string       `json:"content_format" gorm:"default:'markdown'"`
+	ContentHTML    string       `json:"content_html"`
 	Status         string       `json:"status" gorm:"default:'draft'"`
-	OrganizationID uint         `json:"organization_id"`
+	PublishedAt    *time.Time   `json:"published_at" gorm:"index"`
+	Visibility     string       `json:"visibility" gorm:"default:'public';index"`
+	OrganizationID uint         `json:"organization_id" gorm:"uniqueIndex:idx_organization_article_slug"`
 	Organization   Organization `gorm:"foreignKey:OrganizationID"`
 	UserID         uint         `json:"user_id"`
 	User           User         `gorm:"foreignKey:UserID"`
+	TeamID         *uint        `json:"team_id" gorm:"index"`
+	CategoryID     *uint        `json:"category_id" gorm:"index"`
+	Category       *Category    `json:"category,omitempty" gorm:"foreignKey:CategoryID"`
+	Tags           []Tag        `json:"tags,omitempty" gorm:"many2many:article_tags;"`
 	Comments       []Comment    `gorm:"foreignKey:ArticleID"`
 }
 
+// Tag labels articles across categories. Tags are looked up by their slug,
+// so names differing only in case or punctuation are the same tag.
+type Tag struct {
+	gorm.Model
+	OrganizationID uint   `json:"organization_id" gorm:"uniqueIndex:idx_organization_tag_slug"`
+	Name           string `json:"name"`
+	Slug           string `json:"slug" gorm:"uniqueIndex:idx_organization_tag_slug"`
+}
+
+// Category files articles in a tree of topics. ParentID is nil for
+// top-level categories.
+type Category struct {
+	gorm.Model
+	OrganizationID uint   `json:"organization_id" gorm:"uniqueIndex:idx_organization_category_slug"`
+	ParentID       *uint  `json:"parent_id" gorm:"index"`
+	Name           string `json:"name"`
+	Slug           string `json:"slug" gorm:"uniqueIndex:idx_organization_category_slug"`
+	Description    string `json:"description"`
+}
+
 type Comment struct {
 	gorm.Model
 	Content   string  `json:"content"`
@@ -42,3 +95,297 @@ type Comment struct {
 	AuthorID  uint    `json:"author_id"`
 	Author    User    `gorm:"foreignKey:AuthorID"`
 }
+
+type UserOrganization struct {
+	UserID         uint           `json:"user_id" gorm:"primaryKey"`
+	OrganizationID uint           `json:"organization_id" gorm:"primaryKey"`
+	Role           string         `json:"role" gorm:"default:'member'"`
+	CreatedAt      time.Time      `json:"created_at"`
+	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+type RoleChange struct {
+	gorm.Model
+	ActorID        uint   `json:"actor_id"`
+	UserID         uint   `json:"user_id"`
+	OrganizationID *uint  `json:"organization_id"`
+	OldRole        string `json:"old_role"`
+	NewRole        string `json:"new_role"`
+}
+
+type PolicyRule struct {
+	gorm.Model
+	OrganizationID uint     `json:"organization_id" gorm:"index"`
+	Role           string   `json:"role"`
+	Resource       string   `json:"resource"`
+	Actions        []string `json:"actions" gorm:"serializer:json"`
+	Conditions     []string `json:"conditions" gorm:"serializer:json"`
+}
+
+type OrganizationRole struct {
+	gorm.Model
+	OrganizationID uint   `json:"organization_id" gorm:"uniqueIndex:idx_organization_role_name"`
+	Name           string `json:"name" gorm:"uniqueIndex:idx_organization_role_name"`
+	Description    string `json:"description"`
+}
+
+// ArticleCollaborator grants a permission on one article to a single user, to
+// every member holding an organization role, or to every member of a team.
+type ArticleCollaborator struct {
+	gorm.Model
+	ArticleID  uint   `json:"article_id" gorm:"index"`
+	UserID     *uint  `json:"user_id,omitempty"`
+	Role       string `json:"role,omitempty"`
+	TeamID     *uint  `json:"team_id,omitempty"`
+	Permission string `json:"permission"`
+	GrantedBy  uint   `json:"granted_by"`
+}
+
+type Team struct {
+	gorm.Model
+	OrganizationID uint   `json:"organization_id" gorm:"uniqueIndex:idx_organization_team_name"`
+	Name           string `json:"name" gorm:"uniqueIndex:idx_organization_team_name"`
+	Description    string `json:"description"`
+	Members        []User `json:"members,omitempty" gorm:"many2many:team_members;"`
+}
+
+// OrganizationDomain maps a custom host name to an organization. Requests for
+// the host only resolve to the organization once VerifiedAt is set. Several
+// organizations may claim a host, but only one can verify it.
+type OrganizationDomain struct {
+	gorm.Model
+	OrganizationID    uint       `json:"organization_id" gorm:"index;uniqueIndex:idx_organization_domain_host"`
+	Host              string     `json:"host" gorm:"uniqueIndex:idx_organization_domain_host;uniqueIndex:idx_verified_domain_host,where:verified_at IS NOT NULL"`
+	VerificationToken string     `json:"verification_token"`
+	VerifiedAt        *time.Time `json:"verified_at"`
+}
+
+const (
+	SlugResourceOrganization = "organization"
+	SlugResourceArticle      = "article"
+)
+
+// SlugRedirect remembers a slug a resource used to have, so links using it
+// keep working after a rename. OrganizationID scopes article slugs and is 0
+// for organization slugs.
+type SlugRedirect struct {
+	ID             uint      `json:"id" gorm:"primarykey"`
+	Resource       string    `json:"resource" gorm:"uniqueIndex:idx_slug_redirect"`
+	OrganizationID uint      `json:"organization_id" gorm:"uniqueIndex:idx_slug_redirect"`
+	Slug           string    `json:"slug" gorm:"uniqueIndex:idx_slug_redirect"`
+	TargetID       uint      `json:"target_id"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+const (
+	DeletionPendingConfirmation = "pending_confirmation"
+	DeletionQueued              = "queued"
+	DeletionRunning             = "running"
+	DeletionCompleted           = "completed"
+	DeletionFailed              = "failed"
+)
+
+// OrganizationDeletion tracks a request to delete an organization from its
+// confirmation through the background job that carries it out.
+type OrganizationDeletion struct {
+	gorm.Model
+	OrganizationID uint       `json:"organization_id" gorm:"index"`
+	RequestedBy    uint       `json:"requested_by"`
+	TokenHash      string     `json:"-"`
+	ExpiresAt      time.Time  `json:"expires_at"`
+	Status         string     `json:"status"`
+	Error          string     `json:"error,omitempty"`
+	StartedAt      *time.Time `json:"started_at"`
+	CompletedAt    *time.Time `json:"completed_at"`
+}
+
+const (
+	TransferPending   = "pending"
+	TransferAccepted  = "accepted"
+	TransferDeclined  = "declined"
+	TransferCancelled = "cancelled"
+)
+
+// OwnershipTransfer offers ownership of an organization to another member.
+// Ownership only changes hands once that member accepts.
+type OwnershipTransfer struct {
+	gorm.Model
+	OrganizationID uint       `json:"organization_id" gorm:"index"`
+	FromUserID     uint       `json:"from_user_id"`
+	ToUserID       uint       `json:"to_user_id" gorm:"index"`
+	Status         string     `json:"status"`
+	RespondedAt    *time.Time `json:"responded_at"`
+}
+
+const (
+	PlanFree       = "free"
+	PlanPro        = "pro"
+	PlanEnterprise = "enterprise"
+)
+
+// Plan is a pricing tier and the limits that come with it. A limit of 0
+// means unlimited. New organizations get the plan marked IsDefault.
+type Plan struct {
+	gorm.Model
+	Name                   string `json:"name" gorm:"uniqueIndex"`
+	IsDefault              bool   `json:"is_default"`
+	MaxMembers             int64  `json:"max_members"`
+	MaxArticles            int64  `json:"max_articles"`
+	MaxStorageBytes        int64  `json:"max_storage_bytes"`
+	MaxAPIRequestsPerMonth int64  `json:"max_api_requests_per_month"`
+}
+
+// OrganizationUsage holds the counters plan limits are enforced against.
+// APIRequests counts the requests made since PeriodStart, the start of a
+// calendar month in UTC.
+type OrganizationUsage struct {
+	OrganizationID uint      `json:"organization_id" gorm:"primaryKey;autoIncrement:false"`
+	Members        int64     `json:"members"`
+	Articles       int64     `json:"articles"`
+	StorageBytes   int64     `json:"storage_bytes"`
+	APIRequests    int64     `json:"api_requests"`
+	PeriodStart    time.Time `json:"period_start"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// Article visibility levels, which decide who can read an article once it
+// is published. Public articles are listed in the organization's public
+// blog and feeds, organization articles are only readable by its members,
+// and private articles are unlisted: they appear in no listing but anyone
+// with their link can read them. Organizations pick the level new articles
+// get by default in their settings.
+const (
+	VisibilityPublic       = "public"
+	VisibilityOrganization = "organization"
+	VisibilityPrivate      = "private"
+)
+
+// What happens to the articles of a member who deletes their account. They
+// are either handed to the organization's owner or deleted with the account.
+const (
+	DeletedAuthorReassign = "reassign"
+	DeletedAuthorDelete   = "delete"
+)
+
+// OrganizationSettings is an organization's stored configuration. Empty
+// fields and missing feature flags fall back to the built-in defaults.
+type OrganizationSettings struct {
+	OrganizationID           uint            `json:"organization_id" gorm:"primaryKey;autoIncrement:false"`
+	CommentPolicy            string          `json:"comment_policy"`
+	DefaultArticleVisibility string          `json:"default_article_visibility"`
+	DeletedAuthorArticles    string          `json:"deleted_author_articles"`
+	Locale                   string          `json:"locale"`
+	Timezone                 string          `json:"timezone"`
+	LogoURL                  string          `json:"logo_url"`
+	PrimaryColor             string          `json:"primary_color"`
+	Features                 map[string]bool `json:"features" gorm:"serializer:json"`
+	UpdatedAt                time.Time       `json:"updated_at"`
+}
+
+// AuditLog records one change made through the API: who made it, in which
+// organization, to what, and where the request came from. Rows are only
+// ever inserted.
+type AuditLog struct {
+	ID             uint                   `json:"id" gorm:"primarykey"`
+	CreatedAt      time.Time              `json:"created_at" gorm:"index"`
+	OrganizationID *uint                  `json:"organization_id" gorm:"index"`
+	ActorID        *uint                  `json:"actor_id" gorm:"index"`
+	Action         string                 `json:"action" gorm:"index"`
+	TargetType     string                 `json:"target_type"`
+	TargetID       uint                   `json:"target_id"`
+	Changes        map[string]AuditChange `json:"changes,omitempty" gorm:"serializer:json"`
+	IP             string                 `json:"ip"`
+	RequestID      string                 `json:"request_id" gorm:"index"`
+}
+
+// AuditChange is the value of one field before and after a change. From is
+// nil for creations and To is nil for deletions.
+type AuditChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+const (
+	ExportQueued    = "queued"
+	ExportRunning   = "running"
+	ExportCompleted = "completed"
+	ExportFailed    = "failed"
+)
+
+// OrganizationExport tracks a background job writing an organization's data
+// to an archive that can be downloaded once it has completed.
+type OrganizationExport struct {
+	gorm.Model
+	OrganizationID uint       `json:"organization_id" gorm:"index"`
+	RequestedBy    uint       `json:"requested_by"`
+	Status         string     `json:"status"`
+	Error          string     `json:"error,omitempty"`
+	FileName       string     `json:"-"`
+	Size           int64      `json:"size"`
+	StartedAt      *time.Time `json:"started_at"`
+	CompletedAt    *time.Time `json:"completed_at"`
+}
+
+const (
+	AccountDeletionPending   = "pending"
+	AccountDeletionCancelled = "cancelled"
+	AccountDeletionCompleted = "completed"
+	AccountDeletionFailed    = "failed"
+)
+
+// AccountDeletion is a user's request to have their account erased. It is
+// carried out once ScheduledFor has passed, unless cancelled before.
+type AccountDeletion struct {
+	gorm.Model
+	UserID       uint       `json:"user_id" gorm:"index"`
+	Status       string     `json:"status"`
+	ScheduledFor time.Time  `json:"scheduled_for" gorm:"index"`
+	Error        string     `json:"error,omitempty"`
+	CompletedAt  *time.Time `json:"completed_at"`
+}
+
+const (
+	ProcessingPending   = "pending"
+	ProcessingRunning   = "processing"
+	ProcessingCompleted = "completed"
+	ProcessingFailed    = "failed"
+)
+
+// Attachment is a file uploaded to an article. The file itself lives in the
+// configured storage under StorageKey. Deleting an attachment only marks the
+// row; it is removed once the file is gone from storage as well.
+//
+// Images are processed in the background: ProcessingStatus is empty for
+// files that are not, and once it is completed the image's dimensions,
+// blurhash and resized variants are filled in.
+type Attachment struct {
+	gorm.Model
+	OrganizationID   uint                `json:"organization_id" gorm:"index"`
+	ArticleID        uint                `json:"article_id" gorm:"index"`
+	UploadedBy       uint                `json:"uploaded_by"`
+	FileName         string              `json:"file_name"`
+	ContentType      string              `json:"content_type"`
+	Size             int64               `json:"size"`
+	Checksum         string              `json:"checksum"`
+	StorageKey       string              `json:"-" gorm:"uniqueIndex"`
+	ProcessingStatus string              `json:"processing_status,omitempty" gorm:"index"`
+	ProcessingError  string              `json:"processing_error,omitempty"`
+	Width            int                 `json:"width,omitempty"`
+	Height           int                 `json:"height,omitempty"`
+	Blurhash         string              `json:"blurhash,omitempty"`
+	Variants         []AttachmentVariant `json:"variants,omitempty"`
+}
+
+// AttachmentVariant is a resized copy of an image attachment, generated
+// from it and removed with it.
+type AttachmentVariant struct {
+	ID           uint      `json:"-" gorm:"primaryKey"`
+	AttachmentID uint      `json:"-" gorm:"index"`
+	Name         string    `json:"name"`
+	ContentType  string    `json:"content_type"`
+	Width        int       `json:"width"`
+	Height       int       `json:"height"`
+	Size         int64     `json:"size"`
+	StorageKey   string    `json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+}
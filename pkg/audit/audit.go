@@ -0,0 +1,150 @@
+package audit
+
+import (
+	"encoding/json"
+	"log"
+	"reflect"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/middleware"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/repository"
+)
+
+const recorderKey = "auditRecorder"
+
+// Entry describes a change to record. OrganizationID and ActorID default to
+// the organization and the user of the request.
+type Entry struct {
+	Action         string
+	OrganizationID uint
+	ActorID        uint
+	TargetType     string
+	TargetID       uint
+	// Before and After are the target's state around the change: Before is
+	// nil for creations and After is nil for deletions. Leave both out for
+	// users, whose records hold personal data that must not end up in the
+	// append-only log.
+	Before interface{}
+	After  interface{}
+}
+
+// Context makes repo available to Record for the rest of the request.
+func Context(repo repository.AuditRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(recorderKey, repo)
+		c.Next()
+	}
+}
+
+// Record appends e to the audit log together with the caller's IP and the
+// request ID. Inside a tenant transaction, i.e. with row-level security or
+// schema-per-tenant enabled, the entry commits or rolls back with the change
+// it describes, and a failure to record it rolls the change back. Otherwise
+// the change is already committed when Record runs, and a failure is only
+// logged and attached to the request.
+func Record(c *gin.Context, e Entry) {
+	value, exists := c.Get(recorderKey)
+	if !exists {
+		log.Printf("Audit recorder not found in context, dropping %s", e.Action)
+		return
+	}
+
+	entry := &model.AuditLog{
+		Action:     e.Action,
+		TargetType: e.TargetType,
+		TargetID:   e.TargetID,
+		Changes:    Diff(e.Before, e.After),
+		IP:         c.ClientIP(),
+		RequestID:  middleware.GetRequestID(c),
+	}
+	if e.OrganizationID != 0 {
+		entry.OrganizationID = &e.OrganizationID
+	} else if orgID, ok := c.Get(middleware.OrganizationKey); ok {
+		id := orgID.(uint)
+		entry.OrganizationID = &id
+	}
+	if e.ActorID != 0 {
+		entry.ActorID = &e.ActorID
+	} else if userID, ok := c.Get("userID"); ok {
+		id := userID.(uint)
+		entry.ActorID = &id
+	}
+
+	if err := value.(repository.AuditRepository).RecordAudit(c.Request.Context(), entry); err != nil {
+		_ = c.Error(err)
+	}
+}
+
+// Diff compares the JSON form of before and after field by field, so fields
+// hidden from JSON such as password hashes never reach the log. Preloaded
+// associations have audit entries of their own and are skipped, as is
+// updated_at, which changes with everything.
+func Diff(before, after interface{}) map[string]model.AuditChange {
+	from, to := fields(before), fields(after)
+	changes := make(map[string]model.AuditChange)
+	for name, value := range to {
+		if old := from[name]; !reflect.DeepEqual(old, value) {
+			changes[name] = model.AuditChange{From: old, To: value}
+		}
+	}
+	for name, old := range from {
+		if _, ok := to[name]; !ok && old != nil {
+			changes[name] = model.AuditChange{From: old}
+		}
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	return changes
+}
+
+func fields(v interface{}) map[string]interface{} {
+	if v == nil || (reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil()) {
+		return nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		log.Printf("Error encoding audit state: %v", err)
+		return nil
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil
+	}
+
+	object, ok := decoded.(map[string]interface{})
+	if !ok {
+		return map[string]interface{}{"value": decoded}
+	}
+	for name, value := range object {
+		if name == "updated_at" || name == "UpdatedAt" || isNested(value) {
+			delete(object, name)
+		}
+	}
+	return object
+}
+
+// isNested reports whether value is a preloaded association: a record, or
+// a list of records, with an ID of its own.
+func isNested(value interface{}) bool {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return isRecord(v)
+	case []interface{}:
+		for _, item := range v {
+			if object, ok := item.(map[string]interface{}); ok && isRecord(object) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isRecord(object map[string]interface{}) bool {
+	_, hasID := object["ID"]
+	_, hasLowerID := object["id"]
+	return hasID || hasLowerID
+}
@@ -0,0 +1,61 @@
+package domainverify
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+)
+
+// RecordPrefix is the label under which the verification TXT record must be
+// published, e.g. _blog-verification.blog.acme.com.
+const RecordPrefix = "_blog-verification"
+
+// Resolver looks up DNS TXT records. *net.Resolver satisfies it; tests and
+// air-gapped deployments can plug in their own.
+type Resolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+type Verifier struct {
+	resolver Resolver
+}
+
+func NewVerifier(resolver Resolver) *Verifier {
+	return &Verifier{resolver: resolver}
+}
+
+// NewToken returns a random token for a domain to publish.
+func NewToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// RecordName is the DNS name the TXT record for host must be published at.
+func RecordName(host string) string {
+	return RecordPrefix + "." + host
+}
+
+// RecordValue is the TXT record content that proves control of a domain.
+func RecordValue(token string) string {
+	return "blog-verification=" + token
+}
+
+// Verify reports whether host publishes the TXT record for token.
+func (v *Verifier) Verify(ctx context.Context, host, token string) (bool, error) {
+	records, err := v.resolver.LookupTXT(ctx, RecordName(host))
+	if err != nil {
+		return false, err
+	}
+
+	expected := RecordValue(token)
+	for _, record := range records {
+		if strings.TrimSpace(record) == expected {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,79 @@
+package domainverify
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubResolver answers TXT lookups from a fixed table.
+type stubResolver struct {
+	records map[string][]string
+	err     error
+	lookups []string
+}
+
+func (r *stubResolver) LookupTXT(_ context.Context, name string) ([]string, error) {
+	r.lookups = append(r.lookups, name)
+	if r.err != nil {
+		return nil, r.err
+	}
+	return r.records[name], nil
+}
+
+func TestRecord(t *testing.T) {
+	assert.Equal(t, "_blog-verification.blog.acme.com", RecordName("blog.acme.com"))
+	assert.Equal(t, "blog-verification=abc", RecordValue("abc"))
+}
+
+func TestNewToken(t *testing.T) {
+	first, err := NewToken()
+	require.NoError(t, err)
+	second, err := NewToken()
+	require.NoError(t, err)
+
+	assert.Len(t, first, 32)
+	assert.NotEqual(t, first, second)
+}
+
+func TestVerify(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Matching Record", func(t *testing.T) {
+		resolver := &stubResolver{records: map[string][]string{
+			"_blog-verification.blog.acme.com": {"v=spf1 -all", "  blog-verification=abc  "},
+		}}
+		verified, err := NewVerifier(resolver).Verify(ctx, "blog.acme.com", "abc")
+		require.NoError(t, err)
+		assert.True(t, verified)
+		assert.Equal(t, []string{"_blog-verification.blog.acme.com"}, resolver.lookups)
+	})
+
+	t.Run("Other Token", func(t *testing.T) {
+		resolver := &stubResolver{records: map[string][]string{
+			"_blog-verification.blog.acme.com": {"blog-verification=other"},
+		}}
+		verified, err := NewVerifier(resolver).Verify(ctx, "blog.acme.com", "abc")
+		require.NoError(t, err)
+		assert.False(t, verified)
+	})
+
+	t.Run("Record On The Host Itself Does Not Count", func(t *testing.T) {
+		resolver := &stubResolver{records: map[string][]string{
+			"blog.acme.com": {"blog-verification=abc"},
+		}}
+		verified, err := NewVerifier(resolver).Verify(ctx, "blog.acme.com", "abc")
+		require.NoError(t, err)
+		assert.False(t, verified)
+	})
+
+	t.Run("Lookup Failure", func(t *testing.T) {
+		resolver := &stubResolver{err: errors.New("no such host")}
+		verified, err := NewVerifier(resolver).Verify(ctx, "blog.acme.com", "abc")
+		assert.Error(t, err)
+		assert.False(t, verified)
+	})
+}
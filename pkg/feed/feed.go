@@ -0,0 +1,235 @@
+// Package feed encodes lists of articles as RSS 2.0 and Atom 1.0 feeds.
+package feed
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+)
+
+const (
+	FormatRSS  = "rss"
+	FormatAtom = "atom"
+)
+
+// Feed is what both formats are encoded from. Link is the page the feed
+// belongs to, Self the URL the feed itself is served at and Logo, if set,
+// the URL of an image representing it. Times are written in their own time
+// zone.
+type Feed struct {
+	ID          string
+	Title       string
+	Description string
+	Link        string
+	Self        string
+	Logo        string
+	Language    string
+	Updated     time.Time
+	Entries     []Entry
+}
+
+// Entry is one article of a feed. Summary is plain text and Content HTML;
+// entries without Content only carry their summary.
+type Entry struct {
+	ID         string
+	Title      string
+	Link       string
+	Author     string
+	Published  time.Time
+	Updated    time.Time
+	Categories []string
+	Summary    string
+	Content    string
+}
+
+// ValidFormat reports whether format is one Encode can write.
+func ValidFormat(format string) bool {
+	return format == FormatRSS || format == FormatAtom
+}
+
+// ContentType is the media type feeds of the format are served as.
+func ContentType(format string) string {
+	if format == FormatAtom {
+		return "application/atom+xml; charset=utf-8"
+	}
+	return "application/rss+xml; charset=utf-8"
+}
+
+// Encode writes the feed in the format to w.
+func Encode(w io.Writer, format string, f *Feed) error {
+	var document interface{}
+	switch format {
+	case FormatRSS:
+		document = rssDocument(f)
+	case FormatAtom:
+		document = atomDocument(f)
+	default:
+		return fmt.Errorf("unknown feed format %q", format)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(document); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+type rss struct {
+	XMLName      xml.Name   `xml:"rss"`
+	Version      string     `xml:"version,attr"`
+	AtomNS       string     `xml:"xmlns:atom,attr"`
+	ContentNS    string     `xml:"xmlns:content,attr"`
+	DublinCoreNS string     `xml:"xmlns:dc,attr"`
+	Channel      rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	Language      string    `xml:"language,omitempty"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Self          atomLink  `xml:"atom:link"`
+	Image         *rssImage `xml:"image,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssImage struct {
+	URL   string `xml:"url"`
+	Title string `xml:"title"`
+	Link  string `xml:"link"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	GUID        rssGUID  `xml:"guid"`
+	Creator     string   `xml:"dc:creator,omitempty"`
+	PubDate     string   `xml:"pubDate"`
+	Categories  []string `xml:"category"`
+	Description string   `xml:"description"`
+	Content     string   `xml:"content:encoded,omitempty"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+func rssDocument(f *Feed) *rss {
+	channel := rssChannel{
+		Title:         f.Title,
+		Link:          f.Link,
+		Description:   f.Description,
+		Language:      f.Language,
+		LastBuildDate: f.Updated.Format(time.RFC1123Z),
+		Self:          atomLink{Rel: "self", Type: ContentType(FormatRSS), Href: f.Self},
+		Items:         make([]rssItem, 0, len(f.Entries)),
+	}
+	if f.Logo != "" {
+		channel.Image = &rssImage{URL: f.Logo, Title: f.Title, Link: f.Link}
+	}
+	for _, entry := range f.Entries {
+		channel.Items = append(channel.Items, rssItem{
+			Title:       entry.Title,
+			Link:        entry.Link,
+			GUID:        rssGUID{Value: entry.ID},
+			Creator:     entry.Author,
+			PubDate:     entry.Published.Format(time.RFC1123Z),
+			Categories:  entry.Categories,
+			Description: entry.Summary,
+			Content:     entry.Content,
+		})
+	}
+	return &rss{
+		Version:      "2.0",
+		AtomNS:       "http://www.w3.org/2005/Atom",
+		ContentNS:    "http://purl.org/rss/1.0/modules/content/",
+		DublinCoreNS: "http://purl.org/dc/elements/1.1/",
+		Channel:      channel,
+	}
+}
+
+type atom struct {
+	XMLName  xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Language string      `xml:"xml:lang,attr,omitempty"`
+	ID       string      `xml:"id"`
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle,omitempty"`
+	Logo     string      `xml:"logo,omitempty"`
+	Updated  string      `xml:"updated"`
+	Links    []atomLink  `xml:"link"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Link       atomLink       `xml:"link"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+	Author     atomPerson     `xml:"author"`
+	Categories []atomCategory `xml:"category"`
+	Summary    atomText       `xml:"summary"`
+	Content    *atomText      `xml:"content,omitempty"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+func atomDocument(f *Feed) *atom {
+	document := &atom{
+		Language: f.Language,
+		ID:       f.ID,
+		Title:    f.Title,
+		Subtitle: f.Description,
+		Logo:     f.Logo,
+		Updated:  f.Updated.Format(time.RFC3339),
+		Links: []atomLink{
+			{Rel: "alternate", Href: f.Link},
+			{Rel: "self", Type: ContentType(FormatAtom), Href: f.Self},
+		},
+		Entries: make([]atomEntry, 0, len(f.Entries)),
+	}
+	for _, entry := range f.Entries {
+		atomEntry := atomEntry{
+			ID:        entry.ID,
+			Title:     entry.Title,
+			Link:      atomLink{Rel: "alternate", Href: entry.Link},
+			Published: entry.Published.Format(time.RFC3339),
+			Updated:   entry.Updated.Format(time.RFC3339),
+			Author:    atomPerson{Name: entry.Author},
+			Summary:   atomText{Type: "text", Body: entry.Summary},
+		}
+		for _, category := range entry.Categories {
+			atomEntry.Categories = append(atomEntry.Categories, atomCategory{Term: category})
+		}
+		if entry.Content != "" {
+			atomEntry.Content = &atomText{Type: "html", Body: entry.Content}
+		}
+		document.Entries = append(document.Entries, atomEntry)
+	}
+	return document
+}
@@ -0,0 +1,93 @@
+package feed
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testFeed() *Feed {
+	published := time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)
+	return &Feed{
+		ID:          "tag:blog.example.com,2026:acme",
+		Title:       "Acme",
+		Description: "Latest articles from Acme",
+		Link:        "https://blog.example.com/acme",
+		Self:        "https://blog.example.com/acme/feed",
+		Logo:        "https://cdn.example.com/acme.png",
+		Language:    "en",
+		Updated:     published.Add(time.Hour),
+		Entries: []Entry{{
+			ID:         "tag:blog.example.com,2026-03-01:articles/7",
+			Title:      "Fish & chips",
+			Link:       "https://blog.example.com/acme/fish",
+			Author:     "Ada",
+			Published:  published,
+			Updated:    published.Add(time.Hour),
+			Categories: []string{"food"},
+			Summary:    "A <classic> dish",
+			Content:    "<p>A &lt;classic&gt; dish</p>",
+		}},
+	}
+}
+
+func TestEncodeRSS(t *testing.T) {
+	var b bytes.Buffer
+	assert.NoError(t, Encode(&b, FormatRSS, testFeed()))
+
+	out := b.String()
+	assert.Contains(t, out, `<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom"`)
+	assert.Contains(t, out, `<atom:link rel="self" type="application/rss+xml; charset=utf-8" href="https://blog.example.com/acme/feed"></atom:link>`)
+	assert.Contains(t, out, `<image>
+      <url>https://cdn.example.com/acme.png</url>
+      <title>Acme</title>
+      <link>https://blog.example.com/acme</link>
+    </image>`)
+	assert.Contains(t, out, `<title>Fish &amp; chips</title>`)
+	assert.Contains(t, out, `<guid isPermaLink="false">tag:blog.example.com,2026-03-01:articles/7</guid>`)
+	assert.Contains(t, out, `<pubDate>Sun, 01 Mar 2026 09:30:00 +0000</pubDate>`)
+	assert.Contains(t, out, `<dc:creator>Ada</dc:creator>`)
+	assert.Contains(t, out, `<description>A &lt;classic&gt; dish</description>`)
+	assert.Contains(t, out, `<content:encoded>&lt;p&gt;A &amp;lt;classic&amp;gt; dish&lt;/p&gt;</content:encoded>`)
+}
+
+func TestEncodeAtom(t *testing.T) {
+	f := testFeed()
+	f.Entries[0].Content = ""
+
+	var b bytes.Buffer
+	assert.NoError(t, Encode(&b, FormatAtom, f))
+
+	out := b.String()
+	assert.Contains(t, out, `<feed xmlns="http://www.w3.org/2005/Atom" xml:lang="en">`)
+	assert.Contains(t, out, `<link rel="self" type="application/atom+xml; charset=utf-8" href="https://blog.example.com/acme/feed"></link>`)
+	assert.Contains(t, out, `<logo>https://cdn.example.com/acme.png</logo>`)
+	assert.Contains(t, out, `<updated>2026-03-01T10:30:00Z</updated>`)
+	assert.Contains(t, out, `<published>2026-03-01T09:30:00Z</published>`)
+	assert.Contains(t, out, `<category term="food"></category>`)
+	assert.Contains(t, out, `<summary type="text">A &lt;classic&gt; dish</summary>`)
+	assert.NotContains(t, out, `<content`)
+}
+
+func TestEncodeKeepsTimeZone(t *testing.T) {
+	f := testFeed()
+	f.Logo = ""
+	kolkata := time.FixedZone("IST", 5*60*60+30*60)
+	f.Updated = f.Updated.In(kolkata)
+	f.Entries[0].Published = f.Entries[0].Published.In(kolkata)
+	f.Entries[0].Updated = f.Entries[0].Updated.In(kolkata)
+
+	var rss, atom bytes.Buffer
+	assert.NoError(t, Encode(&rss, FormatRSS, f))
+	assert.NoError(t, Encode(&atom, FormatAtom, f))
+	assert.Contains(t, rss.String(), `<pubDate>Sun, 01 Mar 2026 15:00:00 +0530</pubDate>`)
+	assert.NotContains(t, rss.String(), `<image>`)
+	assert.Contains(t, atom.String(), `<published>2026-03-01T15:00:00+05:30</published>`)
+	assert.NotContains(t, atom.String(), `<logo>`)
+}
+
+func TestEncodeUnknownFormat(t *testing.T) {
+	assert.Error(t, Encode(&bytes.Buffer{}, "json", testFeed()))
+}
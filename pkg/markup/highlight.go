@@ -0,0 +1,230 @@
+package markup
+
+import (
+	"html"
+	"strings"
+)
+
+// language describes enough of a programming language's syntax to pick out
+// its keywords, strings, comments and numbers.
+type language struct {
+	keywords      map[string]bool
+	caseFold      bool
+	lineComments  []string
+	blockComments [][2]string
+	// quotes are the characters strings are delimited with. Strings in
+	// multiline quotes may span lines; raw ones have no escapes.
+	quotes    string
+	multiline string
+	raw       string
+	// identifier holds characters besides letters, digits and _ that can
+	// be part of a name.
+	identifier string
+}
+
+func words(list string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range strings.Fields(list) {
+		set[word] = true
+	}
+	return set
+}
+
+var cFamily = language{lineComments: []string{"//"}, blockComments: [][2]string{{"/*", "*/"}}, quotes: `"'`}
+
+var languages = map[string]language{
+	"go": {
+		keywords:      words("break case chan const continue default defer else fallthrough for func go goto if import interface map package range return select struct switch type var true false nil iota"),
+		lineComments:  cFamily.lineComments,
+		blockComments: cFamily.blockComments,
+		quotes:        "\"'`",
+		multiline:     "`",
+		raw:           "`",
+	},
+	"javascript": {
+		keywords:      words("async await break case catch class const continue debugger default delete do else export extends finally for from function if import in instanceof let new of return static super switch this throw try typeof var void while with yield true false null undefined interface type enum implements private protected public readonly as"),
+		lineComments:  cFamily.lineComments,
+		blockComments: cFamily.blockComments,
+		quotes:        "\"'`",
+		multiline:     "`",
+		identifier:    "$",
+	},
+	"python": {
+		keywords:     words("and as assert async await break class continue def del elif else except finally for from global if import in is lambda nonlocal not or pass raise return try while with yield True False None self"),
+		lineComments: []string{"#"},
+		quotes:       `"'`,
+	},
+	"ruby": {
+		keywords:     words("alias and begin break case class def do else elsif end ensure false for if in module next nil not or redo rescue retry return self super then true undef unless until when while yield require attr_accessor"),
+		lineComments: []string{"#"},
+		quotes:       `"'`,
+	},
+	"shell": {
+		keywords:     words("if then else elif fi case esac for while until do done in function return exit local export readonly source echo cd"),
+		lineComments: []string{"#"},
+		quotes:       `"'`,
+		identifier:   "$-",
+	},
+	"sql": {
+		keywords:      words("select from where and or not insert into values update set delete create alter drop table index view join inner left right outer full on as group by order having limit offset distinct union all case when then else end null is in like between exists primary key foreign references default begin commit rollback returning with"),
+		caseFold:      true,
+		lineComments:  []string{"--"},
+		blockComments: cFamily.blockComments,
+		quotes:        `'"`,
+	},
+	"json": {
+		keywords: words("true false null"),
+		quotes:   `"`,
+	},
+	"yaml": {
+		keywords:     words("true false null yes no on off"),
+		lineComments: []string{"#"},
+		quotes:       `"'`,
+	},
+	"java": {
+		keywords:      words("abstract boolean break byte case catch char class const continue default do double else enum extends final finally float for if implements import instanceof int interface long new package private protected public return short static super switch synchronized this throw throws try void volatile while var record true false null"),
+		lineComments:  cFamily.lineComments,
+		blockComments: cFamily.blockComments,
+		quotes:        cFamily.quotes,
+	},
+	"c": {
+		keywords:      words("auto bool break case char class const constexpr continue default delete do double else enum extern float for goto if include define inline int long namespace new nullptr private protected public return short signed sizeof static struct switch template this typedef typename union unsigned using virtual void volatile while true false NULL"),
+		lineComments:  cFamily.lineComments,
+		blockComments: cFamily.blockComments,
+		quotes:        cFamily.quotes,
+	},
+	"rust": {
+		keywords:      words("as async await break const continue crate dyn else enum extern false fn for if impl in let loop match mod move mut pub ref return self Self static struct super trait true type unsafe use where while"),
+		lineComments:  cFamily.lineComments,
+		blockComments: cFamily.blockComments,
+		quotes:        `"`,
+	},
+}
+
+// languageAliases maps the names code blocks are commonly tagged with to
+// the languages above.
+var languageAliases = map[string]string{
+	"golang": "go",
+	"js":     "javascript", "jsx": "javascript", "ts": "javascript", "tsx": "javascript", "typescript": "javascript",
+	"py": "python",
+	"rb": "ruby",
+	"sh": "shell", "bash": "shell", "zsh": "shell", "console": "shell",
+	"postgres": "sql", "postgresql": "sql", "mysql": "sql",
+	"yml": "yaml",
+	"cpp": "c", "c++": "c", "h": "c", "hpp": "c", "cs": "c", "csharp": "c",
+	"rs": "rust",
+	"kt": "java", "kotlin": "java",
+}
+
+// highlight escapes source and wraps its keywords, strings, comments and
+// numbers in spans with hl-* classes for stylesheets to color. Code in
+// languages it does not know is only escaped.
+func highlight(name, source string) string {
+	if alias, ok := languageAliases[name]; ok {
+		name = alias
+	}
+	lang, ok := languages[name]
+	if !ok {
+		return html.EscapeString(source)
+	}
+
+	var b strings.Builder
+	plain := 0
+	token := func(start, end int, class string) {
+		b.WriteString(html.EscapeString(source[plain:start]))
+		b.WriteString(`<span class="hl-`)
+		b.WriteString(class)
+		b.WriteString(`">`)
+		b.WriteString(html.EscapeString(source[start:end]))
+		b.WriteString("</span>")
+		plain = end
+	}
+
+	for i := 0; i < len(source); {
+		if end := lang.comment(source, i); end > i {
+			token(i, end, "comment")
+			i = end
+			continue
+		}
+
+		c := source[i]
+		switch {
+		case strings.IndexByte(lang.quotes, c) >= 0:
+			end := lang.stringEnd(source, i)
+			token(i, end, "string")
+			i = end
+		case c >= '0' && c <= '9' && (i == 0 || !lang.isIdentifier(source[i-1])):
+			end := i + 1
+			for end < len(source) && (isAlphanumeric(source[end]) || source[end] == '.' || source[end] == '_') {
+				end++
+			}
+			token(i, end, "number")
+			i = end
+		case lang.isIdentifier(c):
+			end := i + 1
+			for end < len(source) && lang.isIdentifier(source[end]) {
+				end++
+			}
+			word := source[i:end]
+			if lang.caseFold {
+				word = strings.ToLower(word)
+			}
+			if lang.keywords[word] && (i == 0 || source[i-1] != '.') {
+				token(i, end, "keyword")
+			}
+			i = end
+		default:
+			i++
+		}
+	}
+	b.WriteString(html.EscapeString(source[plain:]))
+	return b.String()
+}
+
+// comment returns where the comment starting at source[i] ends, or i if
+// none starts there.
+func (l language) comment(source string, i int) int {
+	for _, prefix := range l.lineComments {
+		if strings.HasPrefix(source[i:], prefix) {
+			if end := strings.IndexByte(source[i:], '\n'); end >= 0 {
+				return i + end
+			}
+			return len(source)
+		}
+	}
+	for _, delimiters := range l.blockComments {
+		if strings.HasPrefix(source[i:], delimiters[0]) {
+			if end := strings.Index(source[i+len(delimiters[0]):], delimiters[1]); end >= 0 {
+				return i + len(delimiters[0]) + end + len(delimiters[1])
+			}
+			return len(source)
+		}
+	}
+	return i
+}
+
+// stringEnd returns where the string opened by the quote at source[i]
+// ends. Unterminated strings end with the line.
+func (l language) stringEnd(source string, i int) int {
+	quote := source[i]
+	multiline := strings.IndexByte(l.multiline, quote) >= 0
+	for j := i + 1; j < len(source); j++ {
+		switch source[j] {
+		case quote:
+			return j + 1
+		case '\\':
+			if strings.IndexByte(l.raw, quote) < 0 {
+				j++
+			}
+		case '\n':
+			if !multiline {
+				return j
+			}
+		}
+	}
+	return len(source)
+}
+
+func (l language) isIdentifier(c byte) bool {
+	return isAlphanumeric(c) || c == '_' || c >= 0x80 || strings.IndexByte(l.identifier, c) >= 0
+}
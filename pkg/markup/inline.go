@@ -0,0 +1,369 @@
+package markup
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	autolinkPattern   = regexp.MustCompile(`^<([a-zA-Z][a-zA-Z0-9+.-]{1,31}:[^<>\x00-\x20]*)>`)
+	emailPattern      = regexp.MustCompile(`^<([a-zA-Z0-9.!#$%&'*+/=?^_{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*)>`)
+	inlineHTMLPattern = regexp.MustCompile(`^(?:<[a-zA-Z][a-zA-Z0-9-]*(?:\s+[a-zA-Z_:][a-zA-Z0-9_.:-]*(?:\s*=\s*(?:[^\s"'=<>` + "`" + `]+|'[^']*'|"[^"]*"))?)*\s*/?>|</[a-zA-Z][a-zA-Z0-9-]*\s*>|<!--[\s\S]*?-->)`)
+	entityPattern     = regexp.MustCompile(`^&(?:#[0-9]{1,7}|#[xX][0-9a-fA-F]{1,6}|[a-zA-Z][a-zA-Z0-9]{1,31});`)
+)
+
+// punctuation are the characters a backslash escapes.
+const punctuation = "!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~"
+
+// inline renders the inline Markdown of a paragraph or heading.
+func (r *renderer) inline(text string) string {
+	var b strings.Builder
+	spans(&b, text)
+	return b.String()
+}
+
+func spans(b *strings.Builder, s string) {
+	for i := 0; i < len(s); {
+		next := strings.IndexAny(s[i:], "\\`*_~![<&\n")
+		if next < 0 {
+			b.WriteString(html.EscapeString(s[i:]))
+			return
+		}
+		b.WriteString(html.EscapeString(s[i : i+next]))
+		i += next
+		i += span(b, s, i)
+	}
+}
+
+// span renders the construct starting at s[i] and returns how many bytes
+// it took. Characters that turn out not to start anything are written as
+// text.
+func span(b *strings.Builder, s string, i int) int {
+	switch s[i] {
+	case '\\':
+		switch {
+		case i+1 < len(s) && s[i+1] == '\n':
+			b.WriteString("<br>\n")
+			return 2
+		case i+1 < len(s) && strings.IndexByte(punctuation, s[i+1]) >= 0:
+			b.WriteString(html.EscapeString(s[i+1 : i+2]))
+			return 2
+		}
+	case '\n':
+		// Two spaces at the end of a line make a hard line break.
+		if strings.HasSuffix(s[:i], "  ") {
+			b.WriteString("<br>\n")
+		} else {
+			b.WriteString("\n")
+		}
+		return 1
+	case '`':
+		return codeSpan(b, s, i)
+	case '*', '_', '~':
+		return emphasis(b, s, i)
+	case '!':
+		if i+1 < len(s) && s[i+1] == '[' {
+			if n := link(b, s, i+1, true); n > 0 {
+				return n + 1
+			}
+		}
+	case '[':
+		if n := link(b, s, i, false); n > 0 {
+			return n
+		}
+	case '<':
+		return angle(b, s, i)
+	case '&':
+		if entity := entityPattern.FindString(s[i:]); entity != "" {
+			b.WriteString(entity)
+			return len(entity)
+		}
+	}
+	b.WriteString(html.EscapeString(s[i : i+1]))
+	return 1
+}
+
+func codeSpan(b *strings.Builder, s string, i int) int {
+	n := runLength(s, i)
+	if end := closingBackticks(s, i+n, n); end >= 0 {
+		code := strings.ReplaceAll(s[i+n:end], "\n", " ")
+		if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.Trim(code, " ") != "" {
+			code = code[1 : len(code)-1]
+		}
+		b.WriteString("<code>")
+		b.WriteString(html.EscapeString(code))
+		b.WriteString("</code>")
+		return end + n - i
+	}
+	b.WriteString(s[i : i+n])
+	return n
+}
+
+// closingBackticks finds the run of exactly n backticks closing a code
+// span, or returns -1.
+func closingBackticks(s string, from, n int) int {
+	for j := from; j < len(s); {
+		k := strings.IndexByte(s[j:], '`')
+		if k < 0 {
+			return -1
+		}
+		j += k
+		m := runLength(s, j)
+		if m == n {
+			return j
+		}
+		j += m
+	}
+	return -1
+}
+
+var emphasisTags = map[int][2]string{
+	1: {"<em>", "</em>"},
+	2: {"<strong>", "</strong>"},
+	3: {"<em><strong>", "</strong></em>"},
+}
+
+// emphasis renders *em*, **strong**, ***both*** (or with underscores) and
+// ~~strikethrough~~.
+func emphasis(b *strings.Builder, s string, i int) int {
+	c := s[i]
+	n := runLength(s, i)
+	if canOpen(s, i, n) {
+		if c == '~' {
+			if end := closingDelimiter(s, i+n, 2); n == 2 && end >= 0 {
+				b.WriteString("<del>")
+				spans(b, s[i+2:end])
+				b.WriteString("</del>")
+				return end + 2 - i
+			}
+		} else {
+			for size := min(n, 3); size > 0; size-- {
+				if end := closingDelimiter(s, i+size, size); end >= 0 {
+					b.WriteString(emphasisTags[size][0])
+					spans(b, s[i+size:end])
+					b.WriteString(emphasisTags[size][1])
+					return end + size - i
+				}
+			}
+		}
+	}
+	b.WriteString(s[i : i+n])
+	return n
+}
+
+// canOpen reports whether the delimiter run of n characters at s[i] can
+// open emphasis: it has to be followed by something other than a space,
+// and underscores do not work inside words.
+func canOpen(s string, i, n int) bool {
+	if i+n >= len(s) || isSpace(s[i+n]) {
+		return false
+	}
+	return s[i] != '_' || i == 0 || !isAlphanumeric(s[i-1])
+}
+
+// closingDelimiter finds the run of exactly size delimiters, like the one
+// at s[from-1], that closes emphasis. Escaped characters and code spans are
+// skipped. It returns -1 if there is none.
+func closingDelimiter(s string, from, size int) int {
+	c := s[from-1]
+	for j := from; j < len(s); {
+		switch s[j] {
+		case '\\':
+			j += 2
+			continue
+		case '`':
+			n := runLength(s, j)
+			if end := closingBackticks(s, j+n, n); end >= 0 {
+				j = end + n
+			} else {
+				j += n
+			}
+			continue
+		case c:
+			n := runLength(s, j)
+			after := j + n
+			if n == size && j > from && !isSpace(s[j-1]) && (c != '_' || after >= len(s) || !isAlphanumeric(s[after])) {
+				return j
+			}
+			j = after
+			continue
+		}
+		j++
+	}
+	return -1
+}
+
+// link renders [text](destination "title") or, for images,
+// ![alt](source "title") starting at the opening bracket. It returns 0 if
+// s[i:] is no link.
+func link(b *strings.Builder, s string, i int, image bool) int {
+	end := closingBracket(s, i)
+	if end < 0 || end+1 >= len(s) || s[end+1] != '(' {
+		return 0
+	}
+	destination, title, n, ok := linkTail(s[end+2:])
+	if !ok {
+		return 0
+	}
+
+	text := s[i+1 : end]
+	titleAttribute := ""
+	if title != "" {
+		titleAttribute = fmt.Sprintf(" title=\"%s\"", html.EscapeString(title))
+	}
+	if image {
+		fmt.Fprintf(b, "<img src=\"%s\" alt=\"%s\"%s>", html.EscapeString(destination), html.EscapeString(plainText(text)), titleAttribute)
+	} else {
+		fmt.Fprintf(b, "<a href=\"%s\"%s>", html.EscapeString(destination), titleAttribute)
+		spans(b, text)
+		b.WriteString("</a>")
+	}
+	return end + 2 + n - i
+}
+
+// closingBracket finds the bracket matching the one at s[i], or returns -1.
+func closingBracket(s string, i int) int {
+	depth := 0
+	for j := i; j < len(s); j++ {
+		switch s[j] {
+		case '\\':
+			j++
+		case '`':
+			n := runLength(s, j)
+			if end := closingBackticks(s, j+n, n); end >= 0 {
+				j = end + n - 1
+			} else {
+				j += n - 1
+			}
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				return j
+			}
+		}
+	}
+	return -1
+}
+
+// linkTail parses the `destination "title")` following the text of a link
+// and returns how many bytes it took.
+func linkTail(t string) (destination, title string, n int, ok bool) {
+	j := skipSpaces(t, 0)
+	if j < len(t) && t[j] == '<' {
+		k := strings.IndexAny(t[j+1:], ">\n")
+		if k < 0 || t[j+1+k] != '>' {
+			return "", "", 0, false
+		}
+		destination = t[j+1 : j+1+k]
+		j += k + 2
+	} else {
+		start, depth := j, 0
+	destination:
+		for ; j < len(t); j++ {
+			switch c := t[j]; {
+			case c == '\\' && j+1 < len(t):
+				j++
+			case c == '(':
+				depth++
+			case c == ')':
+				if depth == 0 {
+					break destination
+				}
+				depth--
+			case c <= ' ':
+				break destination
+			}
+		}
+		destination = t[start:j]
+	}
+
+	k := skipSpaces(t, j)
+	if k > j && k < len(t) && strings.IndexByte("\"'(", t[k]) >= 0 {
+		closing := t[k]
+		if closing == '(' {
+			closing = ')'
+		}
+		m := k + 1
+		for ; m < len(t) && t[m] != closing; m++ {
+			if t[m] == '\\' {
+				m++
+			}
+		}
+		if m >= len(t) {
+			return "", "", 0, false
+		}
+		title = t[k+1 : m]
+		k = skipSpaces(t, m+1)
+	}
+	if k >= len(t) || t[k] != ')' {
+		return "", "", 0, false
+	}
+	return unescape(destination), unescape(title), k + 1, true
+}
+
+// angle renders an autolink or raw HTML tag starting at s[i], or escapes
+// the bracket.
+func angle(b *strings.Builder, s string, i int) int {
+	if m := autolinkPattern.FindStringSubmatch(s[i:]); m != nil {
+		fmt.Fprintf(b, "<a href=\"%[1]s\">%[1]s</a>", html.EscapeString(m[1]))
+		return len(m[0])
+	}
+	if m := emailPattern.FindStringSubmatch(s[i:]); m != nil {
+		fmt.Fprintf(b, "<a href=\"mailto:%[1]s\">%[1]s</a>", html.EscapeString(m[1]))
+		return len(m[0])
+	}
+	if tag := inlineHTMLPattern.FindString(s[i:]); tag != "" {
+		b.WriteString(tag)
+		return len(tag)
+	}
+	b.WriteString("&lt;")
+	return 1
+}
+
+// plainText renders inline Markdown and drops the markup, for image alt
+// texts.
+func plainText(s string) string {
+	var b strings.Builder
+	spans(&b, s)
+	return html.UnescapeString(tagPattern.ReplaceAllString(b.String(), ""))
+}
+
+// unescape resolves the backslash escapes and entities of a link
+// destination or title.
+func unescape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && strings.IndexByte(punctuation, s[i+1]) >= 0 {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return html.UnescapeString(b.String())
+}
+
+func runLength(s string, i int) int {
+	n := 0
+	for i+n < len(s) && s[i+n] == s[i] {
+		n++
+	}
+	return n
+}
+
+func skipSpaces(s string, i int) int {
+	for i < len(s) && (s[i] == ' ' || s[i] == '\n') {
+		i++
+	}
+	return i
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\t'
+}
+
+func isAlphanumeric(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
@@ -0,0 +1,468 @@
+package markup
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/slug"
+)
+
+// renderMarkdown renders the commonly used part of CommonMark: headings,
+// paragraphs, block quotes, nested lists, fenced and indented code, thematic
+// breaks, emphasis, code spans, links, images and raw HTML, plus
+// strikethrough. Fenced code is highlighted for the languages highlight
+// knows, and headings get IDs so they can be linked to. Raw HTML is passed
+// through; the caller sanitizes the result.
+func renderMarkdown(source string) string {
+	r := &renderer{ids: make(map[string]int)}
+	lines := strings.Split(normalizeNewlines(source), "\n")
+	for i, line := range lines {
+		lines[i] = expandTabs(line)
+	}
+	r.blocks(lines)
+	return r.out.String()
+}
+
+type renderer struct {
+	out strings.Builder
+	ids map[string]int
+	// tight is set while rendering the items of a tight list, whose
+	// paragraphs are not wrapped in <p>.
+	tight bool
+}
+
+func (r *renderer) blocks(lines []string) {
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		switch {
+		case isBlank(line):
+			i++
+		case indentation(line) >= 4:
+			i = r.indentedCode(lines, i)
+		case fenceOf(line) != nil:
+			i = r.fencedCode(lines, i)
+		case headingLevel(line) > 0:
+			r.atxHeading(line)
+			i++
+		case isThematicBreak(line):
+			r.out.WriteString("<hr>\n")
+			i++
+		case isQuote(line):
+			i = r.blockquote(lines, i)
+		case listMarkerOf(line) != nil:
+			i = r.list(lines, i)
+		case isHTMLBlock(line):
+			i = r.htmlBlock(lines, i)
+		default:
+			i = r.paragraph(lines, i)
+		}
+	}
+}
+
+func (r *renderer) paragraph(lines []string, i int) int {
+	var text []string
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if isBlank(line) {
+			break
+		}
+		if len(text) > 0 {
+			if level := setextLevel(line); level > 0 {
+				r.heading(level, strings.Join(text, "\n"))
+				return i + 1
+			}
+			if interruptsParagraph(line) {
+				break
+			}
+		}
+		text = append(text, strings.TrimLeft(line, " "))
+	}
+
+	content := r.inline(strings.TrimRight(strings.Join(text, "\n"), " "))
+	if r.tight {
+		r.out.WriteString(content)
+		r.out.WriteString("\n")
+		return i
+	}
+	r.out.WriteString("<p>")
+	r.out.WriteString(content)
+	r.out.WriteString("</p>\n")
+	return i
+}
+
+// interruptsParagraph reports whether line starts a new block even without
+// a blank line before it.
+func interruptsParagraph(line string) bool {
+	if headingLevel(line) > 0 || isThematicBreak(line) || isQuote(line) || fenceOf(line) != nil || isHTMLBlock(line) {
+		return true
+	}
+	marker := listMarkerOf(line)
+	return marker != nil && !marker.empty && (!marker.ordered || marker.start == 1)
+}
+
+func (r *renderer) atxHeading(line string) {
+	line = strings.TrimLeft(line, " ")
+	level := headingLevel(line)
+	text := strings.TrimSpace(line[level:])
+	// A closing sequence of #s is not part of the heading.
+	if trimmed := strings.TrimRight(text, "#"); trimmed == "" || strings.HasSuffix(trimmed, " ") {
+		text = strings.TrimSpace(trimmed)
+	}
+	r.heading(level, text)
+}
+
+func (r *renderer) heading(level int, text string) {
+	content := r.inline(strings.TrimSpace(text))
+	fmt.Fprintf(&r.out, "<h%d id=\"%s\">%s</h%d>\n", level, r.headingID(content), content, level)
+}
+
+var tagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// headingID derives an anchor from the heading's text. Headings sharing a
+// text get numbered IDs, like "usage", "usage-1".
+func (r *renderer) headingID(content string) string {
+	id := slug.Make(html.UnescapeString(tagPattern.ReplaceAllString(content, "")), "section")
+	n := r.ids[id]
+	r.ids[id] = n + 1
+	if n > 0 {
+		return id + "-" + strconv.Itoa(n)
+	}
+	return id
+}
+
+func headingLevel(line string) int {
+	if indentation(line) > 3 {
+		return 0
+	}
+	line = strings.TrimLeft(line, " ")
+	level := 0
+	for level < len(line) && line[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 {
+		return 0
+	}
+	if level < len(line) && line[level] != ' ' {
+		return 0
+	}
+	return level
+}
+
+func setextLevel(line string) int {
+	trimmed := strings.TrimSpace(line)
+	switch {
+	case indentation(line) > 3 || trimmed == "":
+		return 0
+	case strings.Trim(trimmed, "=") == "":
+		return 1
+	case strings.Trim(trimmed, "-") == "":
+		return 2
+	}
+	return 0
+}
+
+func isThematicBreak(line string) bool {
+	if indentation(line) > 3 {
+		return false
+	}
+	trimmed := strings.ReplaceAll(strings.TrimSpace(line), " ", "")
+	if len(trimmed) < 3 {
+		return false
+	}
+	return strings.Trim(trimmed, trimmed[:1]) == "" && strings.ContainsAny(trimmed[:1], "-*_")
+}
+
+type fence struct {
+	char   byte
+	length int
+	indent int
+	info   string
+}
+
+func fenceOf(line string) *fence {
+	indent := indentation(line)
+	if indent > 3 {
+		return nil
+	}
+	rest := line[indent:]
+	if rest == "" || (rest[0] != '`' && rest[0] != '~') {
+		return nil
+	}
+	n := 0
+	for n < len(rest) && rest[n] == rest[0] {
+		n++
+	}
+	info := strings.TrimSpace(rest[n:])
+	if n < 3 || (rest[0] == '`' && strings.Contains(info, "`")) {
+		return nil
+	}
+	return &fence{char: rest[0], length: n, indent: indent, info: info}
+}
+
+func (r *renderer) fencedCode(lines []string, i int) int {
+	open := fenceOf(lines[i])
+	var code []string
+	for i++; i < len(lines); i++ {
+		line := lines[i]
+		if closing := fenceOf(line); closing != nil && closing.char == open.char && closing.length >= open.length && closing.info == "" {
+			i++
+			break
+		}
+		code = append(code, strings.TrimPrefix(line, strings.Repeat(" ", min(open.indent, indentation(line)))))
+	}
+
+	language := ""
+	if fields := strings.Fields(open.info); len(fields) > 0 {
+		language = strings.ToLower(html.UnescapeString(fields[0]))
+	}
+	r.code(language, code)
+	return i
+}
+
+func (r *renderer) indentedCode(lines []string, i int) int {
+	var code []string
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if !isBlank(line) && indentation(line) < 4 {
+			break
+		}
+		code = append(code, strings.TrimPrefix(line, strings.Repeat(" ", min(4, indentation(line)))))
+	}
+	for len(code) > 0 && isBlank(code[len(code)-1]) {
+		code = code[:len(code)-1]
+	}
+	r.code("", code)
+	return i
+}
+
+var languagePattern = regexp.MustCompile(`^[a-z0-9_+#.-]{1,32}$`)
+
+func (r *renderer) code(language string, lines []string) {
+	source := strings.Join(lines, "\n")
+	if len(lines) > 0 {
+		source += "\n"
+	}
+	if languagePattern.MatchString(language) {
+		fmt.Fprintf(&r.out, "<pre><code class=\"language-%s\">%s</code></pre>\n", language, highlight(language, source))
+		return
+	}
+	fmt.Fprintf(&r.out, "<pre><code>%s</code></pre>\n", html.EscapeString(source))
+}
+
+func isQuote(line string) bool {
+	return indentation(line) <= 3 && strings.HasPrefix(strings.TrimLeft(line, " "), ">")
+}
+
+func (r *renderer) blockquote(lines []string, i int) int {
+	var inner []string
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if isQuote(line) {
+			line = strings.TrimPrefix(strings.TrimLeft(line, " ")[1:], " ")
+		} else if isBlank(line) || len(inner) == 0 || isBlank(inner[len(inner)-1]) || interruptsParagraph(line) {
+			break
+		}
+		// Anything else is a lazy continuation of the quoted paragraph.
+		inner = append(inner, line)
+	}
+
+	content := &renderer{ids: r.ids}
+	content.blocks(inner)
+	fmt.Fprintf(&r.out, "<blockquote>\n%s</blockquote>\n", content.out.String())
+	return i
+}
+
+type listMarker struct {
+	ordered bool
+	start   int
+	// delimiter is the bullet of an unordered list or the . or ) after the
+	// number of an ordered one.
+	delimiter byte
+	// width is how far the item's content is indented.
+	width int
+	empty bool
+}
+
+func listMarkerOf(line string) *listMarker {
+	indent := indentation(line)
+	if indent > 3 {
+		return nil
+	}
+	rest := line[indent:]
+	marker := &listMarker{}
+	n := 0
+	switch {
+	case rest == "":
+		return nil
+	case strings.ContainsRune("-*+", rune(rest[0])):
+		marker.delimiter = rest[0]
+		n = 1
+	default:
+		for n < len(rest) && n < 9 && rest[n] >= '0' && rest[n] <= '9' {
+			n++
+		}
+		if n == 0 || n >= len(rest) || (rest[n] != '.' && rest[n] != ')') {
+			return nil
+		}
+		marker.ordered = true
+		marker.start, _ = strconv.Atoi(rest[:n])
+		marker.delimiter = rest[n]
+		n++
+	}
+
+	content := rest[n:]
+	if content != "" && content[0] != ' ' {
+		return nil
+	}
+	spaces := indentation(content)
+	marker.empty = isBlank(content)
+	if spaces > 4 || marker.empty {
+		spaces = 1
+	}
+	marker.width = indent + n + spaces
+	return marker
+}
+
+func (m *listMarker) continues(other *listMarker) bool {
+	return other != nil && other.ordered == m.ordered && other.delimiter == m.delimiter
+}
+
+func (r *renderer) list(lines []string, i int) int {
+	first := listMarkerOf(lines[i])
+	var items [][]string
+	loose := false
+	for i < len(lines) {
+		marker := listMarkerOf(lines[i])
+		if !first.continues(marker) {
+			break
+		}
+
+		item := []string{padTo(lines[i], marker.width)[marker.width:]}
+		for i++; i < len(lines); i++ {
+			line := lines[i]
+			switch {
+			case isBlank(line):
+				item = append(item, "")
+				continue
+			case indentation(line) >= marker.width:
+				item = append(item, line[marker.width:])
+				continue
+			case !isBlank(item[len(item)-1]) && !interruptsParagraph(line) && !isThematicBreak(line):
+				// A lazy continuation of the item's last paragraph.
+				item = append(item, strings.TrimLeft(line, " "))
+				continue
+			}
+			break
+		}
+
+		// Blank lines at the end of an item separate it from the next one,
+		// which makes the list loose if another item follows.
+		trailing := 0
+		for len(item) > 1 && isBlank(item[len(item)-1]) {
+			item = item[:len(item)-1]
+			trailing++
+		}
+		if hasInnerBlankLine(item) || (trailing > 0 && i < len(lines) && first.continues(listMarkerOf(lines[i]))) {
+			loose = true
+		}
+		items = append(items, item)
+	}
+
+	tag := "ul"
+	if first.ordered {
+		tag = "ol"
+	}
+	if first.ordered && first.start != 1 {
+		fmt.Fprintf(&r.out, "<ol start=\"%d\">\n", first.start)
+	} else {
+		fmt.Fprintf(&r.out, "<%s>\n", tag)
+	}
+	for _, item := range items {
+		content := &renderer{ids: r.ids, tight: !loose}
+		content.blocks(item)
+		if loose {
+			fmt.Fprintf(&r.out, "<li>\n%s</li>\n", content.out.String())
+		} else {
+			fmt.Fprintf(&r.out, "<li>%s</li>\n", strings.TrimSuffix(content.out.String(), "\n"))
+		}
+	}
+	fmt.Fprintf(&r.out, "</%s>\n", tag)
+	return i
+}
+
+// hasInnerBlankLine reports whether a blank line separates two blocks of a
+// list item, as opposed to lines inside a fenced code block.
+func hasInnerBlankLine(item []string) bool {
+	var open *fence
+	for _, line := range item {
+		if f := fenceOf(line); f != nil {
+			if open == nil {
+				open = f
+			} else if f.char == open.char && f.length >= open.length && f.info == "" {
+				open = nil
+			}
+			continue
+		}
+		if open == nil && isBlank(line) {
+			return true
+		}
+	}
+	return false
+}
+
+var htmlBlockPattern = regexp.MustCompile(`^ {0,3}<(/?[a-zA-Z][a-zA-Z0-9-]*[\s/>]|/?[a-zA-Z][a-zA-Z0-9-]*$|!--)`)
+
+func isHTMLBlock(line string) bool {
+	return htmlBlockPattern.MatchString(line)
+}
+
+// htmlBlock passes lines of raw HTML through up to the next blank line.
+func (r *renderer) htmlBlock(lines []string, i int) int {
+	for ; i < len(lines) && !isBlank(lines[i]); i++ {
+		r.out.WriteString(lines[i])
+		r.out.WriteString("\n")
+	}
+	return i
+}
+
+func isBlank(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
+
+func indentation(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+// padTo appends spaces to line so it is at least n bytes long, for items
+// whose marker is followed by nothing.
+func padTo(line string, n int) string {
+	if len(line) < n {
+		return line + strings.Repeat(" ", n-len(line))
+	}
+	return line
+}
+
+// expandTabs replaces the tabs indenting a line with spaces, up to the
+// next multiple of four, so indentation can be compared in spaces.
+func expandTabs(line string) string {
+	var b strings.Builder
+	column := 0
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\t':
+			n := 4 - column%4
+			b.WriteString(strings.Repeat(" ", n))
+			column += n
+		case ' ':
+			b.WriteByte(' ')
+			column++
+		default:
+			b.WriteString(line[i:])
+			return b.String()
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,51 @@
+// Package markup turns article content into HTML that is safe to display:
+// Markdown is rendered, HTML is sanitized and plain text is escaped.
+package markup
+
+import (
+	"html"
+	"strings"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+)
+
+// ValidFormat reports whether content can be written in format.
+func ValidFormat(format string) bool {
+	switch format {
+	case model.ContentFormatMarkdown, model.ContentFormatHTML, model.ContentFormatPlain:
+		return true
+	}
+	return false
+}
+
+// Render returns the sanitized HTML for content written in format. An
+// empty format is taken as Markdown.
+func Render(format, content string) string {
+	switch format {
+	case model.ContentFormatHTML:
+		return Sanitize(content)
+	case model.ContentFormatPlain:
+		return renderPlain(content)
+	}
+	return Sanitize(renderMarkdown(content))
+}
+
+// renderPlain escapes text and keeps its line structure: blank lines
+// separate paragraphs and other line breaks are kept as they are.
+func renderPlain(text string) string {
+	var b strings.Builder
+	for _, paragraph := range strings.Split(normalizeNewlines(text), "\n\n") {
+		paragraph = strings.Trim(paragraph, "\n")
+		if strings.TrimSpace(paragraph) == "" {
+			continue
+		}
+		b.WriteString("<p>")
+		b.WriteString(strings.ReplaceAll(html.EscapeString(paragraph), "\n", "<br>\n"))
+		b.WriteString("</p>\n")
+	}
+	return b.String()
+}
+
+func normalizeNewlines(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "\r\n", "\n"), "\r", "\n")
+}
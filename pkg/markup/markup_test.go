@@ -0,0 +1,138 @@
+package markup
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/html"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	cases := []struct {
+		name, source, html string
+	}{
+		{"headings get unique anchors", "# Setup\n\n## Setup *again*", "<h1 id=\"setup\">Setup</h1>\n<h2 id=\"setup-again\">Setup <em>again</em></h2>\n"},
+		{"repeated headings are numbered", "## Usage\n\nUsage\n---", "<h2 id=\"usage\">Usage</h2>\n<h2 id=\"usage-1\">Usage</h2>\n"},
+		{"emphasis and code spans", "**bold** _em_ ~~gone~~ `a<b>` snake_case_name", "<p><strong>bold</strong> <em>em</em> <del>gone</del> <code>a&lt;b&gt;</code> snake_case_name</p>\n"},
+		{"links and images", "[docs](https://example.com \"Docs\") ![a *b*](/img.png)", "<p><a href=\"https://example.com\" title=\"Docs\">docs</a> <img src=\"/img.png\" alt=\"a b\"></p>\n"},
+		{"tight nested list", "- one\n- two\n  - three", "<ul>\n<li>one</li>\n<li>two\n<ul>\n<li>three</li>\n</ul></li>\n</ul>\n"},
+		{"loose ordered list", "3. one\n\n4. two", "<ol start=\"3\">\n<li>\n<p>one</p>\n</li>\n<li>\n<p>two</p>\n</li>\n</ol>\n"},
+		{"block quote with lazy line", "> quoted\nlazy", "<blockquote>\n<p>quoted\nlazy</p>\n</blockquote>\n"},
+		{"highlighted code", "```go\nreturn \"x\" // done\n```", "<pre><code class=\"language-go\"><span class=\"hl-keyword\">return</span> <span class=\"hl-string\">&#34;x&#34;</span> <span class=\"hl-comment\">// done</span>\n</code></pre>\n"},
+		{"unknown language is escaped", "~~~brainfuck\n<+>\n~~~", "<pre><code class=\"language-brainfuck\">&lt;+&gt;\n</code></pre>\n"},
+		{"raw html is sanitized", "<div onclick=\"x()\">hi</div>\n\n<script>alert(1)</script>", "<div>hi</div>\n\n"},
+		{"unsafe link destinations are dropped", "[x](javascript:alert(1)) <javascript:alert(1)>", "<p><a>x</a> <a>javascript:alert(1)</a></p>\n"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.html, Render("markdown", tc.source))
+		})
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	cases := []struct {
+		name, source, html string
+	}{
+		{"event handlers and styles", `<p onmouseover="x()" style="color:red">hi</p>`, `<p>hi</p>`},
+		{"scripts with their content", `a<script>document.cookie</script>b<style>p{}</style>c`, `abc`},
+		{"scheme tricks", `<a href=" JaVaScRiPt:x()">1</a><a href="java&#x09;script:x()">2</a><img src="data:image/png;base64,x">`, `<a>1</a><a>2</a><img>`},
+		{"allowed urls", `<a href="mailto:a@b.c">m</a><a href="/x#y">r</a>`, `<a href="mailto:a@b.c">m</a><a href="/x#y">r</a>`},
+		{"unknown elements keep their text", `<marquee><blink>old</blink></marquee>`, `old`},
+		{"classes outside the allow-list", `<span class="hl-keyword overlay">k</span><code class="x">c</code>`, `<span class="hl-keyword">k</span><code>c</code>`},
+		{"unclosed elements", `<ul><li><em>x`, `<ul><li><em>x</em></li></ul>`},
+		{"text is escaped", `<p>&lt;b&gt; &amp; "q"</p>`, `<p>&lt;b&gt; &amp; &#34;q&#34;</p>`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.html, Sanitize(tc.source))
+		})
+	}
+}
+
+func TestRenderPlain(t *testing.T) {
+	assert.Equal(t, "<p>a &lt;b&gt;<br>\nc</p>\n<p>d</p>\n", Render("plain", "a <b>\r\nc\n\n\nd"))
+}
+
+func TestSummary(t *testing.T) {
+	cases := []struct {
+		name, html, summary string
+		limit               int
+	}{
+		{"blocks are separated", "<h1>Title</h1>\n<p>One <em>two</em>.</p><ul><li>a</li><li>b</li></ul>", "Title One two. a b", 100},
+		{"code blocks are left out", "<p>Run</p><pre><code>make all</code></pre><p>then <code>ls</code></p>", "Run then ls", 100},
+		{"entities are decoded", "<p>&lt;tag&gt; &amp; more</p>", "<tag> & more", 100},
+		{"cut after a word", "<p>The quick brown fox jumps</p>", "The quick brown…", 18},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.summary, Summary(tc.html, tc.limit))
+		})
+	}
+}
+
+// FuzzSanitize checks that whatever the input, no event handler attribute
+// and no script or data URL survives, both in raw HTML and in markdown.
+func FuzzSanitize(f *testing.F) {
+	seeds := []string{
+		`<p onmouseover="x()">hi</p>`,
+		`<a href=" JaVaScRiPt:x()">1</a><a href="java&#x09;script:x()">2</a>`,
+		`<img src="data:image/png;base64,x" onerror=alert(1)>`,
+		`<a href="&#0;javascript:x()">z</a><a href="&#x20;data:text/html,x">d</a>`,
+		`<svg><a href="javascript:x()">s</a></svg><math><mi xlink:href="javascript:x()">m</mi></math>`,
+		`<img/src="x"/onerror="alert(1)"><a/href=javascript:x()>a</a>`,
+		`<scr<script>ipt>alert(1)</script>`,
+		"[x](javascript:alert(1)) ![y](data:image/png;base64,x) <javascript:alert(1)>",
+		"[x](<java\tscript:alert(1)>) [y](\x01javascript:x)",
+		"<div onclick=\"x()\">\n\n*hi*\n\n</div>",
+	}
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, source string) {
+		assertSafe(t, Sanitize(source))
+		assertSafe(t, Render("markdown", source))
+	})
+}
+
+func assertSafe(t *testing.T, out string) {
+	t.Helper()
+	z := html.NewTokenizer(strings.NewReader(out))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			return
+		}
+		if tt != html.StartTagToken && tt != html.SelfClosingTagToken {
+			continue
+		}
+		token := z.Token()
+		if droppedElements[token.Data] {
+			t.Fatalf("<%s> survived in %q", token.Data, out)
+		}
+		for _, attribute := range token.Attr {
+			key := strings.ToLower(attribute.Key)
+			if strings.HasPrefix(key, "on") {
+				t.Fatalf("%s survived in %q", key, out)
+			}
+			if key != "href" && key != "src" {
+				continue
+			}
+			// Browsers ignore tabs and newlines anywhere in a URL and
+			// surrounding control characters and spaces.
+			value := strings.Map(func(r rune) rune {
+				if r == '\t' || r == '\n' || r == '\r' {
+					return -1
+				}
+				return r
+			}, attribute.Val)
+			value = strings.ToLower(strings.TrimLeft(value, "\x00\x01\x02\x03\x04\x05\x06\x07\x08\x0b\x0c\x0e\x0f\x10\x11\x12\x13\x14\x15\x16\x17\x18\x19\x1a\x1b\x1c\x1d\x1e\x1f "))
+			for _, scheme := range []string{"javascript:", "vbscript:", "data:"} {
+				if strings.HasPrefix(value, scheme) {
+					t.Fatalf("%s URL survived in %q", scheme, out)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package markup
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/slug"
+)
+
+// allowedElements maps the elements Sanitize keeps to the attributes they
+// may carry. Other elements are dropped but their content is kept.
+var allowedElements = map[string]map[string]bool{
+	"a": {"href": true, "title": true}, "img": {"src": true, "alt": true, "title": true, "width": true, "height": true},
+	"h1": {"id": true}, "h2": {"id": true}, "h3": {"id": true}, "h4": {"id": true}, "h5": {"id": true}, "h6": {"id": true},
+	"p": {}, "br": {}, "hr": {}, "blockquote": {}, "div": {},
+	"pre": {"class": true}, "code": {"class": true}, "span": {"class": true},
+	"em": {}, "strong": {}, "b": {}, "i": {}, "u": {}, "s": {}, "del": {}, "ins": {}, "mark": {},
+	"sub": {}, "sup": {}, "small": {}, "kbd": {}, "abbr": {"title": true},
+	"ul": {}, "ol": {"start": true}, "li": {}, "dl": {}, "dt": {}, "dd": {},
+	"table": {}, "caption": {}, "thead": {}, "tbody": {}, "tfoot": {}, "tr": {},
+	"th": {"align": true, "colspan": true, "rowspan": true}, "td": {"align": true, "colspan": true, "rowspan": true},
+	"figure": {}, "figcaption": {}, "details": {}, "summary": {},
+}
+
+// droppedElements are removed together with their content.
+var droppedElements = map[string]bool{
+	"script": true, "style": true, "template": true, "iframe": true, "frame": true, "frameset": true,
+	"object": true, "embed": true, "applet": true, "noscript": true, "noembed": true, "noframes": true,
+	"textarea": true, "select": true, "title": true, "head": true, "svg": true, "math": true, "xmp": true,
+}
+
+var voidElements = map[string]bool{"br": true, "hr": true, "img": true}
+
+var (
+	classPattern  = regexp.MustCompile(`^(language-[a-z0-9_+#.-]{1,32}|hl-[a-z]+)$`)
+	numberPattern = regexp.MustCompile(`^[0-9]{1,4}$`)
+)
+
+// Sanitize keeps only the elements and attributes of an allow-list, so the
+// HTML can be shown on a page without running scripts, loading frames or
+// changing the page's styles. Links and images must point to http(s) or
+// relative URLs (links may also be mailto:), and unclosed elements are
+// closed.
+func Sanitize(source string) string {
+	var b strings.Builder
+	var open []string
+	// skip is the dropped element whose content is being skipped, and
+	// depth how deep inside it the tokenizer is.
+	skip, depth := "", 0
+
+	z := html.NewTokenizer(strings.NewReader(source))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			for i := len(open) - 1; i >= 0; i-- {
+				b.WriteString("</" + open[i] + ">")
+			}
+			return b.String()
+		}
+		token := z.Token()
+
+		if skip != "" {
+			switch {
+			case tt == html.StartTagToken && token.Data == skip:
+				depth++
+			case tt == html.EndTagToken && token.Data == skip:
+				if depth--; depth == 0 {
+					skip = ""
+				}
+			}
+			continue
+		}
+
+		switch tt {
+		case html.TextToken:
+			b.WriteString(html.EscapeString(token.Data))
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if droppedElements[token.Data] {
+				if tt == html.StartTagToken {
+					skip, depth = token.Data, 1
+				}
+				continue
+			}
+			attributes, ok := allowedElements[token.Data]
+			if !ok {
+				continue
+			}
+			writeStartTag(&b, token, attributes)
+			switch {
+			case voidElements[token.Data]:
+			case tt == html.SelfClosingTagToken:
+				b.WriteString("</" + token.Data + ">")
+			default:
+				open = append(open, token.Data)
+			}
+		case html.EndTagToken:
+			// Close the element along with any left open inside it.
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] == token.Data {
+					for len(open) > i {
+						b.WriteString("</" + open[len(open)-1] + ">")
+						open = open[:len(open)-1]
+					}
+					break
+				}
+			}
+		}
+	}
+}
+
+func writeStartTag(b *strings.Builder, token html.Token, attributes map[string]bool) {
+	b.WriteString("<" + token.Data)
+	seen := make(map[string]bool)
+	for _, attribute := range token.Attr {
+		if attribute.Namespace != "" || !attributes[attribute.Key] || seen[attribute.Key] {
+			continue
+		}
+		value, ok := cleanAttribute(attribute.Key, attribute.Val)
+		if !ok {
+			continue
+		}
+		seen[attribute.Key] = true
+		b.WriteString(" " + attribute.Key + `="` + html.EscapeString(value) + `"`)
+	}
+	b.WriteString(">")
+}
+
+// cleanAttribute returns the value to keep for an allowed attribute, or
+// false if it is unsafe or malformed.
+func cleanAttribute(key, value string) (string, bool) {
+	switch key {
+	case "href":
+		return strings.TrimSpace(value), safeURL(value, "http", "https", "mailto")
+	case "src":
+		return strings.TrimSpace(value), safeURL(value, "http", "https")
+	case "id":
+		return value, slug.Valid(value)
+	case "class":
+		var classes []string
+		for _, class := range strings.Fields(value) {
+			if classPattern.MatchString(class) {
+				classes = append(classes, class)
+			}
+		}
+		return strings.Join(classes, " "), len(classes) > 0
+	case "start", "width", "height", "colspan", "rowspan":
+		return value, numberPattern.MatchString(value)
+	case "align":
+		return value, value == "left" || value == "center" || value == "right"
+	}
+	return value, true
+}
+
+// safeURL reports whether value is a relative URL or uses one of the
+// schemes.
+func safeURL(value string, schemes ...string) bool {
+	if strings.IndexFunc(value, func(r rune) bool { return r < 0x20 || r == 0x7f }) >= 0 {
+		return false
+	}
+	u, err := url.Parse(strings.TrimSpace(value))
+	if err != nil {
+		return false
+	}
+	if u.Scheme == "" {
+		return true
+	}
+	for _, scheme := range schemes {
+		if u.Scheme == scheme {
+			return true
+		}
+	}
+	return false
+}
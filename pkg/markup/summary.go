@@ -0,0 +1,62 @@
+package markup
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// inlineElements do not separate the words around them.
+var inlineElements = map[string]bool{
+	"a": true, "em": true, "strong": true, "b": true, "i": true, "u": true, "s": true, "del": true, "ins": true,
+	"mark": true, "sub": true, "sup": true, "small": true, "kbd": true, "abbr": true, "code": true, "span": true,
+}
+
+// Summary returns the text of rendered HTML with whitespace collapsed, cut
+// at a word boundary to at most limit characters. Text that was cut ends in
+// an ellipsis. Code blocks are left out, as they read poorly as prose.
+func Summary(source string, limit int) string {
+	var text strings.Builder
+	pre := 0
+
+	z := html.NewTokenizer(strings.NewReader(source))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		name, _ := z.TagName()
+		switch tt {
+		case html.TextToken:
+			if pre == 0 {
+				text.Write(z.Text())
+			}
+			continue
+		case html.StartTagToken:
+			if string(name) == "pre" {
+				pre++
+			}
+		case html.EndTagToken:
+			if string(name) == "pre" && pre > 0 {
+				pre--
+			}
+		}
+		if !inlineElements[string(name)] {
+			text.WriteByte(' ')
+		}
+	}
+	return truncate(strings.Join(strings.Fields(text.String()), " "), limit)
+}
+
+// truncate cuts s to at most limit characters, preferably after a word.
+func truncate(s string, limit int) string {
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+	cut := string(runes[:limit-1])
+	if i := strings.LastIndexByte(cut, ' '); i > len(cut)/2 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " ,.;:") + "…"
+}
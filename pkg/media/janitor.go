@@ -0,0 +1,85 @@
+package media
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/storage"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/repository"
+)
+
+// SweepInterval is how often the janitor removes the files of deleted
+// attachments.
+const SweepInterval = 10 * time.Minute
+
+const sweepBatch = 100
+
+// Janitor removes the files of deleted attachments and their variants from
+// storage and then drops their rows.
+type Janitor struct {
+	attachmentRepo repository.AttachmentRepository
+	store          storage.Storage
+}
+
+func NewJanitor(attachmentRepo repository.AttachmentRepository, store storage.Storage) *Janitor {
+	return &Janitor{
+		attachmentRepo: attachmentRepo,
+		store:          store,
+	}
+}
+
+// Run sweeps right away and then every SweepInterval until ctx is done.
+func (j *Janitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(SweepInterval)
+	defer ticker.Stop()
+
+	for {
+		j.SweepOnce(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SweepOnce works through deleted attachments in batches until none are
+// left or a file cannot be removed, which is retried on the next sweep.
+func (j *Janitor) SweepOnce(ctx context.Context) {
+	removed := 0
+	for {
+		n, err := j.sweep(ctx)
+		removed += n
+		if err != nil || n == 0 {
+			break
+		}
+	}
+	if removed > 0 {
+		log.Printf("Removed %d deleted attachments from storage", removed)
+	}
+}
+
+func (j *Janitor) sweep(ctx context.Context) (int, error) {
+	attachments, err := j.attachmentRepo.GetDeletedAttachments(ctx, sweepBatch)
+	if err != nil {
+		return 0, err
+	}
+	for i, attachment := range attachments {
+		keys := []string{attachment.StorageKey}
+		for _, variant := range attachment.Variants {
+			keys = append(keys, variant.StorageKey)
+		}
+		for _, key := range keys {
+			if err := j.store.Delete(ctx, key); err != nil {
+				log.Printf("Error removing file of attachment ID %d: %v", attachment.ID, err)
+				return i, err
+			}
+		}
+		if err := j.attachmentRepo.PurgeAttachment(ctx, attachment.ID); err != nil {
+			return i, err
+		}
+	}
+	return len(attachments), nil
+}
@@ -0,0 +1,244 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+var errMalformedImage = errors.New("malformed image")
+
+// CanStripMetadata reports whether StripMetadata understands the content
+// type.
+func CanStripMetadata(contentType string) bool {
+	return contentType == "image/jpeg" || contentType == "image/png" || contentType == "image/webp"
+}
+
+// StripMetadata removes EXIF, XMP, IPTC and comments from a JPEG, PNG or
+// WebP image without re-encoding it, so location and camera details are not
+// published with the file. The EXIF orientation of a JPEG is kept, as
+// viewers need it to show the image the right way up.
+func StripMetadata(contentType string, data []byte) ([]byte, error) {
+	switch contentType {
+	case "image/jpeg":
+		return stripJPEG(data)
+	case "image/png":
+		return stripPNG(data)
+	case "image/webp":
+		return stripWebP(data)
+	}
+	return data, nil
+}
+
+// Orientation returns the EXIF orientation (1 to 8) of a JPEG image, or 1
+// when it has none.
+func Orientation(data []byte) int {
+	orientation := 1
+	_ = walkJPEG(data, func(marker byte, segment []byte) bool {
+		if marker == 0xE1 && bytes.HasPrefix(segment[4:], exifHeader) {
+			orientation = exifOrientation(segment[4+len(exifHeader):])
+			return false
+		}
+		return true
+	})
+	return orientation
+}
+
+var exifHeader = []byte("Exif\x00\x00")
+
+func stripJPEG(data []byte) ([]byte, error) {
+	out := make([]byte, 0, len(data))
+	out = append(out, 0xFF, 0xD8)
+	err := walkJPEG(data, func(marker byte, segment []byte) bool {
+		switch {
+		case marker == 0xE1:
+			if bytes.HasPrefix(segment[4:], exifHeader) {
+				if orientation := exifOrientation(segment[4+len(exifHeader):]); orientation != 1 {
+					out = append(out, orientationSegment(orientation)...)
+				}
+			}
+		case marker == 0xFE, marker == 0xED:
+			// Comments and Photoshop/IPTC data.
+		case marker >= 0xE3 && marker <= 0xEF && marker != 0xEE:
+			// Other application segments; APP0 (JFIF), APP2 (ICC profile)
+			// and APP14 (Adobe color transform) affect how the image looks.
+		default:
+			out = append(out, segment...)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// walkJPEG calls fn with every segment of a JPEG image up to the start of
+// the compressed data, which is passed on as one final segment. fn returns
+// false to stop early.
+func walkJPEG(data []byte, fn func(marker byte, segment []byte) bool) error {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return errMalformedImage
+	}
+	for i := 2; i < len(data); {
+		if data[i] != 0xFF {
+			return errMalformedImage
+		}
+		for i+1 < len(data) && data[i+1] == 0xFF {
+			i++
+		}
+		if i+1 >= len(data) {
+			return errMalformedImage
+		}
+		marker := data[i+1]
+		switch {
+		case marker == 0xDA || marker == 0xD9:
+			// Start of scan or end of image: the rest is image data.
+			fn(marker, data[i:])
+			return nil
+		case marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7):
+			if !fn(marker, data[i:i+2]) {
+				return nil
+			}
+			i += 2
+			continue
+		}
+		if i+4 > len(data) {
+			return errMalformedImage
+		}
+		end := i + 2 + int(binary.BigEndian.Uint16(data[i+2:i+4]))
+		if end > len(data) || end < i+4 {
+			return errMalformedImage
+		}
+		if !fn(marker, data[i:end]) {
+			return nil
+		}
+		i = end
+	}
+	return errMalformedImage
+}
+
+// exifOrientation reads the orientation tag from the TIFF structure of an
+// EXIF segment.
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	ifd := int(order.Uint32(tiff[4:8]))
+	if ifd+2 > len(tiff) || ifd < 8 {
+		return 1
+	}
+	entries := int(order.Uint16(tiff[ifd : ifd+2]))
+	for n := 0; n < entries; n++ {
+		entry := ifd + 2 + n*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:entry+2]) == 0x0112 && order.Uint16(tiff[entry+2:entry+4]) == 3 {
+			if orientation := int(order.Uint16(tiff[entry+8 : entry+10])); orientation >= 1 && orientation <= 8 {
+				return orientation
+			}
+			return 1
+		}
+	}
+	return 1
+}
+
+// orientationSegment builds an APP1 segment whose EXIF data holds nothing
+// but the orientation.
+func orientationSegment(orientation int) []byte {
+	payload := append([]byte{}, exifHeader...)
+	payload = append(payload, 'M', 'M', 0x00, 0x2A, 0x00, 0x00, 0x00, 0x08) // TIFF header, IFD0 at 8
+	payload = append(payload, 0x00, 0x01)                                   // one entry
+	payload = append(payload, 0x01, 0x12, 0x00, 0x03, 0x00, 0x00, 0x00, 0x01, 0x00, byte(orientation), 0x00, 0x00)
+	payload = append(payload, 0x00, 0x00, 0x00, 0x00) // no next IFD
+
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	return append(segment, payload...)
+}
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// pngMetadataChunks are the chunks holding text, EXIF and timestamps.
+var pngMetadataChunks = map[string]bool{"eXIf": true, "tEXt": true, "zTXt": true, "iTXt": true, "tIME": true}
+
+func stripPNG(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, errMalformedImage
+	}
+	out := make([]byte, 0, len(data))
+	out = append(out, pngSignature...)
+	for i := len(pngSignature); i < len(data); {
+		if i+8 > len(data) {
+			return nil, errMalformedImage
+		}
+		length := int(binary.BigEndian.Uint32(data[i : i+4]))
+		end := i + 12 + length
+		if length < 0 || end > len(data) || end < i {
+			return nil, errMalformedImage
+		}
+		if !pngMetadataChunks[string(data[i+4:i+8])] {
+			out = append(out, data[i:end]...)
+		}
+		if string(data[i+4:i+8]) == "IEND" {
+			return out, nil
+		}
+		i = end
+	}
+	return nil, errMalformedImage
+}
+
+// webpMetadataChunks are the RIFF chunks holding EXIF and XMP data, with
+// the VP8X flag announcing each.
+var webpMetadataChunks = map[string]byte{"EXIF": 0x08, "XMP ": 0x04}
+
+func stripWebP(data []byte) ([]byte, error) {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, errMalformedImage
+	}
+	// Anything after the RIFF container is not part of the image.
+	riff := 8 + int(binary.LittleEndian.Uint32(data[4:8]))
+	if riff > len(data) || riff < 12 {
+		return nil, errMalformedImage
+	}
+	data = data[:riff]
+
+	out := make([]byte, 12, len(data))
+	copy(out, data[:12])
+	extended := -1
+	for i := 12; i < len(data); {
+		if i+8 > len(data) {
+			return nil, errMalformedImage
+		}
+		fourCC := string(data[i : i+4])
+		size := int(binary.LittleEndian.Uint32(data[i+4 : i+8]))
+		end := i + 8 + size + size%2
+		if size < 0 || end > len(data) || end < i {
+			return nil, errMalformedImage
+		}
+		if _, metadata := webpMetadataChunks[fourCC]; !metadata {
+			if fourCC == "VP8X" && size > 0 {
+				extended = len(out) + 8
+			}
+			out = append(out, data[i:end]...)
+		}
+		i = end
+	}
+	if extended >= 0 {
+		for _, flag := range webpMetadataChunks {
+			out[extended] &^= flag
+		}
+	}
+	binary.LittleEndian.PutUint32(out[4:8], uint32(len(out)-8))
+	return out, nil
+}
@@ -0,0 +1,91 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+
+	"github.com/HugoSmits86/nativewebp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/image/webp"
+)
+
+func TestStripMetadataKeepsOrientation(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 40, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 40; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x * 6), G: uint8(y * 12), B: 100, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, img, nil))
+	encoded := buf.Bytes()
+
+	// An EXIF segment with the orientation and a comment with something
+	// private in it.
+	comment := []byte("taken at home")
+	data := append([]byte{0xFF, 0xD8}, orientationSegment(6)...)
+	data = append(data, 0xFF, 0xFE, 0, byte(len(comment)+2))
+	data = append(data, comment...)
+	data = append(data, encoded[2:]...)
+
+	stripped, err := StripMetadata("image/jpeg", data)
+	require.NoError(t, err)
+	assert.False(t, bytes.Contains(stripped, comment))
+	assert.Equal(t, 6, Orientation(stripped))
+
+	decoded, err := jpeg.Decode(bytes.NewReader(stripped))
+	require.NoError(t, err)
+	oriented := orient(toRGBA(decoded), Orientation(stripped))
+	assert.Equal(t, image.Rect(0, 0, 20, 40), oriented.Bounds())
+
+	_, err = StripMetadata("image/jpeg", data[:len(data)/8])
+	assert.Error(t, err)
+}
+
+func TestStripMetadataFromWebP(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 8, 4))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 8; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x * 30), G: uint8(y * 60), B: 100, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, nativewebp.Encode(&buf, img, nil))
+	encoded := buf.Bytes()
+
+	// The extended format, announcing and carrying an EXIF chunk with
+	// something private in it.
+	chunk := func(fourCC string, payload []byte) []byte {
+		out := append([]byte(fourCC), 0, 0, 0, 0)
+		binary.LittleEndian.PutUint32(out[4:], uint32(len(payload)))
+		out = append(out, payload...)
+		if len(payload)%2 == 1 {
+			out = append(out, 0)
+		}
+		return out
+	}
+	secret := []byte("taken at home")
+	body := []byte("WEBP")
+	body = append(body, chunk("VP8X", []byte{0x08, 0, 0, 0, 7, 0, 0, 3, 0, 0})...)
+	body = append(body, encoded[12:]...)
+	body = append(body, chunk("EXIF", secret)...)
+	data := append(chunk("RIFF", body)[:8], body...)
+
+	stripped, err := StripMetadata("image/webp", data)
+	require.NoError(t, err)
+	assert.False(t, bytes.Contains(stripped, secret))
+	assert.Zero(t, stripped[20]&0x08, "the VP8X flag no longer announces EXIF")
+	assert.Equal(t, uint32(len(stripped)-8), binary.LittleEndian.Uint32(stripped[4:8]))
+
+	decoded, err := webp.Decode(bytes.NewReader(stripped))
+	require.NoError(t, err)
+	assert.Equal(t, img.Bounds(), decoded.Bounds())
+
+	_, err = StripMetadata("image/webp", data[:len(data)-4])
+	assert.Error(t, err)
+}
@@ -0,0 +1,234 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"log"
+	"time"
+
+	"github.com/HugoSmits86/nativewebp"
+	_ "golang.org/x/image/webp"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/storage"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/repository"
+)
+
+// ProcessInterval is how often the processor looks for images it was not
+// handed directly, e.g. ones uploaded inside a transaction that had not
+// committed yet, imported ones, or ones interrupted by a restart.
+const ProcessInterval = time.Minute
+
+// maxPixels bounds the images the processor decodes, so a small file
+// cannot claim gigabytes of memory.
+const maxPixels = 50_000_000
+
+const (
+	blurhashWidth = 32
+	jpegQuality   = 82
+)
+
+// Variant is a resized copy generated for every image wider than Width.
+type Variant struct {
+	Name  string
+	Width int
+}
+
+// Variants are the sizes images are offered in, from small to large. Each
+// is stored as JPEG, or PNG for images with transparency, and as lossless
+// WebP for clients that ask for FormatWebP.
+var Variants = []Variant{
+	{Name: "thumbnail", Width: 320},
+	{Name: "medium", Width: 960},
+	{Name: "large", Width: 1920},
+}
+
+// FormatWebP selects the WebP copy of a variant.
+const FormatWebP = "webp"
+
+// LookupVariant reports whether name is one of the Variants.
+func LookupVariant(name string) bool {
+	for _, variant := range Variants {
+		if variant.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Processable reports whether images of the content type can be decoded
+// and so get dimensions, a blurhash and variants.
+func Processable(contentType string) bool {
+	return contentType == "image/jpeg" || contentType == "image/png" || contentType == "image/gif" || contentType == "image/webp"
+}
+
+// Processor generates the variants, dimensions and blurhash of uploaded
+// images, one at a time.
+type Processor struct {
+	attachmentRepo repository.AttachmentRepository
+	store          storage.Storage
+	queue          chan uint
+}
+
+func NewProcessor(attachmentRepo repository.AttachmentRepository, store storage.Storage) *Processor {
+	return &Processor{
+		attachmentRepo: attachmentRepo,
+		store:          store,
+		queue:          make(chan uint, 64),
+	}
+}
+
+// Enqueue schedules an image for processing. It never blocks the caller.
+func (p *Processor) Enqueue(attachmentID uint) {
+	select {
+	case p.queue <- attachmentID:
+	default:
+		// The next sweep picks it up.
+	}
+}
+
+// Run processes queued images as they come and sweeps for unprocessed ones
+// right away and then every ProcessInterval, until ctx is done.
+func (p *Processor) Run(ctx context.Context) {
+	ticker := time.NewTicker(ProcessInterval)
+	defer ticker.Stop()
+
+	p.sweep(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case attachmentID := <-p.queue:
+			p.process(ctx, attachmentID)
+		case <-ticker.C:
+			p.sweep(ctx)
+		}
+	}
+}
+
+func (p *Processor) sweep(ctx context.Context) {
+	attachments, err := p.attachmentRepo.GetUnprocessedAttachments(ctx, 100)
+	if err != nil {
+		return
+	}
+	for _, attachment := range attachments {
+		p.process(ctx, attachment.ID)
+	}
+}
+
+func (p *Processor) process(ctx context.Context, attachmentID uint) {
+	attachment, err := p.attachmentRepo.StartProcessing(ctx, attachmentID)
+	if err != nil {
+		// Not visible yet, deleted or done already.
+		return
+	}
+
+	variants, err := p.render(ctx, attachment)
+	if err != nil {
+		p.removeVariants(ctx, variants)
+		log.Printf("Processing attachment %d failed: %v", attachment.ID, err)
+		_ = p.attachmentRepo.FailProcessing(ctx, attachment, err)
+		return
+	}
+	if err := p.attachmentRepo.CompleteProcessing(ctx, attachment, variants); err != nil {
+		p.removeVariants(ctx, variants)
+	}
+}
+
+// render decodes the image, records its dimensions and blurhash on
+// attachment and stores its variants. On error it returns the variants it
+// already stored, so they can be removed.
+func (p *Processor) render(ctx context.Context, attachment *model.Attachment) ([]model.AttachmentVariant, error) {
+	body, err := p.store.Get(ctx, attachment.StorageKey)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(io.LimitReader(body, attachment.Size+1))
+	body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decode image: %w", err)
+	}
+	if config.Width*config.Height > maxPixels {
+		return nil, fmt.Errorf("image of %dx%d pixels is too large to process", config.Width, config.Height)
+	}
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decode image: %w", err)
+	}
+
+	img := toRGBA(decoded)
+	if attachment.ContentType == "image/jpeg" {
+		img = orient(img, Orientation(data))
+	}
+	attachment.Width, attachment.Height = img.Bounds().Dx(), img.Bounds().Dy()
+	attachment.Blurhash = Blurhash(resize(img, blurhashWidth))
+
+	var variants []model.AttachmentVariant
+	for _, spec := range Variants {
+		if spec.Width >= attachment.Width {
+			break
+		}
+		resized := resize(img, spec.Width)
+		for _, format := range []string{"", FormatWebP} {
+			variant, err := p.storeVariant(ctx, attachment, spec, resized, format)
+			if err != nil {
+				return variants, fmt.Errorf("variant %s: %w", spec.Name, err)
+			}
+			variants = append(variants, *variant)
+		}
+	}
+	return variants, nil
+}
+
+// storeVariant encodes img in format, or as JPEG or PNG when format is
+// empty, and stores it as the spec variant of attachment.
+func (p *Processor) storeVariant(ctx context.Context, attachment *model.Attachment, spec Variant, img *image.RGBA, format string) (*model.AttachmentVariant, error) {
+	var buf bytes.Buffer
+	contentType, key := "image/jpeg", attachment.StorageKey+"-"+spec.Name
+	switch {
+	case format == FormatWebP:
+		contentType, key = "image/webp", key+"."+FormatWebP
+		if err := nativewebp.Encode(&buf, img, nil); err != nil {
+			return nil, err
+		}
+	case img.Opaque():
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality}); err != nil {
+			return nil, err
+		}
+	default:
+		contentType = "image/png"
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, err
+		}
+	}
+
+	variant := &model.AttachmentVariant{
+		Name:        spec.Name,
+		ContentType: contentType,
+		Width:       img.Bounds().Dx(),
+		Height:      img.Bounds().Dy(),
+		Size:        int64(buf.Len()),
+		StorageKey:  key,
+	}
+	if err := p.store.Put(ctx, variant.StorageKey, &buf, variant.Size, contentType); err != nil {
+		return nil, err
+	}
+	return variant, nil
+}
+
+func (p *Processor) removeVariants(ctx context.Context, variants []model.AttachmentVariant) {
+	for _, variant := range variants {
+		_ = p.store.Delete(ctx, variant.StorageKey)
+	}
+}
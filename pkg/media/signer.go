@@ -0,0 +1,50 @@
+package media
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Signer creates and checks download URLs that grant access to a single
+// attachment until they expire, without an API token.
+type Signer struct {
+	key []byte
+	ttl time.Duration
+}
+
+// NewSigner derives the signing key from secret, so URLs stay valid across
+// restarts but cannot be forged without it.
+func NewSigner(secret string, ttl time.Duration) *Signer {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("attachment-download"))
+	return &Signer{key: mac.Sum(nil), ttl: ttl}
+}
+
+// URL returns the signed download path of the attachment, valid for the
+// signer's TTL from now.
+func (s *Signer) URL(attachmentID uint, now time.Time) string {
+	expires := now.Add(s.ttl).Unix()
+	return fmt.Sprintf("/api/v1/attachments/%d/download?expires=%d&signature=%s",
+		attachmentID, expires, s.signature(attachmentID, expires))
+}
+
+// Verify reports whether signature was issued for the attachment and has
+// not expired at now.
+func (s *Signer) Verify(attachmentID uint, expires, signature string, now time.Time) bool {
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || now.Unix() > expiresAt {
+		return false
+	}
+	expected := s.signature(attachmentID, expiresAt)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+func (s *Signer) signature(attachmentID uint, expires int64) string {
+	mac := hmac.New(sha256.New, s.key)
+	fmt.Fprintf(mac, "%d|%d", attachmentID, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,66 @@
+package media
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"unicode"
+)
+
+// SniffLength is how many leading bytes of a file Sniff looks at.
+const SniffLength = 512
+
+// allowedTypes are the content types attachments may have. The type is
+// always sniffed from the content; what the client claims is ignored.
+var allowedTypes = map[string]bool{
+	"image/png":       true,
+	"image/jpeg":      true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"application/pdf": true,
+	"text/plain":      true,
+}
+
+// Sniff detects the content type of a file from its first bytes and
+// reports whether it may be attached.
+func Sniff(head []byte) (string, bool) {
+	detected := http.DetectContentType(head)
+	mediaType, _, err := mime.ParseMediaType(detected)
+	if err != nil {
+		return detected, false
+	}
+	return mediaType, allowedTypes[mediaType]
+}
+
+// NewKey returns a fresh, unguessable storage key for an attachment.
+func NewKey() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "attachments/" + hex.EncodeToString(buf), nil
+}
+
+// CleanFileName reduces a client-supplied file name to its base name
+// without control characters, so it is safe to show and to send back in a
+// Content-Disposition header.
+func CleanFileName(name string) string {
+	name = path.Base(strings.ReplaceAll(name, `\`, "/"))
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+	if name == "" || name == "." || name == "/" {
+		return "file"
+	}
+	if runes := []rune(name); len(runes) > 255 {
+		name = string(runes[:255])
+	}
+	return name
+}
@@ -0,0 +1,27 @@
+package media
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSniffAcceptsWebP(t *testing.T) {
+	webp := []byte("RIFF\x24\x00\x00\x00WEBPVP8 \x18\x00\x00\x00")
+	contentType, allowed := Sniff(webp)
+	assert.Equal(t, "image/webp", contentType)
+	assert.True(t, allowed)
+}
+
+// Every image that can be attached must be decodable and have its
+// metadata stripped, or it would be served without variants or dimensions
+// or with the uploader's location.
+func TestAllowedImagesAreProcessable(t *testing.T) {
+	for contentType := range allowedTypes {
+		if strings.HasPrefix(contentType, "image/") {
+			assert.True(t, Processable(contentType), contentType)
+			assert.True(t, CanStripMetadata(contentType) || contentType == "image/gif", contentType)
+		}
+	}
+}
@@ -2,17 +2,21 @@ package middleware
 
 import (
 	"log"
+	"sort"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 
 	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/policy"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/slug"
 	"github.com/adityadeshlahre/multi-tenant-backend-app/repository"
 )
 
 const (
 	ArticleKey        = "article"
 	UserPermissionKey = "userPermission"
+	ArticleActionsKey = "articleActions"
 
 	PermissionNone    = "none"
 	PermissionView    = "view"
@@ -21,7 +25,15 @@ const (
 	PermissionOwner   = "owner"
 )
 
-func ArticleContext(articleRepo repository.ArticleRepository) gin.HandlerFunc {
+// grantActions maps the permission of a collaborator grant to the article
+// actions it adds on top of the policy.
+var grantActions = map[string][]string{
+	PermissionView:    {policy.ActionView},
+	PermissionComment: {policy.ActionView, policy.ActionComment},
+	PermissionEdit:    {policy.ActionView, policy.ActionComment, policy.ActionEdit},
+}
+
+func ArticleContext(articleRepo repository.ArticleRepository, collaboratorRepo repository.CollaboratorRepository, teamRepo repository.TeamRepository) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		articleIDParam := c.Param("articleId")
 		if articleIDParam == "" {
@@ -34,28 +46,50 @@ func ArticleContext(articleRepo repository.ArticleRepository) gin.HandlerFunc {
 			return
 		}
 
-		articleID, err := strconv.ParseUint(articleIDParam, 10, 32)
-		if err != nil {
-			log.Printf("Invalid article ID format: %v", err)
-			c.AbortWithStatusJSON(400, gin.H{"error": "Invalid article ID format"})
-			return
-		}
-
-		article, err := articleRepo.GetArticleByID(c.Request.Context(), uint(articleID))
-		if err != nil {
-			log.Printf("Error fetching article: %v", err)
-			c.AbortWithStatusJSON(404, gin.H{"error": "Article not found"})
+		org, exists := c.Get("organization")
+		if !exists {
+			log.Println("Organization not found in context")
+			c.AbortWithStatusJSON(500, gin.H{"error": "Organization not found in context"})
 			return
 		}
+		orgModel := org.(*model.Organization)
 
-		org, exists := c.Get("organization")
-		if exists {
-			orgModel := org.(*model.Organization)
-			if article.OrganizationID != orgModel.ID {
-				log.Printf("Article %d does not belong to organization %d", article.ID, orgModel.ID)
-				c.AbortWithStatusJSON(403, gin.H{"error": "Access denied: Article not in your organization"})
+		var article *model.Article
+		if articleID, err := strconv.ParseUint(articleIDParam, 10, 32); err == nil {
+			article, err = articleRepo.GetArticleByID(c.Request.Context(), uint(articleID))
+			if err != nil {
+				log.Printf("Error fetching article: %v", err)
+				c.AbortWithStatusJSON(404, gin.H{"error": "Article not found"})
+				return
+			}
+		} else {
+			if !slug.Valid(articleIDParam) {
+				log.Printf("Invalid article ID format: %v", err)
+				c.AbortWithStatusJSON(400, gin.H{"error": "Invalid article ID format"})
 				return
 			}
+
+			article, err = articleRepo.GetArticleBySlug(c.Request.Context(), orgModel.ID, articleIDParam)
+			if err != nil {
+				article, err = articleRepo.GetArticleByOldSlug(c.Request.Context(), orgModel.ID, articleIDParam)
+				if err != nil {
+					c.AbortWithStatusJSON(404, gin.H{"error": "Article not found"})
+					return
+				}
+				param := "articleId"
+				if c.Param(param) == "" {
+					param = "id"
+				}
+				if RedirectToCurrentSlug(c, param, article.Slug) {
+					return
+				}
+			}
+		}
+
+		if article.OrganizationID != orgModel.ID {
+			log.Printf("Article %d does not belong to organization %d", article.ID, orgModel.ID)
+			c.AbortWithStatusJSON(403, gin.H{"error": "Access denied: Article not in your organization"})
+			return
 		}
 
 		userID, exists := c.Get("userID")
@@ -65,14 +99,17 @@ func ArticleContext(articleRepo repository.ArticleRepository) gin.HandlerFunc {
 			return
 		}
 
-		userRole, exists := c.Get("userRole")
-		if !exists {
-			userRole = "member"
+		actions, err := ResolveArticleActions(c, collaboratorRepo, teamRepo, article)
+		if err != nil {
+			log.Printf("Error resolving article permissions: %v", err)
+			c.AbortWithStatusJSON(500, gin.H{"error": "Failed to resolve article permissions"})
+			return
 		}
 
-		permission := determineUserPermission(article, userID.(uint), userRole.(string))
+		permission := permissionLevel(actions)
 
 		c.Set(ArticleKey, article)
+		c.Set(ArticleActionsKey, actions)
 		c.Set(UserPermissionKey, permission)
 
 		log.Printf("User %d has %s permission for article %d", userID.(uint), permission, article.ID)
@@ -81,70 +118,187 @@ func ArticleContext(articleRepo repository.ArticleRepository) gin.HandlerFunc {
 	}
 }
 
-func determineUserPermission(article *model.Article, userID uint, userRole string) string {
-	if article.UserID == userID {
-		return PermissionOwner
+// ResolveArticleActions returns what the caller may do with article: the
+// actions granted by the organization policy merged with any collaborator
+// grants the caller holds on it. Members of the team that owns an article can
+// edit it.
+func ResolveArticleActions(c *gin.Context, collaboratorRepo repository.CollaboratorRepository, teamRepo repository.TeamRepository, article *model.Article) ([]string, error) {
+	sub := CurrentSubject(c)
+	grants, err := collaboratorRepo.GetGrantsForUser(c.Request.Context(), article.ID, sub.UserID, memberRole(c))
+	if err != nil {
+		return nil, err
 	}
+	access := newArticleAccess(grants)
 
-	switch userRole {
-	case "admin":
-		return PermissionEdit
-	case "editor":
-		if article.Status == "published" {
-			return PermissionComment
-		}
-		return PermissionView
-	case "member":
-		if article.Status == "published" {
-			return PermissionComment
+	if article.TeamID != nil {
+		isMember, err := teamRepo.IsTeamMember(c.Request.Context(), *article.TeamID, sub.UserID)
+		if err != nil {
+			return nil, err
 		}
+		access.teams[*article.TeamID] = isMember
+	}
+	return access.Actions(c, article), nil
+}
+
+// ArticleAccess is what the caller holds on an organization's articles
+// beyond the policy: collaborator grants and membership in the teams that
+// own articles. It is loaded once for a listing, so the actions on each
+// article can be resolved without querying per article.
+type ArticleAccess struct {
+	grants map[uint][]string
+	teams  map[uint]bool
+}
+
+// LoadArticleAccess loads the caller's grants and teams in the
+// organization.
+func LoadArticleAccess(c *gin.Context, collaboratorRepo repository.CollaboratorRepository, teamRepo repository.TeamRepository, orgID uint) (*ArticleAccess, error) {
+	sub := CurrentSubject(c)
+	grants, err := collaboratorRepo.GetOrganizationGrantsForUser(c.Request.Context(), orgID, sub.UserID, memberRole(c))
+	if err != nil {
+		return nil, err
+	}
+	access := newArticleAccess(grants)
+
+	teamIDs, err := teamRepo.GetTeamIDsForUser(c.Request.Context(), orgID, sub.UserID)
+	if err != nil {
+		return nil, err
+	}
+	for _, teamID := range teamIDs {
+		access.teams[teamID] = true
+	}
+	return access, nil
+}
+
+func newArticleAccess(grants []model.ArticleCollaborator) *ArticleAccess {
+	access := &ArticleAccess{grants: make(map[uint][]string), teams: make(map[uint]bool)}
+	for _, grant := range grants {
+		access.grants[grant.ArticleID] = append(access.grants[grant.ArticleID], grant.Permission)
+	}
+	return access
+}
+
+// Actions returns what the caller may do with article, see
+// ResolveArticleActions.
+func (a *ArticleAccess) Actions(c *gin.Context, article *model.Article) []string {
+	actions := GetPolicyFromContext(c).Allowed(CurrentSubject(c), ArticleResource(article))
+	for _, permission := range a.grants[article.ID] {
+		actions = mergeActions(actions, grantActions[permission])
+	}
+	if article.TeamID != nil && a.teams[*article.TeamID] {
+		actions = mergeActions(actions, grantActions[PermissionEdit])
+	}
+	return actions
+}
+
+// memberRole is the caller's role in the organization, or "" if they are
+// not a member.
+func memberRole(c *gin.Context) string {
+	orgRole, _ := c.Get(OrgRoleKey)
+	role, _ := orgRole.(string)
+	return role
+}
+
+// ArticleResource describes an article to the policy engine.
+func ArticleResource(article *model.Article) policy.Resource {
+	return policy.Resource{
+		Type:    policy.ResourceArticle,
+		OwnerID: article.UserID,
+		Attributes: map[string]string{
+			"status":     article.Status,
+			"visibility": article.Visibility,
+		},
+	}
+}
+
+// CommentResource describes a comment to the policy engine.
+func CommentResource(comment *model.Comment) policy.Resource {
+	return policy.Resource{
+		Type:    policy.ResourceComment,
+		OwnerID: comment.AuthorID,
+	}
+}
+
+// permissionLevel summarizes the allowed actions as the coarse permission
+// level clients already understand.
+func permissionLevel(actions []string) string {
+	switch {
+	case hasAction(actions, policy.ActionDelete):
+		return PermissionOwner
+	case hasAction(actions, policy.ActionEdit):
+		return PermissionEdit
+	case hasAction(actions, policy.ActionComment):
+		return PermissionComment
+	case hasAction(actions, policy.ActionView):
 		return PermissionView
 	default:
-		if article.Status == "published" {
-			return PermissionView
-		}
 		return PermissionNone
 	}
 }
 
-func CanViewArticle(c *gin.Context) bool {
-	permission, exists := c.Get(UserPermissionKey)
-	if !exists {
-		return false
+func mergeActions(actions, extra []string) []string {
+	for _, action := range extra {
+		if !hasAction(actions, action) {
+			actions = append(actions, action)
+		}
 	}
+	sort.Strings(actions)
+	return actions
+}
 
-	perm := permission.(string)
-	return perm == PermissionView || perm == PermissionComment ||
-		perm == PermissionEdit || perm == PermissionOwner
+func hasAction(actions []string, action string) bool {
+	for _, a := range actions {
+		if a == action {
+			return true
+		}
+	}
+	return false
 }
 
-func CanCommentOnArticle(c *gin.Context) bool {
-	permission, exists := c.Get(UserPermissionKey)
+// CanOnArticle reports whether the caller may perform action on the article
+// loaded by ArticleContext.
+func CanOnArticle(c *gin.Context, action string) bool {
+	actions, exists := GetArticleActionsFromContext(c)
 	if !exists {
 		return false
 	}
+	return hasAction(actions, action)
+}
 
-	perm := permission.(string)
-	return perm == PermissionComment || perm == PermissionEdit || perm == PermissionOwner
+func CanViewArticle(c *gin.Context) bool {
+	return CanOnArticle(c, policy.ActionView)
+}
+
+func CanCommentOnArticle(c *gin.Context) bool {
+	return CanOnArticle(c, policy.ActionComment)
 }
 
 func CanEditArticle(c *gin.Context) bool {
-	permission, exists := c.Get(UserPermissionKey)
-	if !exists {
-		return false
-	}
+	return CanOnArticle(c, policy.ActionEdit)
+}
 
-	perm := permission.(string)
-	return perm == PermissionEdit || perm == PermissionOwner
+func CanDeleteArticle(c *gin.Context) bool {
+	return CanOnArticle(c, policy.ActionDelete)
+}
+
+func CanModerateComments(c *gin.Context) bool {
+	return CanOnArticle(c, policy.ActionModerate)
+}
+
+func CanShareArticle(c *gin.Context) bool {
+	return CanOnArticle(c, policy.ActionShare)
+}
+
+// CanOnComment reports whether the caller may perform action on comment.
+func CanOnComment(c *gin.Context, action string, comment *model.Comment) bool {
+	return GetPolicyFromContext(c).Can(CurrentSubject(c), action, CommentResource(comment))
 }
 
 func IsArticleOwner(c *gin.Context) bool {
-	permission, exists := c.Get(UserPermissionKey)
+	article, exists := GetArticleFromContext(c)
 	if !exists {
 		return false
 	}
-
-	return permission.(string) == PermissionOwner
+	return article.UserID == CurrentSubject(c).UserID
 }
 
 func GetArticleFromContext(c *gin.Context) (*model.Article, bool) {
@@ -155,6 +309,14 @@ func GetArticleFromContext(c *gin.Context) (*model.Article, bool) {
 	return article.(*model.Article), true
 }
 
+func GetArticleActionsFromContext(c *gin.Context) ([]string, bool) {
+	actions, exists := c.Get(ArticleActionsKey)
+	if !exists {
+		return nil, false
+	}
+	return actions.([]string), true
+}
+
 func GetUserPermissionFromContext(c *gin.Context) (string, bool) {
 	permission, exists := c.Get(UserPermissionKey)
 	if !exists {
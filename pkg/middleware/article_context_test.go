@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/policy"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/repository"
+)
+
+// stubGrants holds the grants of the caller and counts the queries made
+// for them.
+type stubGrants struct {
+	repository.CollaboratorRepository
+	grants  []model.ArticleCollaborator
+	queries int
+}
+
+func (s *stubGrants) GetGrantsForUser(_ context.Context, articleID, _ uint, _ string) ([]model.ArticleCollaborator, error) {
+	s.queries++
+	var grants []model.ArticleCollaborator
+	for _, grant := range s.grants {
+		if grant.ArticleID == articleID {
+			grants = append(grants, grant)
+		}
+	}
+	return grants, nil
+}
+
+func (s *stubGrants) GetOrganizationGrantsForUser(context.Context, uint, uint, string) ([]model.ArticleCollaborator, error) {
+	s.queries++
+	return s.grants, nil
+}
+
+// stubTeams makes the caller a member of the teams in teamIDs.
+type stubTeams struct {
+	repository.TeamRepository
+	teamIDs []uint
+	queries int
+}
+
+func (s *stubTeams) GetTeamIDsForUser(context.Context, uint, uint) ([]uint, error) {
+	s.queries++
+	return s.teamIDs, nil
+}
+
+func (s *stubTeams) IsTeamMember(_ context.Context, teamID, _ uint) (bool, error) {
+	s.queries++
+	for _, id := range s.teamIDs {
+		if id == teamID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func TestArticleAccessMatchesResolveArticleActions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/", nil)
+	c.Set("userID", uint(5))
+	c.Set(OrgRoleKey, model.RoleMember)
+
+	teamID, otherTeamID := uint(3), uint(4)
+	draft := func(id uint, teamID *uint) *model.Article {
+		return &model.Article{Model: gorm.Model{ID: id}, UserID: 9, Status: "draft", Visibility: model.VisibilityPrivate, TeamID: teamID}
+	}
+	articles := []*model.Article{draft(1, nil), draft(2, &teamID), draft(3, &otherTeamID), draft(4, nil)}
+	grants := &stubGrants{grants: []model.ArticleCollaborator{{ArticleID: 1, Permission: PermissionComment}}}
+	teams := &stubTeams{teamIDs: []uint{teamID}}
+
+	access, err := LoadArticleAccess(c, grants, teams, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 2, grants.queries+teams.queries, "one query each for the whole organization")
+
+	expected := [][]string{
+		{policy.ActionComment, policy.ActionView},
+		{policy.ActionComment, policy.ActionEdit, policy.ActionView},
+		{policy.ActionView},
+		{policy.ActionView},
+	}
+	for i, article := range articles {
+		actions := access.Actions(c, article)
+		assert.ElementsMatch(t, expected[i], actions, "article %d", article.ID)
+
+		resolved, err := ResolveArticleActions(c, grants, teams, article)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, resolved, actions, "article %d", article.ID)
+	}
+}
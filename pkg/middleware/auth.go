@@ -66,6 +66,25 @@ func AuthMiddleware(userRepo repository.UserRepository) gin.HandlerFunc {
 	}
 }
 
+// RequireGlobalRole only lets through users whose platform-wide role is one
+// of roles. It must run after AuthMiddleware.
+func RequireGlobalRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userRole, exists := c.Get("userRole")
+		if exists {
+			for _, role := range roles {
+				if userRole.(string) == role {
+					c.Next()
+					return
+				}
+			}
+		}
+
+		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient privileges"})
+		c.Abort()
+	}
+}
+
 func GenerateJWT(user *model.User) (string, error) {
 	claims := &Claims{
 		UserID: user.ID,
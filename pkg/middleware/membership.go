@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/policy"
+)
+
+// RequireMembership turns away callers who are not members of the
+// organization set by OrganizationContext, unless the organization's policy
+// has rules for any role on the resource the route acts on. resources maps
+// route prefixes to that resource type, and the longest matching prefix
+// wins. Non-members who get through are then held to the policy like
+// everyone else; with the default policy that opens the article routes, as
+// published articles and one's own articles and comments are open to any
+// role. It runs before APIQuota, so outsiders cannot use up the
+// organization's allowance on routes that are closed to them.
+func RequireMembership(store *policy.Store, resources map[string]string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if EffectiveRole(c) != "" {
+			c.Next()
+			return
+		}
+
+		orgID, exists := c.Get(OrganizationKey)
+		if !exists {
+			log.Println("Organization not found in context")
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Organization not found in context"})
+			return
+		}
+
+		route, resource := c.FullPath(), ""
+		matched := -1
+		for prefix, resourceType := range resources {
+			if strings.HasPrefix(route, prefix) && len(prefix) > matched {
+				resource, matched = resourceType, len(prefix)
+			}
+		}
+		if resource != "" {
+			p, err := store.ForOrganization(c.Request.Context(), orgID.(uint))
+			if err != nil {
+				log.Printf("Error loading policy for organization %d: %v", orgID.(uint), err)
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to load organization policy"})
+				return
+			}
+			if len(p.RulesFor("", resource)) > 0 {
+				c.Next()
+				return
+			}
+		}
+
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "You are not a member of this organization"})
+	}
+}
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/policy"
+)
+
+// fixedRules gives every organization the same policy overrides.
+type fixedRules []model.PolicyRule
+
+func (r fixedRules) GetPolicyRules(context.Context, uint) ([]model.PolicyRule, error) {
+	return r, nil
+}
+
+func TestRequireMembership(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	serve := func(rules fixedRules, orgRole, path string) int {
+		router := gin.New()
+		router.Use(func(c *gin.Context) {
+			c.Set(OrganizationKey, uint(1))
+			if orgRole != "" {
+				c.Set(OrgRoleKey, orgRole)
+			}
+		})
+		org := router.Group("/organizations/:orgId")
+		org.Use(RequireMembership(policy.NewStore(policy.Default(), rules), map[string]string{
+			org.BasePath():               policy.ResourceOrganization,
+			org.BasePath() + "/articles": policy.ResourceArticle,
+		}))
+		ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+		org.GET("/settings", ok)
+		org.GET("/articles", ok)
+		org.GET("/articles/:id", ok)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w.Code
+	}
+
+	t.Run("Members Get Everywhere", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve(nil, model.RoleMember, "/organizations/1/settings"))
+		assert.Equal(t, http.StatusOK, serve(nil, model.RoleMember, "/organizations/1/articles/7"))
+	})
+
+	t.Run("Non-Members Only Reach Routes Open To Any Role", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, serve(nil, "", "/organizations/1/settings"))
+		assert.Equal(t, http.StatusOK, serve(nil, "", "/organizations/1/articles"))
+		assert.Equal(t, http.StatusOK, serve(nil, "", "/organizations/1/articles/7"))
+	})
+
+	t.Run("The Organization's Policy Decides", func(t *testing.T) {
+		openOrg := fixedRules{{Role: policy.AnyRole, Resource: policy.ResourceOrganization, Actions: []string{policy.ActionView}}}
+		assert.Equal(t, http.StatusOK, serve(openOrg, "", "/organizations/1/settings"))
+	})
+}
@@ -1,41 +1,188 @@
 package middleware
 
 import (
+	"errors"
 	"log"
+	"net"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/slug"
 	"github.com/adityadeshlahre/multi-tenant-backend-app/repository"
 )
 
-const OrganizationKey = "organizationId"
+const (
+	OrganizationKey = "organizationId"
+	OrgRoleKey      = "orgRole"
+)
+
+const (
+	TenantFromHeader = "header"
+	TenantFromPath   = "path"
+	TenantFromHost   = "host"
+)
 
-func OrganizationContext(orgRepo repository.OrgRepository) gin.HandlerFunc {
+// TenantResolution configures how OrganizationContext finds the organization
+// of a request. Strategies are tried in order and the first one that finds an
+// organization identifier in the request decides:
+//
+//   - header: the X-Organization-ID header, an ID or slug
+//   - path:   the :orgId route parameter, an ID or slug
+//   - host:   a subdomain of BaseDomain (acme.blog.example.com) or a
+//     verified custom domain
+type TenantResolution struct {
+	Strategies []string
+	BaseDomain string
+}
+
+func OrganizationContext(orgRepo repository.OrgRepository, roleRepo repository.RoleRepository, resolution TenantResolution) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		orgIdHeader := c.GetHeader("X-Organization-ID")
-		if orgIdHeader == "" {
-			log.Println("Organization ID not found in header")
-			c.AbortWithStatusJSON(400, gin.H{"error": "Organization ID header required"})
+		var (
+			org      *model.Organization
+			err      error
+			resolved bool
+		)
+		for _, strategy := range resolution.Strategies {
+			org, resolved, err = resolveOrganization(c, orgRepo, resolution, strategy)
+			if resolved || err != nil {
+				break
+			}
+		}
+		if c.IsAborted() {
 			return
 		}
 
-		orgId, err := strconv.ParseUint(orgIdHeader, 10, 32)
 		if err != nil {
-			log.Printf("Invalid organization ID format: %v", err)
-			c.AbortWithStatusJSON(400, gin.H{"error": "Invalid organization ID format"})
+			log.Printf("Error resolving organization: %v", err)
+			c.AbortWithStatusJSON(400, gin.H{"error": err.Error()})
 			return
 		}
-
-		org, err := orgRepo.GetOrganizationByID(c.Request.Context(), uint(orgId))
-		if err != nil {
-			log.Printf("Error fetching organization: %v", err)
+		if !resolved {
+			log.Println("Organization could not be resolved from the request")
+			message := "Organization could not be resolved from the request"
+			if len(resolution.Strategies) == 1 && resolution.Strategies[0] == TenantFromHeader {
+				message = "Organization ID header required"
+			}
+			c.AbortWithStatusJSON(400, gin.H{"error": message})
+			return
+		}
+		if org == nil {
 			c.AbortWithStatusJSON(404, gin.H{"error": "Organization not found"})
 			return
 		}
 
+		orgRole := ""
+		if userID, exists := c.Get("userID"); exists {
+			if membership, err := roleRepo.GetMembership(c.Request.Context(), userID.(uint), org.ID); err == nil {
+				orgRole = membership.Role
+			}
+		}
+
 		c.Set("organization", org)
-		c.Set(OrganizationKey, uint(orgId))
+		c.Set(OrganizationKey, org.ID)
+		c.Set(OrgRoleKey, orgRole)
 		c.Next()
 	}
 }
+
+// resolveOrganization applies one strategy. resolved reports whether the
+// request carried an identifier for that strategy at all; org is nil when
+// the identifier matched no organization.
+func resolveOrganization(c *gin.Context, orgRepo repository.OrgRepository, resolution TenantResolution, strategy string) (org *model.Organization, resolved bool, err error) {
+	ctx := c.Request.Context()
+
+	switch strategy {
+	case TenantFromHeader, TenantFromPath:
+		raw := c.GetHeader("X-Organization-ID")
+		if strategy == TenantFromPath {
+			raw = c.Param("orgId")
+		}
+		if raw == "" {
+			return nil, false, nil
+		}
+
+		if orgId, err := strconv.ParseUint(raw, 10, 32); err == nil {
+			org, err := orgRepo.GetOrganizationByID(ctx, uint(orgId))
+			if err != nil {
+				return nil, true, nil
+			}
+			return org, true, nil
+		}
+		if !slug.Valid(raw) {
+			return nil, true, errors.New("Invalid organization ID format")
+		}
+
+		if org, err := orgRepo.GetOrganizationBySlug(ctx, raw); err == nil {
+			return org, true, nil
+		}
+		org, err := orgRepo.GetOrganizationByOldSlug(ctx, raw)
+		if err != nil {
+			return nil, true, nil
+		}
+		if strategy == TenantFromPath && RedirectToCurrentSlug(c, "orgId", org.Slug) {
+			return nil, true, nil
+		}
+		return org, true, nil
+
+	case TenantFromHost:
+		host := strings.ToLower(c.Request.Host)
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if host == "" {
+			return nil, false, nil
+		}
+
+		baseDomain := strings.ToLower(resolution.BaseDomain)
+		if baseDomain != "" && host == baseDomain {
+			return nil, false, nil
+		}
+		if baseDomain != "" && strings.HasSuffix(host, "."+baseDomain) {
+			subdomain := strings.TrimSuffix(host, "."+baseDomain)
+			if strings.Contains(subdomain, ".") {
+				return nil, false, nil
+			}
+			org, err := orgRepo.GetOrganizationBySubdomain(ctx, subdomain)
+			if err != nil {
+				return nil, true, nil
+			}
+			return org, true, nil
+		}
+
+		org, err := orgRepo.GetOrganizationByVerifiedDomain(ctx, host)
+		if err != nil {
+			// Unknown hosts such as localhost are not tenant hosts; let the
+			// next strategy try.
+			return nil, false, nil
+		}
+		return org, true, nil
+	}
+
+	return nil, false, nil
+}
+
+// EffectiveRole returns the caller's role inside the current organization.
+// The organization's owner is always its owner; platform admins keep their
+// global role in every organization; everyone else gets the role of their
+// membership, or "" when they are not a member.
+func EffectiveRole(c *gin.Context) string {
+	if orgRole, exists := c.Get(OrgRoleKey); exists && orgRole.(string) == model.RoleOwner {
+		return model.RoleOwner
+	}
+
+	if userRole, exists := c.Get("userRole"); exists {
+		role := userRole.(string)
+		if role == model.RoleSuperAdmin || role == model.RoleAdmin {
+			return role
+		}
+	}
+
+	orgRole, exists := c.Get(OrgRoleKey)
+	if !exists {
+		return ""
+	}
+	return orgRole.(string)
+}
@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/repository"
+)
+
+// stubOrgs resolves organizations from fixed subdomains and verified
+// domains. Methods host resolution does not use are left unimplemented.
+type stubOrgs struct {
+	repository.OrgRepository
+	subdomains map[string]*model.Organization
+	domains    map[string]*model.Organization
+}
+
+func (s *stubOrgs) GetOrganizationBySubdomain(_ context.Context, subdomain string) (*model.Organization, error) {
+	if org, ok := s.subdomains[subdomain]; ok {
+		return org, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (s *stubOrgs) GetOrganizationByVerifiedDomain(_ context.Context, host string) (*model.Organization, error) {
+	if org, ok := s.domains[host]; ok {
+		return org, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (s *stubOrgs) GetOrganizationByID(_ context.Context, id uint) (*model.Organization, error) {
+	return &model.Organization{Model: gorm.Model{ID: id}}, nil
+}
+
+type stubRoles struct {
+	repository.RoleRepository
+}
+
+func (stubRoles) GetMembership(context.Context, uint, uint) (*model.UserOrganization, error) {
+	return nil, gorm.ErrRecordNotFound
+}
+
+func TestOrganizationContextFromHost(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	acme := &model.Organization{Model: gorm.Model{ID: 1}, Name: "Acme"}
+	globex := &model.Organization{Model: gorm.Model{ID: 2}, Name: "Globex"}
+	orgs := &stubOrgs{
+		subdomains: map[string]*model.Organization{"acme": acme},
+		domains:    map[string]*model.Organization{"blog.globex.com": globex},
+	}
+
+	router := gin.New()
+	router.Use(OrganizationContext(orgs, stubRoles{}, TenantResolution{
+		Strategies: []string{TenantFromHost, TenantFromHeader},
+		BaseDomain: "blog.example.com",
+	}))
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, c.MustGet("organization").(*model.Organization).Name)
+	})
+
+	tests := []struct {
+		name   string
+		host   string
+		header string
+		status int
+		org    string
+	}{
+		{name: "Subdomain", host: "acme.blog.example.com", status: http.StatusOK, org: "Acme"},
+		{name: "Subdomain With Port", host: "ACME.blog.example.com:8080", status: http.StatusOK, org: "Acme"},
+		{name: "Unknown Subdomain", host: "initech.blog.example.com", status: http.StatusNotFound},
+		{name: "Verified Custom Domain", host: "blog.globex.com", status: http.StatusOK, org: "Globex"},
+		{name: "Nested Subdomain Falls Through", host: "a.acme.blog.example.com", status: http.StatusBadRequest},
+		{name: "Base Domain Falls Through To Header", host: "blog.example.com", header: "2", status: http.StatusOK},
+		{name: "Unverified Host Falls Through To Header", host: "localhost:8080", header: "7", status: http.StatusOK},
+		{name: "Unverified Host Without Header", host: "blog.initech.com", status: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Host = tt.host
+			if tt.header != "" {
+				req.Header.Set("X-Organization-ID", tt.header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+			if tt.org != "" {
+				assert.Equal(t, tt.org, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"log"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/policy"
+)
+
+const PolicyKey = "policy"
+
+// PolicyContext resolves the policy of the organization set by
+// OrganizationContext, so it must run after it.
+func PolicyContext(store *policy.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID, exists := c.Get(OrganizationKey)
+		if !exists {
+			log.Println("Organization ID not found in context")
+			c.AbortWithStatusJSON(500, gin.H{"error": "Organization not found in context"})
+			return
+		}
+
+		p, err := store.ForOrganization(c.Request.Context(), orgID.(uint))
+		if err != nil {
+			log.Printf("Error loading policy for organization %d: %v", orgID.(uint), err)
+			c.AbortWithStatusJSON(500, gin.H{"error": "Failed to load organization policy"})
+			return
+		}
+
+		c.Set(PolicyKey, p)
+		c.Next()
+	}
+}
+
+// GetPolicyFromContext returns the organization policy, falling back to the
+// default policy on routes that do not resolve one.
+func GetPolicyFromContext(c *gin.Context) *policy.Policy {
+	p, exists := c.Get(PolicyKey)
+	if !exists {
+		return policy.Default()
+	}
+	return p.(*policy.Policy)
+}
+
+func CurrentSubject(c *gin.Context) policy.Subject {
+	sub := policy.Subject{Role: EffectiveRole(c)}
+	if userID, exists := c.Get("userID"); exists {
+		sub.UserID = userID.(uint)
+	}
+	return sub
+}
+
+// CanInOrganization reports whether the caller may perform action on the
+// current organization.
+func CanInOrganization(c *gin.Context, action string) bool {
+	return GetPolicyFromContext(c).Can(CurrentSubject(c), action, policy.Resource{Type: policy.ResourceOrganization})
+}
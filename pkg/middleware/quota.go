@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/repository"
+)
+
+// APIQuota counts the request against the monthly API request allowance of
+// the organization set by OrganizationContext and rejects it with 429 once
+// the allowance is used up. It runs after RequireMembership, so only
+// requests the organization's policy is open to are counted, and before
+// TenantTransaction, so the count sticks even when the request itself
+// fails.
+func APIQuota(usageRepo repository.UsageRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID, exists := c.Get(OrganizationKey)
+		if !exists {
+			log.Println("Organization not found in context")
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Organization not found in context"})
+			return
+		}
+
+		now := time.Now().UTC()
+		err := usageRepo.RecordAPIRequest(c.Request.Context(), orgID.(uint), now)
+		var quotaErr *repository.QuotaExceededError
+		if errors.As(err, &quotaErr) {
+			resetsAt := time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+			c.Header("Retry-After", strconv.Itoa(int(resetsAt.Sub(now).Seconds())+1))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"error":     fmt.Sprintf("The organization has used its %d API requests for this month", quotaErr.Limit),
+				"quota":     quotaErr.Quota,
+				"limit":     quotaErr.Limit,
+				"resets_at": resetsAt,
+			})
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to record API usage"})
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/repository"
+)
+
+// countingUsage records API requests and rejects them past limit.
+type countingUsage struct {
+	repository.UsageRepository
+	limit    int64
+	requests int64
+}
+
+func (u *countingUsage) RecordAPIRequest(context.Context, uint, time.Time) error {
+	if u.requests >= u.limit {
+		return &repository.QuotaExceededError{Quota: repository.QuotaAPIRequests, Limit: u.limit}
+	}
+	u.requests++
+	return nil
+}
+
+func TestAPIQuota(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	serve := func(usage *countingUsage, orgRole, userRole string) int {
+		router := gin.New()
+		router.Use(func(c *gin.Context) {
+			c.Set(OrganizationKey, uint(1))
+			c.Set(OrgRoleKey, orgRole)
+			c.Set("userRole", userRole)
+		})
+		router.Use(APIQuota(usage))
+		router.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		return w.Code
+	}
+
+	t.Run("Requests Are Counted", func(t *testing.T) {
+		usage := &countingUsage{limit: 10}
+		assert.Equal(t, http.StatusOK, serve(usage, model.RoleMember, model.RoleMember))
+		assert.Equal(t, http.StatusOK, serve(usage, "", model.RoleSuperAdmin))
+		assert.Equal(t, int64(2), usage.requests)
+	})
+
+	t.Run("Exhausted Allowance", func(t *testing.T) {
+		usage := &countingUsage{limit: 1}
+		assert.Equal(t, http.StatusOK, serve(usage, model.RoleMember, model.RoleMember))
+		assert.Equal(t, http.StatusTooManyRequests, serve(usage, model.RoleMember, model.RoleMember))
+	})
+}
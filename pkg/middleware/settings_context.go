@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/settings"
+)
+
+const SettingsKey = "settings"
+
+// SettingsContext resolves the settings of the organization set by
+// OrganizationContext, so it must run after it.
+func SettingsContext(store *settings.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID, exists := c.Get(OrganizationKey)
+		if !exists {
+			log.Println("Organization ID not found in context")
+			c.AbortWithStatusJSON(500, gin.H{"error": "Organization not found in context"})
+			return
+		}
+
+		s, err := store.ForOrganization(c.Request.Context(), orgID.(uint))
+		if err != nil {
+			log.Printf("Error loading settings for organization %d: %v", orgID.(uint), err)
+			c.AbortWithStatusJSON(500, gin.H{"error": "Failed to load organization settings"})
+			return
+		}
+
+		c.Set(SettingsKey, s)
+		c.Next()
+	}
+}
+
+// GetSettingsFromContext returns the organization settings, falling back to
+// the defaults on routes that do not resolve them.
+func GetSettingsFromContext(c *gin.Context) *settings.Settings {
+	s, exists := c.Get(SettingsKey)
+	if !exists {
+		return settings.Default()
+	}
+	return s.(*settings.Settings)
+}
+
+// RequireFeature answers 404 for routes of a feature the organization has
+// switched off. It must run after SettingsContext.
+func RequireFeature(flag string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !GetSettingsFromContext(c).Enabled(flag) {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "This feature is disabled for the organization", "feature": flag})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RedirectToCurrentSlug answers a GET or HEAD addressed by a resource's old
+// slug with a permanent redirect to the same URL using currentSlug for the
+// route parameter param. It reports whether it redirected; other methods are
+// served under the old slug instead, as redirects would drop their bodies.
+func RedirectToCurrentSlug(c *gin.Context, param, currentSlug string) bool {
+	if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+		return false
+	}
+
+	route := strings.Split(c.FullPath(), "/")
+	path := strings.Split(c.Request.URL.Path, "/")
+	if len(route) != len(path) {
+		return false
+	}
+	for i, segment := range route {
+		if segment == ":"+param {
+			path[i] = currentSlug
+		}
+	}
+
+	location := strings.Join(path, "/")
+	if c.Request.URL.RawQuery != "" {
+		location += "?" + c.Request.URL.RawQuery
+	}
+	c.Redirect(http.StatusMovedPermanently, location)
+	c.Abort()
+	return true
+}
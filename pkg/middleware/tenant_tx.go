@@ -0,0 +1,218 @@
+package middleware
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/database"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/repository"
+)
+
+// TenantTransaction runs the rest of the request inside a transaction scoped
+// to the organization set by OrganizationContext. With row-level security the
+// transaction hides other tenants' rows even from queries that forget to
+// filter by organization; in schema-per-tenant mode it points the search_path
+// at the organization's schema. The transaction is committed when the handler
+// responds with a non-error status without attaching errors to the request,
+// such as a failed audit write, and rolled back otherwise. Responses to
+// requests that change data are held back until the commit succeeds, so a
+// client is never told a change was saved when it was not; reads, such as
+// export downloads, stream as usual. It does nothing when neither mode is
+// enabled.
+func TenantTransaction(db *gorm.DB, rls bool, schemaPerTenant bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !rls && !schemaPerTenant {
+			c.Next()
+			return
+		}
+
+		org, exists := c.Get("organization")
+		if !exists {
+			log.Println("Organization not found in context")
+			c.AbortWithStatusJSON(500, gin.H{"error": "Organization not found in context"})
+			return
+		}
+		orgModel := org.(*model.Organization)
+
+		tx := db.WithContext(c.Request.Context()).Begin()
+		if tx.Error != nil {
+			log.Printf("Error starting tenant transaction: %v", tx.Error)
+			c.AbortWithStatusJSON(500, gin.H{"error": "Failed to start transaction"})
+			return
+		}
+
+		if err := scopeTransaction(tx, orgModel, rls, schemaPerTenant); err != nil {
+			tx.Rollback()
+			log.Printf("Error scoping transaction to organization %d: %v", orgModel.ID, err)
+			c.AbortWithStatusJSON(500, gin.H{"error": "Failed to start transaction"})
+			return
+		}
+
+		writer := c.Writer
+		var buffered *bufferedResponse
+		if !safeMethod(c.Request.Method) {
+			buffered = &bufferedResponse{ResponseWriter: writer, initial: writer.Header().Clone()}
+			c.Writer = buffered
+		}
+
+		committed := false
+		defer func() {
+			c.Writer = writer
+			if !committed {
+				tx.Rollback()
+			}
+		}()
+
+		var afterCommit []func()
+		c.Set(afterCommitKey, &afterCommit)
+		c.Request = c.Request.WithContext(repository.ContextWithTx(c.Request.Context(), tx))
+		c.Next()
+
+		if c.Writer.Status() < 400 {
+			var err error
+			if last := c.Errors.Last(); last != nil {
+				err = last
+				log.Printf("Rolling back tenant transaction for organization %d: %v", orgModel.ID, err)
+			} else if err = tx.Commit().Error; err != nil {
+				log.Printf("Error committing tenant transaction for organization %d: %v", orgModel.ID, err)
+			}
+			if err != nil {
+				if buffered == nil {
+					// The response has already been written; all we can do
+					// is make the failure visible.
+					return
+				}
+				c.Writer = writer
+				buffered.discard()
+				c.JSON(500, gin.H{"error": "Failed to save changes"})
+				return
+			}
+			committed = true
+			for _, fn := range afterCommit {
+				fn()
+			}
+		}
+		if buffered != nil {
+			buffered.flush()
+		}
+	}
+}
+
+const afterCommitKey = "afterCommit"
+
+// AfterCommit runs fn once the request's changes are visible to other
+// requests: after TenantTransaction commits, or right away when the request
+// has no tenant transaction. fn is dropped when the transaction rolls back.
+// Use it to invalidate caches, so concurrent requests cannot cache data
+// from before the commit.
+func AfterCommit(c *gin.Context, fn func()) {
+	if pending, exists := c.Get(afterCommitKey); exists {
+		hooks := pending.(*[]func())
+		*hooks = append(*hooks, fn)
+		return
+	}
+	fn()
+}
+
+func safeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// bufferedResponse holds a response back until flush is called. Headers are
+// set on the underlying writer right away but only sent on flush.
+type bufferedResponse struct {
+	gin.ResponseWriter
+	// initial are the headers set before the handler ran.
+	initial http.Header
+	status  int
+	body    bytes.Buffer
+}
+
+func (w *bufferedResponse) WriteHeader(code int) {
+	if code > 0 {
+		w.status = code
+	}
+}
+
+func (w *bufferedResponse) WriteHeaderNow() {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+}
+
+func (w *bufferedResponse) Write(data []byte) (int, error) {
+	w.WriteHeaderNow()
+	return w.body.Write(data)
+}
+
+func (w *bufferedResponse) WriteString(s string) (int, error) {
+	w.WriteHeaderNow()
+	return w.body.WriteString(s)
+}
+
+func (w *bufferedResponse) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+func (w *bufferedResponse) Size() int {
+	if w.status == 0 {
+		return -1
+	}
+	return w.body.Len()
+}
+
+func (w *bufferedResponse) Written() bool {
+	return w.status != 0
+}
+
+// Flush is a no-op: nothing may reach the client before the commit.
+func (w *bufferedResponse) Flush() {}
+
+// flush sends the held back response.
+func (w *bufferedResponse) flush() {
+	if w.status == 0 {
+		return
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	if _, err := w.ResponseWriter.Write(w.body.Bytes()); err != nil {
+		log.Printf("Error writing response: %v", err)
+	}
+}
+
+// discard drops the held back response, including the headers the handler
+// set for it.
+func (w *bufferedResponse) discard() {
+	header := w.ResponseWriter.Header()
+	for key := range header {
+		delete(header, key)
+	}
+	for key, values := range w.initial {
+		header[key] = values
+	}
+	w.status = 0
+	w.body.Reset()
+}
+
+func scopeTransaction(tx *gorm.DB, org *model.Organization, rls bool, schemaPerTenant bool) error {
+	if schemaPerTenant {
+		if org.SchemaName == "" {
+			return fmt.Errorf("organization %d has no schema", org.ID)
+		}
+		if err := database.ScopeToSchema(tx, org.SchemaName); err != nil {
+			return err
+		}
+	}
+	if rls {
+		return database.ScopeToOrganization(tx, org.ID)
+	}
+	return nil
+}
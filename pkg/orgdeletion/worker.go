@@ -0,0 +1,66 @@
+package orgdeletion
+
+import (
+	"context"
+	"log"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/policy"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/repository"
+)
+
+// Worker runs confirmed organization deletions in the background, one at a
+// time, so deleting a large tenant does not hold up the request that asked
+// for it.
+type Worker struct {
+	deletionRepo repository.DeletionRepository
+	policies     *policy.Store
+	queue        chan uint
+}
+
+func NewWorker(deletionRepo repository.DeletionRepository, policies *policy.Store) *Worker {
+	return &Worker{
+		deletionRepo: deletionRepo,
+		policies:     policies,
+		queue:        make(chan uint, 64),
+	}
+}
+
+// Enqueue schedules a confirmed deletion. It never blocks the caller.
+func (w *Worker) Enqueue(deletionID uint) {
+	select {
+	case w.queue <- deletionID:
+	default:
+		go func() { w.queue <- deletionID }()
+	}
+}
+
+// Run resumes deletions left unfinished by a previous run and then processes
+// the queue until ctx is done.
+func (w *Worker) Run(ctx context.Context) {
+	unfinished, err := w.deletionRepo.GetUnfinishedDeletions(ctx)
+	if err != nil {
+		log.Printf("Error resuming organization deletions: %v", err)
+	}
+	for _, deletion := range unfinished {
+		w.run(ctx, deletion.ID)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case deletionID := <-w.queue:
+			w.run(ctx, deletionID)
+		}
+	}
+}
+
+func (w *Worker) run(ctx context.Context, deletionID uint) {
+	deletion, err := w.deletionRepo.RunDeletion(ctx, deletionID)
+	if err != nil {
+		log.Printf("Organization deletion %d failed: %v", deletionID, err)
+		return
+	}
+	w.policies.Invalidate(deletion.OrganizationID)
+	log.Printf("Deleted organization %d", deletion.OrganizationID)
+}
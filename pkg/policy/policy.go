@@ -0,0 +1,285 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+)
+
+const (
+	ResourceOrganization = "organization"
+	ResourceArticle      = "article"
+	ResourceComment      = "comment"
+
+	ActionView           = "view"
+	ActionComment        = "comment"
+	ActionEdit           = "edit"
+	ActionDelete         = "delete"
+	ActionModerate       = "moderate"
+	ActionShare          = "share"
+	ActionCreateArticle  = "create_article"
+	ActionManageMembers  = "manage_members"
+	ActionManagePolicy   = "manage_policy"
+	ActionManageTeams    = "manage_teams"
+	ActionManageDomains  = "manage_domains"
+	ActionManageTrash    = "manage_trash"
+	ActionViewUsage      = "view_usage"
+	ActionManageSettings = "manage_settings"
+	ActionViewAudit      = "view_audit"
+	ActionExportData     = "export_data"
+	ActionManageTaxonomy = "manage_taxonomy"
+
+	// AnyRole matches every role, including callers that are not members of
+	// the organization.
+	AnyRole = "*"
+
+	// ConditionOwner holds when the caller owns the resource.
+	ConditionOwner = "owner"
+)
+
+// resourceActions lists the actions that make sense for each resource type.
+var resourceActions = map[string][]string{
+	ResourceOrganization: {ActionView, ActionCreateArticle, ActionManageMembers, ActionManagePolicy, ActionManageTeams, ActionManageDomains, ActionManageTrash, ActionViewUsage, ActionManageSettings, ActionViewAudit, ActionExportData, ActionManageTaxonomy},
+	ResourceArticle:      {ActionView, ActionComment, ActionEdit, ActionDelete, ActionModerate, ActionShare},
+	ResourceComment:      {ActionEdit, ActionDelete},
+}
+
+// Rule grants Actions on a Resource type to a Role when every condition
+// holds. Conditions are either "owner" or an attribute comparison such as
+// "status == published" or "status != draft".
+type Rule struct {
+	Role       string   `json:"role"`
+	Resource   string   `json:"resource"`
+	Actions    []string `json:"actions"`
+	Conditions []string `json:"conditions,omitempty"`
+}
+
+type Policy struct {
+	Rules []Rule `json:"rules"`
+}
+
+// Subject is the caller an authorization decision is made for.
+type Subject struct {
+	UserID uint
+	Role   string
+}
+
+// Resource describes the object being accessed. OwnerID is zero for
+// resources that have no owner, such as an organization.
+type Resource struct {
+	Type       string
+	OwnerID    uint
+	Attributes map[string]string
+}
+
+func Default() *Policy {
+	return &Policy{Rules: []Rule{
+		{Role: AnyRole, Resource: ResourceArticle, Actions: []string{ActionView, ActionComment, ActionEdit, ActionDelete, ActionModerate, ActionShare}, Conditions: []string{ConditionOwner}},
+		{Role: AnyRole, Resource: ResourceArticle, Actions: []string{ActionView}, Conditions: []string{"status == published", "visibility != " + model.VisibilityOrganization}},
+		{Role: AnyRole, Resource: ResourceComment, Actions: []string{ActionEdit, ActionDelete}, Conditions: []string{ConditionOwner}},
+
+		{Role: model.RoleSuperAdmin, Resource: ResourceOrganization, Actions: []string{ActionView, ActionCreateArticle, ActionManageMembers, ActionManagePolicy, ActionManageTeams, ActionManageDomains, ActionManageTrash, ActionViewUsage, ActionManageSettings, ActionViewAudit, ActionExportData, ActionManageTaxonomy}},
+		{Role: model.RoleSuperAdmin, Resource: ResourceArticle, Actions: []string{ActionView, ActionComment, ActionEdit, ActionShare}},
+
+		{Role: model.RoleOwner, Resource: ResourceOrganization, Actions: []string{ActionView, ActionCreateArticle, ActionManageMembers, ActionManagePolicy, ActionManageTeams, ActionManageDomains, ActionManageTrash, ActionViewUsage, ActionManageSettings, ActionViewAudit, ActionExportData, ActionManageTaxonomy}},
+		{Role: model.RoleOwner, Resource: ResourceArticle, Actions: []string{ActionView, ActionComment, ActionEdit, ActionShare}},
+
+		{Role: model.RoleAdmin, Resource: ResourceOrganization, Actions: []string{ActionView, ActionCreateArticle, ActionManageMembers, ActionManagePolicy, ActionManageTeams, ActionManageDomains, ActionManageTrash, ActionViewUsage, ActionManageSettings, ActionViewAudit, ActionExportData, ActionManageTaxonomy}},
+		{Role: model.RoleAdmin, Resource: ResourceArticle, Actions: []string{ActionView, ActionComment, ActionEdit, ActionShare}},
+
+		{Role: model.RoleEditor, Resource: ResourceOrganization, Actions: []string{ActionView, ActionCreateArticle, ActionManageTaxonomy}},
+		{Role: model.RoleEditor, Resource: ResourceArticle, Actions: []string{ActionView}},
+		{Role: model.RoleEditor, Resource: ResourceArticle, Actions: []string{ActionComment}, Conditions: []string{"status == published"}},
+
+		{Role: model.RoleMember, Resource: ResourceOrganization, Actions: []string{ActionView, ActionCreateArticle}},
+		{Role: model.RoleMember, Resource: ResourceArticle, Actions: []string{ActionView}},
+		{Role: model.RoleMember, Resource: ResourceArticle, Actions: []string{ActionComment}, Conditions: []string{"status == published"}},
+	}}
+}
+
+// LoadFile reads a policy from a JSON file of the form {"rules": [...]}.
+func LoadFile(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var p Policy
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("parse policy file %s: %w", path, err)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, fmt.Errorf("policy file %s: %w", path, err)
+	}
+	return &p, nil
+}
+
+func (p *Policy) Validate() error {
+	for i, rule := range p.Rules {
+		if err := rule.Validate(); err != nil {
+			return fmt.Errorf("rule %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func (r Rule) Validate() error {
+	if r.Role == "" {
+		return fmt.Errorf("role is required")
+	}
+	if r.Resource == "" {
+		return fmt.Errorf("resource is required")
+	}
+	known, ok := resourceActions[r.Resource]
+	if !ok {
+		return fmt.Errorf("unknown resource %q", r.Resource)
+	}
+	if len(r.Actions) == 0 {
+		return fmt.Errorf("at least one action is required")
+	}
+	for _, action := range r.Actions {
+		if !contains(known, action) {
+			return fmt.Errorf("unknown action %q for resource %q", action, r.Resource)
+		}
+	}
+	for _, cond := range r.Conditions {
+		if _, err := parseCondition(cond); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WithOverrides returns a copy of p where every role mentioned in rules has
+// its rules replaced by the given ones. Rules for other roles, including the
+// shared "*" rules unless overridden, are kept.
+func (p *Policy) WithOverrides(rules []Rule) *Policy {
+	if len(rules) == 0 {
+		return p
+	}
+
+	overridden := make(map[string]bool)
+	for _, rule := range rules {
+		overridden[rule.Role] = true
+	}
+
+	merged := &Policy{}
+	for _, rule := range p.Rules {
+		if !overridden[rule.Role] {
+			merged.Rules = append(merged.Rules, rule)
+		}
+	}
+	merged.Rules = append(merged.Rules, rules...)
+	return merged
+}
+
+// Allowed returns the sorted set of actions sub may perform on res.
+func (p *Policy) Allowed(sub Subject, res Resource) []string {
+	set := make(map[string]bool)
+	for _, rule := range p.Rules {
+		if !rule.appliesTo(sub, res.Type) || !rule.conditionsHold(sub, res) {
+			continue
+		}
+		for _, action := range rule.Actions {
+			set[action] = true
+		}
+	}
+
+	actions := make([]string, 0, len(set))
+	for action := range set {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+	return actions
+}
+
+func (p *Policy) Can(sub Subject, action string, res Resource) bool {
+	for _, rule := range p.Rules {
+		if !rule.appliesTo(sub, res.Type) || !contains(rule.Actions, action) {
+			continue
+		}
+		if rule.conditionsHold(sub, res) {
+			return true
+		}
+	}
+	return false
+}
+
+// RulesFor returns the rules that apply to role on resource type, which is
+// what introspection needs when no concrete resource is at hand.
+func (p *Policy) RulesFor(role, resourceType string) []Rule {
+	var rules []Rule
+	sub := Subject{Role: role}
+	for _, rule := range p.Rules {
+		if rule.appliesTo(sub, resourceType) {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+func (r Rule) appliesTo(sub Subject, resourceType string) bool {
+	if r.Resource != resourceType {
+		return false
+	}
+	return r.Role == AnyRole || (sub.Role != "" && r.Role == sub.Role)
+}
+
+func (r Rule) conditionsHold(sub Subject, res Resource) bool {
+	for _, raw := range r.Conditions {
+		cond, err := parseCondition(raw)
+		if err != nil || !cond.holds(sub, res) {
+			return false
+		}
+	}
+	return true
+}
+
+type condition struct {
+	owner     bool
+	attribute string
+	negate    bool
+	value     string
+}
+
+func parseCondition(raw string) (condition, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == ConditionOwner {
+		return condition{owner: true}, nil
+	}
+
+	for _, op := range []string{"==", "!="} {
+		if attr, value, found := strings.Cut(raw, op); found {
+			attr, value = strings.TrimSpace(attr), strings.TrimSpace(value)
+			if attr == "" || value == "" {
+				break
+			}
+			return condition{attribute: attr, negate: op == "!=", value: value}, nil
+		}
+	}
+	return condition{}, fmt.Errorf("invalid condition %q", raw)
+}
+
+func (c condition) holds(sub Subject, res Resource) bool {
+	if c.owner {
+		return res.OwnerID != 0 && res.OwnerID == sub.UserID
+	}
+	actual, ok := res.Attributes[c.attribute]
+	if !ok {
+		return false
+	}
+	return (actual == c.value) != c.negate
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
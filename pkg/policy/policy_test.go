@@ -0,0 +1,196 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+)
+
+func article(ownerID uint, attributes map[string]string) Resource {
+	return Resource{Type: ResourceArticle, OwnerID: ownerID, Attributes: attributes}
+}
+
+func TestOwnerCondition(t *testing.T) {
+	p := &Policy{Rules: []Rule{
+		{Role: AnyRole, Resource: ResourceArticle, Actions: []string{ActionEdit}, Conditions: []string{ConditionOwner}},
+	}}
+
+	assert.True(t, p.Can(Subject{UserID: 7}, ActionEdit, article(7, nil)))
+	assert.False(t, p.Can(Subject{UserID: 8}, ActionEdit, article(7, nil)))
+	// Resources without an owner are owned by nobody, not by user 0.
+	assert.False(t, p.Can(Subject{}, ActionEdit, article(0, nil)))
+}
+
+func TestAttributeConditions(t *testing.T) {
+	p := &Policy{Rules: []Rule{
+		{Role: AnyRole, Resource: ResourceArticle, Actions: []string{ActionView}, Conditions: []string{"status == published", "visibility != organization"}},
+	}}
+	sub := Subject{UserID: 1}
+
+	assert.True(t, p.Can(sub, ActionView, article(2, map[string]string{"status": "published", "visibility": "public"})))
+	assert.False(t, p.Can(sub, ActionView, article(2, map[string]string{"status": "draft", "visibility": "public"})))
+	assert.False(t, p.Can(sub, ActionView, article(2, map[string]string{"status": "published", "visibility": "organization"})))
+	// A missing attribute satisfies neither == nor !=.
+	assert.False(t, p.Can(sub, ActionView, article(2, map[string]string{"status": "published"})))
+}
+
+func TestRoles(t *testing.T) {
+	p := &Policy{Rules: []Rule{
+		{Role: AnyRole, Resource: ResourceOrganization, Actions: []string{ActionView}},
+		{Role: model.RoleAdmin, Resource: ResourceOrganization, Actions: []string{ActionManageMembers}},
+	}}
+	org := Resource{Type: ResourceOrganization}
+
+	assert.Equal(t, []string{ActionManageMembers, ActionView}, p.Allowed(Subject{Role: model.RoleAdmin}, org))
+	assert.Equal(t, []string{ActionView}, p.Allowed(Subject{Role: model.RoleMember}, org))
+	// Non-members have no role but still match "*" rules.
+	assert.Equal(t, []string{ActionView}, p.Allowed(Subject{}, org))
+	// Rules only apply to their resource type.
+	assert.Empty(t, p.Allowed(Subject{Role: model.RoleAdmin}, article(0, nil)))
+}
+
+func TestDefaultPolicy(t *testing.T) {
+	p := Default()
+	require.NoError(t, p.Validate())
+
+	published := map[string]string{"status": "published", "visibility": model.VisibilityPublic}
+	draft := map[string]string{"status": "draft", "visibility": model.VisibilityPublic}
+	membersOnly := map[string]string{"status": "published", "visibility": model.VisibilityOrganization}
+
+	outsider := Subject{UserID: 1}
+	assert.True(t, p.Can(outsider, ActionView, article(2, published)))
+	assert.False(t, p.Can(outsider, ActionView, article(2, draft)))
+	assert.False(t, p.Can(outsider, ActionView, article(2, membersOnly)))
+	assert.False(t, p.Can(outsider, ActionComment, article(2, published)))
+
+	member := Subject{UserID: 1, Role: model.RoleMember}
+	assert.True(t, p.Can(member, ActionView, article(2, membersOnly)))
+	assert.True(t, p.Can(member, ActionComment, article(2, published)))
+	assert.False(t, p.Can(member, ActionComment, article(2, draft)))
+	assert.False(t, p.Can(member, ActionEdit, article(2, published)))
+	assert.True(t, p.Can(member, ActionDelete, article(1, draft)))
+}
+
+func TestWithOverrides(t *testing.T) {
+	defaults := &Policy{Rules: []Rule{
+		{Role: AnyRole, Resource: ResourceArticle, Actions: []string{ActionView}},
+		{Role: model.RoleEditor, Resource: ResourceArticle, Actions: []string{ActionView, ActionEdit}},
+		{Role: model.RoleMember, Resource: ResourceArticle, Actions: []string{ActionComment}},
+	}}
+
+	assert.Same(t, defaults, defaults.WithOverrides(nil))
+
+	merged := defaults.WithOverrides([]Rule{
+		{Role: model.RoleEditor, Resource: ResourceArticle, Actions: []string{ActionComment}},
+	})
+	// The override replaces every default rule of the role...
+	assert.Equal(t, []string{ActionComment, ActionView}, merged.Allowed(Subject{Role: model.RoleEditor}, article(0, nil)))
+	// ...and leaves other roles, including "*", alone.
+	assert.Equal(t, []string{ActionComment, ActionView}, merged.Allowed(Subject{Role: model.RoleMember}, article(0, nil)))
+	assert.Len(t, defaults.Rules, 3)
+
+	merged = defaults.WithOverrides([]Rule{
+		{Role: AnyRole, Resource: ResourceArticle, Actions: []string{ActionComment}},
+	})
+	assert.Equal(t, []string{ActionComment}, merged.Allowed(Subject{}, article(0, nil)))
+}
+
+func TestValidate(t *testing.T) {
+	valid := Rule{Role: model.RoleMember, Resource: ResourceArticle, Actions: []string{ActionView}, Conditions: []string{"status != draft", ConditionOwner}}
+	assert.NoError(t, valid.Validate())
+
+	for name, rule := range map[string]Rule{
+		"no role":                 {Resource: ResourceArticle, Actions: []string{ActionView}},
+		"no resource":             {Role: model.RoleMember, Actions: []string{ActionView}},
+		"unknown resource":        {Role: model.RoleMember, Resource: "team", Actions: []string{ActionView}},
+		"no actions":              {Role: model.RoleMember, Resource: ResourceArticle},
+		"misspelled action":       {Role: model.RoleMember, Resource: ResourceArticle, Actions: []string{"veiw"}},
+		"action of other type":    {Role: model.RoleMember, Resource: ResourceComment, Actions: []string{ActionView}},
+		"condition without op":    {Role: model.RoleMember, Resource: ResourceArticle, Actions: []string{ActionView}, Conditions: []string{"published"}},
+		"condition without value": {Role: model.RoleMember, Resource: ResourceArticle, Actions: []string{ActionView}, Conditions: []string{"status =="}},
+	} {
+		assert.Error(t, rule.Validate(), name)
+	}
+
+	p := &Policy{Rules: []Rule{valid, {Role: model.RoleMember}}}
+	assert.ErrorContains(t, p.Validate(), "rule 1")
+}
+
+func TestLoadFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "policy.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"rules": [{"role": "*", "resource": "article", "actions": ["view"]}]}`), 0o600))
+
+	p, err := LoadFile(path)
+	require.NoError(t, err)
+	assert.Len(t, p.Rules, 1)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"rules": [{"role": "*", "resource": "article", "actions": ["fly"]}]}`), 0o600))
+	_, err = LoadFile(path)
+	assert.Error(t, err)
+}
+
+type fakeRules struct {
+	calls int
+	rules []model.PolicyRule
+	err   error
+	// during runs while the rules are loaded.
+	during func()
+}
+
+func (f *fakeRules) GetPolicyRules(ctx context.Context, orgID uint) ([]model.PolicyRule, error) {
+	f.calls++
+	if f.during != nil {
+		f.during()
+	}
+	return f.rules, f.err
+}
+
+func TestStore(t *testing.T) {
+	source := &fakeRules{rules: []model.PolicyRule{
+		{OrganizationID: 1, Role: model.RoleMember, Resource: ResourceArticle, Actions: []string{ActionEdit}},
+	}}
+	store := NewStore(Default(), source)
+
+	p, err := store.ForOrganization(context.Background(), 1)
+	require.NoError(t, err)
+	member := Subject{Role: model.RoleMember}
+	assert.True(t, p.Can(member, ActionEdit, article(0, nil)))
+	// Overriding the member role drops its default organization rules.
+	assert.False(t, p.Can(member, ActionCreateArticle, Resource{Type: ResourceOrganization}))
+
+	_, err = store.ForOrganization(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, source.calls, "resolved policies are cached")
+
+	store.Invalidate(1)
+	source.err = errors.New("database down")
+	_, err = store.ForOrganization(context.Background(), 1)
+	assert.Error(t, err)
+	assert.Equal(t, 2, source.calls)
+}
+
+func TestStoreDoesNotCacheLoadsRacingAnInvalidation(t *testing.T) {
+	source := &fakeRules{}
+	store := NewStore(Default(), source)
+	source.during = func() { store.Invalidate(1) }
+
+	_, err := store.ForOrganization(context.Background(), 1)
+	require.NoError(t, err)
+
+	source.during = nil
+	_, err = store.ForOrganization(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, 2, source.calls, "a load that raced with an invalidation is not cached")
+
+	_, err = store.ForOrganization(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, 2, source.calls)
+}
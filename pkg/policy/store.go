@@ -0,0 +1,78 @@
+package policy
+
+import (
+	"context"
+	"sync"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+)
+
+// RuleSource loads the rules an organization has defined for itself.
+type RuleSource interface {
+	GetPolicyRules(ctx context.Context, orgID uint) ([]model.PolicyRule, error)
+}
+
+// Store resolves the effective policy of an organization: the default policy
+// with the organization's own rules layered on top. Resolved policies are
+// cached until Invalidate is called for the organization.
+type Store struct {
+	defaults *Policy
+	source   RuleSource
+
+	mu    sync.RWMutex
+	cache map[uint]*Policy
+	// generations counts the invalidations of each organization, so a load
+	// that raced with one is not cached.
+	generations map[uint]uint64
+}
+
+func NewStore(defaults *Policy, source RuleSource) *Store {
+	return &Store{
+		defaults:    defaults,
+		source:      source,
+		cache:       make(map[uint]*Policy),
+		generations: make(map[uint]uint64),
+	}
+}
+
+func (s *Store) Defaults() *Policy {
+	return s.defaults
+}
+
+func (s *Store) ForOrganization(ctx context.Context, orgID uint) (*Policy, error) {
+	s.mu.RLock()
+	cached, ok := s.cache[orgID]
+	generation := s.generations[orgID]
+	s.mu.RUnlock()
+	if ok {
+		return cached, nil
+	}
+
+	stored, err := s.source.GetPolicyRules(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make([]Rule, 0, len(stored))
+	for _, r := range stored {
+		rules = append(rules, Rule{Role: r.Role, Resource: r.Resource, Actions: r.Actions, Conditions: r.Conditions})
+	}
+	resolved := s.defaults.WithOverrides(rules)
+
+	s.mu.Lock()
+	if s.generations[orgID] == generation {
+		s.cache[orgID] = resolved
+	}
+	s.mu.Unlock()
+
+	return resolved, nil
+}
+
+// Invalidate drops the cached policy of the organization. Call it once
+// changes to them are committed.
+func (s *Store) Invalidate(orgID uint) {
+	s.mu.Lock()
+	delete(s.cache, orgID)
+	s.generations[orgID]++
+	s.mu.Unlock()
+}
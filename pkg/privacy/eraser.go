@@ -0,0 +1,53 @@
+package privacy
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/repository"
+)
+
+// EraseInterval is how often the eraser looks for account deletions whose
+// grace period is over.
+const EraseInterval = time.Hour
+
+// Eraser carries out account deletions once their grace period has passed.
+type Eraser struct {
+	accountRepo repository.AccountRepository
+}
+
+func NewEraser(accountRepo repository.AccountRepository) *Eraser {
+	return &Eraser{accountRepo: accountRepo}
+}
+
+// Run erases due accounts right away and then every EraseInterval until ctx
+// is done.
+func (e *Eraser) Run(ctx context.Context) {
+	ticker := time.NewTicker(EraseInterval)
+	defer ticker.Stop()
+
+	for {
+		e.EraseDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (e *Eraser) EraseDue(ctx context.Context) {
+	deletions, err := e.accountRepo.GetDueDeletions(ctx, time.Now())
+	if err != nil {
+		return
+	}
+	for _, deletion := range deletions {
+		if _, err := e.accountRepo.RunDeletion(ctx, deletion.ID); err != nil {
+			log.Printf("Account deletion %d failed: %v", deletion.ID, err)
+			continue
+		}
+		log.Printf("Deleted account of user %d", deletion.UserID)
+	}
+}
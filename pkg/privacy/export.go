@@ -0,0 +1,144 @@
+package privacy
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/repository"
+)
+
+const (
+	profileFile     = "profile.json"
+	membershipsFile = "memberships.jsonl"
+	articlesFile    = "articles.jsonl"
+	commentsFile    = "comments.jsonl"
+)
+
+type profileRecord struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type articleRecord struct {
+	ID             uint       `json:"id"`
+	OrganizationID uint       `json:"organization_id"`
+	Title          string     `json:"title"`
+	Slug           string     `json:"slug"`
+	Content        string     `json:"content"`
+	ContentFormat  string     `json:"content_format"`
+	Status         string     `json:"status"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	DeletedAt      *time.Time `json:"deleted_at,omitempty"`
+}
+
+type commentRecord struct {
+	ID        uint       `json:"id"`
+	ArticleID uint       `json:"article_id"`
+	Content   string     `json:"content"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+// WriteUserArchive writes everything held about the user to w as a zip
+// archive: profile.json plus one JSONL file each for memberships, articles
+// and comments. Every file is present even when it has no records. Trashed
+// articles and comments carry the time they were deleted.
+func WriteUserArchive(ctx context.Context, w io.Writer, accountRepo repository.AccountRepository, userID uint) error {
+	var (
+		profile     profileRecord
+		memberships []repository.Membership
+		articles    []articleRecord
+		comments    []commentRecord
+	)
+	err := accountRepo.ReadUserData(ctx, userID, func(record interface{}) error {
+		switch row := record.(type) {
+		case *model.User:
+			profile = profileRecord{ID: row.ID, Name: row.Name, Email: row.Email, Role: row.Role, CreatedAt: row.CreatedAt, UpdatedAt: row.UpdatedAt}
+		case *repository.Membership:
+			memberships = append(memberships, *row)
+		case *model.Article:
+			articles = append(articles, articleRecord{
+				ID:             row.ID,
+				OrganizationID: row.OrganizationID,
+				Title:          row.Title,
+				Slug:           row.Slug,
+				Content:        row.Content,
+				ContentFormat:  row.ContentFormat,
+				Status:         row.Status,
+				CreatedAt:      row.CreatedAt,
+				UpdatedAt:      row.UpdatedAt,
+				DeletedAt:      deletedAt(row.DeletedAt),
+			})
+		case *model.Comment:
+			comments = append(comments, commentRecord{
+				ID:        row.ID,
+				ArticleID: row.ArticleID,
+				Content:   row.Content,
+				CreatedAt: row.CreatedAt,
+				UpdatedAt: row.UpdatedAt,
+				DeletedAt: deletedAt(row.DeletedAt),
+			})
+		default:
+			return fmt.Errorf("cannot export %T", record)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	zw := zip.NewWriter(w)
+	entry, err := zw.Create(profileFile)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(entry)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(profile); err != nil {
+		return err
+	}
+	if err := writeLines(zw, membershipsFile, len(memberships), func(i int) interface{} { return memberships[i] }); err != nil {
+		return err
+	}
+	if err := writeLines(zw, articlesFile, len(articles), func(i int) interface{} { return articles[i] }); err != nil {
+		return err
+	}
+	if err := writeLines(zw, commentsFile, len(comments), func(i int) interface{} { return comments[i] }); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+func deletedAt(at gorm.DeletedAt) *time.Time {
+	if !at.Valid {
+		return nil
+	}
+	return &at.Time
+}
+
+func writeLines(zw *zip.Writer, name string, n int, record func(i int) interface{}) error {
+	entry, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(entry)
+	for i := 0; i < n; i++ {
+		if err := encoder.Encode(record(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package settings
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"time"
+
+	"golang.org/x/text/language"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+)
+
+const (
+	// CommentsOpen lets everyone the policy allows comment.
+	CommentsOpen = "open"
+	// CommentsClosed stops new comments on every article.
+	CommentsClosed = "closed"
+)
+
+// Feature flags organizations can switch off.
+const (
+	FeatureTeams         = "teams"
+	FeatureCollaborators = "collaborators"
+	FeatureCustomDomains = "custom_domains"
+)
+
+var defaultFeatures = map[string]bool{
+	FeatureTeams:         true,
+	FeatureCollaborators: true,
+	FeatureCustomDomains: true,
+}
+
+var hexColor = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// Settings is the effective configuration of an organization: its stored
+// settings with defaults filled in.
+type Settings struct {
+	CommentPolicy            string          `json:"comment_policy"`
+	DefaultArticleVisibility string          `json:"default_article_visibility"`
+	DeletedAuthorArticles    string          `json:"deleted_author_articles"`
+	Locale                   string          `json:"locale"`
+	Timezone                 string          `json:"timezone"`
+	Branding                 Branding        `json:"branding"`
+	Features                 map[string]bool `json:"features"`
+}
+
+type Branding struct {
+	LogoURL      string `json:"logo_url"`
+	PrimaryColor string `json:"primary_color"`
+}
+
+func Default() *Settings {
+	features := make(map[string]bool, len(defaultFeatures))
+	for flag, enabled := range defaultFeatures {
+		features[flag] = enabled
+	}
+	return &Settings{
+		CommentPolicy:            CommentsOpen,
+		DefaultArticleVisibility: model.VisibilityPublic,
+		DeletedAuthorArticles:    model.DeletedAuthorReassign,
+		Locale:                   "en",
+		Timezone:                 "UTC",
+		Features:                 features,
+	}
+}
+
+// Resolve layers stored on top of the defaults. Flags that are no longer
+// known are dropped.
+func Resolve(stored *model.OrganizationSettings) *Settings {
+	s := Default()
+	if stored == nil {
+		return s
+	}
+
+	if stored.CommentPolicy != "" {
+		s.CommentPolicy = stored.CommentPolicy
+	}
+	if stored.DefaultArticleVisibility != "" {
+		s.DefaultArticleVisibility = stored.DefaultArticleVisibility
+	}
+	if stored.DeletedAuthorArticles != "" {
+		s.DeletedAuthorArticles = stored.DeletedAuthorArticles
+	}
+	if stored.Locale != "" {
+		s.Locale = stored.Locale
+	}
+	if stored.Timezone != "" {
+		s.Timezone = stored.Timezone
+	}
+	s.Branding = Branding{LogoURL: stored.LogoURL, PrimaryColor: stored.PrimaryColor}
+	for flag, enabled := range stored.Features {
+		if _, known := defaultFeatures[flag]; known {
+			s.Features[flag] = enabled
+		}
+	}
+	return s
+}
+
+// Enabled reports whether the feature flag is on.
+func (s *Settings) Enabled(flag string) bool {
+	return s.Features[flag]
+}
+
+// Location returns the organization's time zone, falling back to UTC.
+func (s *Settings) Location() *time.Location {
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+func (s *Settings) Validate() error {
+	if s.CommentPolicy != CommentsOpen && s.CommentPolicy != CommentsClosed {
+		return fmt.Errorf("comment_policy must be %q or %q", CommentsOpen, CommentsClosed)
+	}
+	switch s.DefaultArticleVisibility {
+	case model.VisibilityPublic, model.VisibilityOrganization, model.VisibilityPrivate:
+	default:
+		return fmt.Errorf("default_article_visibility must be %q, %q or %q", model.VisibilityPublic, model.VisibilityOrganization, model.VisibilityPrivate)
+	}
+	if s.DeletedAuthorArticles != model.DeletedAuthorReassign && s.DeletedAuthorArticles != model.DeletedAuthorDelete {
+		return fmt.Errorf("deleted_author_articles must be %q or %q", model.DeletedAuthorReassign, model.DeletedAuthorDelete)
+	}
+	if _, err := language.Parse(s.Locale); err != nil {
+		return fmt.Errorf("locale %q is not a valid BCP 47 language tag", s.Locale)
+	}
+	if _, err := time.LoadLocation(s.Timezone); err != nil {
+		return fmt.Errorf("unknown timezone %q", s.Timezone)
+	}
+	if logo := s.Branding.LogoURL; logo != "" {
+		u, err := url.Parse(logo)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("logo_url must be an http or https URL")
+		}
+	}
+	if color := s.Branding.PrimaryColor; color != "" && !hexColor.MatchString(color) {
+		return fmt.Errorf("primary_color must look like #1a2b3c")
+	}
+	for flag := range s.Features {
+		if _, known := defaultFeatures[flag]; !known {
+			return fmt.Errorf("unknown feature flag %q", flag)
+		}
+	}
+	return nil
+}
+
+// Features lists the known feature flags.
+func Features() []string {
+	flags := make([]string, 0, len(defaultFeatures))
+	for flag := range defaultFeatures {
+		flags = append(flags, flag)
+	}
+	sort.Strings(flags)
+	return flags
+}
@@ -0,0 +1,67 @@
+package settings
+
+import (
+	"context"
+	"sync"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+)
+
+// Source loads the settings an organization has stored. It returns nil when
+// the organization has not stored any.
+type Source interface {
+	GetSettings(ctx context.Context, orgID uint) (*model.OrganizationSettings, error)
+}
+
+// Store resolves the effective settings of an organization. Resolved
+// settings are cached until Invalidate is called for the organization.
+type Store struct {
+	source Source
+
+	mu    sync.RWMutex
+	cache map[uint]*Settings
+	// generations counts the invalidations of each organization, so a load
+	// that raced with one is not cached.
+	generations map[uint]uint64
+}
+
+func NewStore(source Source) *Store {
+	return &Store{
+		source:      source,
+		cache:       make(map[uint]*Settings),
+		generations: make(map[uint]uint64),
+	}
+}
+
+func (s *Store) ForOrganization(ctx context.Context, orgID uint) (*Settings, error) {
+	s.mu.RLock()
+	cached, ok := s.cache[orgID]
+	generation := s.generations[orgID]
+	s.mu.RUnlock()
+	if ok {
+		return cached, nil
+	}
+
+	stored, err := s.source.GetSettings(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	resolved := Resolve(stored)
+
+	s.mu.Lock()
+	if s.generations[orgID] == generation {
+		s.cache[orgID] = resolved
+	}
+	s.mu.Unlock()
+
+	return resolved, nil
+}
+
+// Invalidate drops the cached settings of the organization. Call it once
+// changes to them are committed.
+func (s *Store) Invalidate(orgID uint) {
+	s.mu.Lock()
+	delete(s.cache, orgID)
+	s.generations[orgID]++
+	s.mu.Unlock()
+}
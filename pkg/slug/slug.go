@@ -0,0 +1,106 @@
+package slug
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// MaxLength caps generated slugs, leaving room for collision suffixes.
+const MaxLength = 80
+
+// transliterations covers Latin letters that do not decompose into a base
+// letter plus combining marks.
+var transliterations = map[rune]string{
+	'ß': "ss", 'æ': "ae", 'Æ': "ae", 'œ': "oe", 'Œ': "oe",
+	'ø': "o", 'Ø': "o", 'ł': "l", 'Ł': "l", 'đ': "d", 'Đ': "d",
+	'ð': "d", 'Ð': "d", 'þ': "th", 'Þ': "th", 'ı': "i",
+	'&': " and ",
+}
+
+// Make turns s into a lowercase, hyphen separated ASCII slug. Accented
+// letters lose their accents; characters without an ASCII equivalent are
+// dropped. When nothing usable is left, or the slug would be all digits and
+// so look like an ID, fallback is used as (a prefix of) the slug.
+func Make(s, fallback string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range norm.NFKD.String(s) {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		if t, ok := transliterations[r]; ok {
+			for _, tr := range t {
+				hyphen = write(&b, tr, hyphen)
+			}
+			continue
+		}
+		hyphen = write(&b, r, hyphen)
+	}
+
+	slug := strings.Trim(b.String(), "-")
+	if len(slug) > MaxLength {
+		slug = strings.TrimRight(slug[:MaxLength], "-")
+	}
+
+	switch {
+	case slug == "":
+		return fallback
+	case IsNumeric(slug):
+		return fallback + "-" + slug
+	}
+	return slug
+}
+
+func write(b *strings.Builder, r rune, hyphen bool) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+		b.WriteRune(r)
+		return false
+	case r >= 'A' && r <= 'Z':
+		b.WriteRune(unicode.ToLower(r))
+		return false
+	default:
+		if !hyphen && b.Len() > 0 {
+			b.WriteByte('-')
+		}
+		return true
+	}
+}
+
+// Valid reports whether s is already in slug form.
+func Valid(s string) bool {
+	return s != "" && len(s) <= MaxLength && Make(s, "") == s
+}
+
+// IsNumeric reports whether s consists of digits only, i.e. would be read as
+// an ID rather than a slug.
+func IsNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Unique returns base, or base with the first numeric suffix (-2, -3, ...)
+// for which taken reports false.
+func Unique(base string, taken func(candidate string) (bool, error)) (string, error) {
+	candidate := base
+	for n := 2; ; n++ {
+		exists, err := taken(candidate)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s-%d", base, n)
+	}
+}
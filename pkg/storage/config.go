@@ -0,0 +1,26 @@
+package storage
+
+import (
+	"fmt"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/config"
+)
+
+// FromConfig opens the storage backend selected by the configuration.
+func FromConfig(cfg *config.Config) (Storage, error) {
+	switch cfg.StorageBackend {
+	case "local":
+		return NewLocal(cfg.StorageDir)
+	case "s3":
+		return NewS3(S3Config{
+			Endpoint:        cfg.S3Endpoint,
+			Region:          cfg.S3Region,
+			Bucket:          cfg.S3Bucket,
+			AccessKeyID:     cfg.S3AccessKeyID,
+			SecretAccessKey: cfg.S3SecretAccessKey,
+			PathStyle:       cfg.S3PathStyle,
+		})
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", cfg.StorageBackend)
+	}
+}
@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeS3 is a minimal stand-in for an S3-compatible server that keeps
+// objects in memory. It checks that requests are path-style and signed,
+// not that the signatures are correct.
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=test-key/") ||
+		r.Header.Get("X-Amz-Date") == "" || r.Header.Get("X-Amz-Content-Sha256") != unsignedPayload {
+		http.Error(w, "unsigned request", http.StatusForbidden)
+		return
+	}
+	if !strings.HasPrefix(r.URL.Path, "/bucket/") {
+		http.Error(w, "no such bucket", http.StatusNotFound)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.Method {
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		f.objects[r.URL.Path] = body
+	case http.MethodGet:
+		body, ok := f.objects[r.URL.Path]
+		if !ok {
+			http.Error(w, "no such key", http.StatusNotFound)
+			return
+		}
+		w.Write(body)
+	case http.MethodDelete:
+		delete(f.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func TestStorageBackends(t *testing.T) {
+	server := httptest.NewServer(&fakeS3{objects: make(map[string][]byte)})
+	defer server.Close()
+
+	s3, err := NewS3(S3Config{
+		Endpoint:        server.URL,
+		Bucket:          "bucket",
+		AccessKeyID:     "test-key",
+		SecretAccessKey: "test-secret",
+		PathStyle:       true,
+	})
+	require.NoError(t, err)
+	local, err := NewLocal(t.TempDir())
+	require.NoError(t, err)
+
+	for name, store := range map[string]Storage{"Local": local, "S3": s3} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			content := []byte("attachment content")
+			require.NoError(t, store.Put(ctx, "attachments/abc", bytes.NewReader(content), int64(len(content)), "text/plain"))
+
+			body, err := store.Get(ctx, "attachments/abc")
+			require.NoError(t, err)
+			stored, err := io.ReadAll(body)
+			body.Close()
+			require.NoError(t, err)
+			assert.Equal(t, content, stored)
+
+			require.NoError(t, store.Delete(ctx, "attachments/abc"))
+			require.NoError(t, store.Delete(ctx, "attachments/abc"))
+			_, err = store.Get(ctx, "attachments/abc")
+			assert.ErrorIs(t, err, ErrNotFound)
+		})
+	}
+
+	t.Run("Local Rejects Keys Outside Its Directory", func(t *testing.T) {
+		err := local.Put(context.Background(), "../escape", strings.NewReader("x"), 1, "text/plain")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,503 @@
+package tenantexport
+
+import (
+	"archive/zip"
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/markup"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/policy"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/settings"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/slug"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/storage"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/repository"
+)
+
+const (
+	// Format identifies archives written by Write.
+	Format = "tenant-export"
+	// Version is bumped whenever a record changes in a way older readers
+	// would get wrong. Read only accepts archives of this version.
+	Version = 1
+
+	// The archive holds the files below.
+	manifestFile      = "manifest.json"
+	organizationFile  = "organization.jsonl"
+	settingsFile      = "settings.jsonl"
+	rolesFile         = "roles.jsonl"
+	policyRulesFile   = "policy_rules.jsonl"
+	membersFile       = "members.jsonl"
+	teamsFile         = "teams.jsonl"
+	tagsFile          = "tags.jsonl"
+	categoriesFile    = "categories.jsonl"
+	articlesFile      = "articles.jsonl"
+	revisionsFile     = "revisions.jsonl"
+	commentsFile      = "comments.jsonl"
+	collaboratorsFile = "collaborators.jsonl"
+	attachmentsFile   = "attachments.jsonl"
+)
+
+// attachmentPath is where the file of an attachment is kept in the archive.
+func attachmentPath(id uint) string {
+	return fmt.Sprintf("files/%d", id)
+}
+
+// Manifest describes an archive. Files maps each JSONL file to the number
+// of records in it.
+type Manifest struct {
+	Format         string         `json:"format"`
+	Version        int            `json:"version"`
+	ExportedAt     time.Time      `json:"exported_at"`
+	OrganizationID uint           `json:"organization_id"`
+	Files          map[string]int `json:"files"`
+}
+
+// ValidationError lists everything wrong with an archive, so it can be
+// fixed in one go.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid archive: " + strings.Join(e.Problems, "; ")
+}
+
+// Write exports the organization to w as a zip archive holding one JSONL
+// file per entity, the attachments' files read from store, and a manifest.
+func Write(ctx context.Context, w io.Writer, exportRepo repository.ExportRepository, store storage.Storage, orgID uint) error {
+	zw := zip.NewWriter(w)
+	aw := &archiveWriter{zip: zw, counts: make(map[string]int)}
+	if err := exportRepo.ReadTenant(ctx, orgID, aw.write); err != nil {
+		return err
+	}
+	if err := aw.flush(); err != nil {
+		return err
+	}
+	// Articles keep no revision history yet, so revisions.jsonl is written
+	// empty to keep the archive's layout stable for when they do.
+	if _, err := zw.Create(revisionsFile); err != nil {
+		return err
+	}
+	aw.counts[revisionsFile] = 0
+	for _, attachment := range aw.attachments {
+		if err := copyFile(ctx, zw, store, attachment); err != nil {
+			return fmt.Errorf("attachment %d: %w", attachment.ID, err)
+		}
+	}
+
+	entry, err := zw.Create(manifestFile)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(entry)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(Manifest{
+		Format:         Format,
+		Version:        Version,
+		ExportedAt:     time.Now().UTC(),
+		OrganizationID: orgID,
+		Files:          aw.counts,
+	}); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// archiveWriter streams records into the archive. Records of one entity
+// arrive together, so only one file is open at a time. The files of
+// attachments are copied once all records are written.
+type archiveWriter struct {
+	zip         *zip.Writer
+	counts      map[string]int
+	file        string
+	buf         *bufio.Writer
+	encoder     *json.Encoder
+	attachments []model.Attachment
+}
+
+func (aw *archiveWriter) write(record interface{}) error {
+	if attachment, ok := record.(*model.Attachment); ok {
+		aw.attachments = append(aw.attachments, *attachment)
+	}
+	file, line := toRecord(record)
+	if file == "" {
+		return fmt.Errorf("cannot export %T", record)
+	}
+	if file != aw.file {
+		if err := aw.flush(); err != nil {
+			return err
+		}
+		entry, err := aw.zip.Create(file)
+		if err != nil {
+			return err
+		}
+		aw.file = file
+		aw.buf = bufio.NewWriter(entry)
+		aw.encoder = json.NewEncoder(aw.buf)
+	}
+	aw.counts[file]++
+	return aw.encoder.Encode(line)
+}
+
+func (aw *archiveWriter) flush() error {
+	if aw.buf == nil {
+		return nil
+	}
+	return aw.buf.Flush()
+}
+
+func copyFile(ctx context.Context, zw *zip.Writer, store storage.Storage, attachment model.Attachment) error {
+	body, err := store.Get(ctx, attachment.StorageKey)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	// Most attachments are compressed already.
+	entry, err := zw.CreateHeader(&zip.FileHeader{Name: attachmentPath(attachment.ID), Method: zip.Store, Modified: attachment.CreatedAt})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(entry, body)
+	return err
+}
+
+// Read parses and validates an archive written by Write. References between
+// records must resolve within the archive.
+func Read(r io.ReaderAt, size int64) (*repository.TenantData, *Manifest, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, nil, &ValidationError{Problems: []string{"not a zip archive"}}
+	}
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	var manifest Manifest
+	if err := readJSON(files[manifestFile], &manifest); err != nil {
+		return nil, nil, &ValidationError{Problems: []string{fmt.Sprintf("%s: %v", manifestFile, err)}}
+	}
+	if manifest.Format != Format || manifest.Version != Version {
+		return nil, &manifest, &ValidationError{Problems: []string{
+			fmt.Sprintf("unsupported archive %s version %d, expected %s version %d", manifest.Format, manifest.Version, Format, Version),
+		}}
+	}
+
+	v := &validator{}
+	data := &repository.TenantData{}
+
+	var orgs []organizationRecord
+	v.readLines(files, manifest, organizationFile, &orgs)
+	if len(orgs) != 1 {
+		v.fail("%s must hold exactly one organization", organizationFile)
+	} else {
+		data.Organization = orgs[0].model()
+	}
+
+	var stored []settingsRecord
+	v.readLines(files, manifest, settingsFile, &stored)
+	if len(stored) > 1 {
+		v.fail("%s must hold at most one record", settingsFile)
+	} else if len(stored) == 1 {
+		data.Settings = stored[0].model()
+	}
+
+	var roles []roleRecord
+	v.readLines(files, manifest, rolesFile, &roles)
+	for _, role := range roles {
+		data.Roles = append(data.Roles, role.model())
+	}
+
+	var rules []policyRuleRecord
+	v.readLines(files, manifest, policyRulesFile, &rules)
+	for _, rule := range rules {
+		data.PolicyRules = append(data.PolicyRules, rule.model())
+	}
+
+	var members []memberRecord
+	v.readLines(files, manifest, membersFile, &members)
+	for _, member := range members {
+		data.Members = append(data.Members, member.model())
+	}
+
+	var teams []teamRecord
+	v.readLines(files, manifest, teamsFile, &teams)
+	for _, team := range teams {
+		data.Teams = append(data.Teams, team.model())
+	}
+
+	var tags []tagRecord
+	v.readLines(files, manifest, tagsFile, &tags)
+	for _, tag := range tags {
+		data.Tags = append(data.Tags, tag.model())
+	}
+
+	var categories []categoryRecord
+	v.readLines(files, manifest, categoriesFile, &categories)
+	for _, category := range categories {
+		data.Categories = append(data.Categories, category.model())
+	}
+
+	var articles []articleRecord
+	v.readLines(files, manifest, articlesFile, &articles)
+	for _, article := range articles {
+		data.Articles = append(data.Articles, article.model())
+	}
+
+	var revisions []json.RawMessage
+	v.readLines(files, manifest, revisionsFile, &revisions)
+	if len(revisions) > 0 {
+		v.fail("%s holds %d revisions, but articles keep no revision history", revisionsFile, len(revisions))
+	}
+
+	var comments []commentRecord
+	v.readLines(files, manifest, commentsFile, &comments)
+	for _, comment := range comments {
+		data.Comments = append(data.Comments, comment.model())
+	}
+
+	var collaborators []collaboratorRecord
+	v.readLines(files, manifest, collaboratorsFile, &collaborators)
+	for _, collaborator := range collaborators {
+		data.Collaborators = append(data.Collaborators, collaborator.model())
+	}
+
+	var attachments []attachmentRecord
+	v.readLines(files, manifest, attachmentsFile, &attachments)
+	for _, attachment := range attachments {
+		if f := files[attachmentPath(attachment.ID)]; f == nil || int64(f.UncompressedSize64) != attachment.Size {
+			v.fail("attachment %d has no file of %d bytes", attachment.ID, attachment.Size)
+		}
+		data.Attachments = append(data.Attachments, attachment.model())
+	}
+
+	v.check(data)
+	if len(v.problems) > 0 {
+		return nil, &manifest, &ValidationError{Problems: v.problems}
+	}
+	return data, &manifest, nil
+}
+
+func readJSON(f *zip.File, v interface{}) error {
+	if f == nil {
+		return fmt.Errorf("missing")
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return json.NewDecoder(rc).Decode(v)
+}
+
+// maxProblems caps how many problems are collected from a broken archive.
+const maxProblems = 50
+
+type validator struct {
+	problems []string
+}
+
+func (v *validator) fail(format string, args ...interface{}) {
+	if len(v.problems) < maxProblems {
+		v.problems = append(v.problems, fmt.Sprintf(format, args...))
+	}
+}
+
+// readLines decodes every line of the named file into records, which must
+// point to a slice. A file the manifest does not list is treated as empty.
+func (v *validator) readLines(files map[string]*zip.File, manifest Manifest, name string, records interface{}) {
+	expected, listed := manifest.Files[name]
+	if !listed {
+		return
+	}
+	f := files[name]
+	if f == nil {
+		v.fail("%s is listed in the manifest but missing", name)
+		return
+	}
+	rc, err := f.Open()
+	if err != nil {
+		v.fail("%s: %v", name, err)
+		return
+	}
+	defer rc.Close()
+
+	decoder := json.NewDecoder(rc)
+	decoder.DisallowUnknownFields()
+	lines, err := decodeLines(decoder, records)
+	if err != nil {
+		v.fail("%s record %d: %v", name, lines+1, err)
+		return
+	}
+	if lines != expected {
+		v.fail("%s has %d records, the manifest lists %d", name, lines, expected)
+	}
+}
+
+// check validates records on their own and the references between them.
+func (v *validator) check(data *repository.TenantData) {
+	if data.Organization.Name == "" {
+		v.fail("organization name is required")
+	}
+	if data.Settings != nil {
+		if err := settings.Resolve(data.Settings).Validate(); err != nil {
+			v.fail("settings: %v", err)
+		}
+	}
+
+	roles := map[string]bool{model.RoleOwner: true, model.RoleAdmin: true, model.RoleEditor: true, model.RoleMember: true}
+	for _, role := range data.Roles {
+		if role.Name == "" || roles[role.Name] {
+			v.fail("role %q is empty or defined twice", role.Name)
+		}
+		roles[role.Name] = true
+	}
+	for i, rule := range data.PolicyRules {
+		if err := (policy.Rule{Role: rule.Role, Resource: rule.Resource, Actions: rule.Actions, Conditions: rule.Conditions}).Validate(); err != nil {
+			v.fail("policy rule %d: %v", i+1, err)
+		}
+	}
+
+	users := make(map[uint]bool)
+	emails := make(map[string]bool)
+	for _, member := range data.Members {
+		if member.UserID == 0 || users[member.UserID] {
+			v.fail("member %d is missing an ID or listed twice", member.UserID)
+		}
+		users[member.UserID] = true
+		email := strings.ToLower(member.Email)
+		if email == "" || emails[email] {
+			v.fail("member %d has no email or shares it with another member", member.UserID)
+		}
+		emails[email] = true
+		if !roles[member.Role] {
+			v.fail("member %d has unknown role %q", member.UserID, member.Role)
+		}
+	}
+
+	teams := make(map[uint]bool)
+	for _, team := range data.Teams {
+		if team.ID == 0 || teams[team.ID] || team.Name == "" {
+			v.fail("team %d is missing an ID or name, or listed twice", team.ID)
+		}
+		teams[team.ID] = true
+		for _, member := range team.Members {
+			if !users[member.ID] {
+				v.fail("team %d lists user %d, who is not a member", team.ID, member.ID)
+			}
+		}
+	}
+
+	tags := make(map[uint]bool)
+	tagSlugs := make(map[string]bool)
+	for _, tag := range data.Tags {
+		if tag.ID == 0 || tags[tag.ID] || tag.Name == "" {
+			v.fail("tag %d is missing an ID or name, or listed twice", tag.ID)
+		}
+		tags[tag.ID] = true
+		if !slug.Valid(tag.Slug) || tagSlugs[tag.Slug] {
+			v.fail("tag %d has an invalid or reused slug %q", tag.ID, tag.Slug)
+		}
+		tagSlugs[tag.Slug] = true
+	}
+
+	categories := make(map[uint]*uint)
+	categorySlugs := make(map[string]bool)
+	for _, category := range data.Categories {
+		if _, seen := categories[category.ID]; category.ID == 0 || seen || category.Name == "" {
+			v.fail("category %d is missing an ID or name, or listed twice", category.ID)
+		}
+		categories[category.ID] = category.ParentID
+		if !slug.Valid(category.Slug) || categorySlugs[category.Slug] {
+			v.fail("category %d has an invalid or reused slug %q", category.ID, category.Slug)
+		}
+		categorySlugs[category.Slug] = true
+	}
+	for _, category := range data.Categories {
+		// Following the parents must reach a top-level category within as
+		// many steps as there are categories.
+		parent := category.ParentID
+		for steps := 0; parent != nil; steps++ {
+			next, ok := categories[*parent]
+			if !ok {
+				v.fail("category %d has unknown parent %d", category.ID, *parent)
+				break
+			}
+			if steps == len(categories) {
+				v.fail("category %d is nested inside itself", category.ID)
+				break
+			}
+			parent = next
+		}
+	}
+
+	articles := make(map[uint]bool)
+	slugs := make(map[string]bool)
+	for _, article := range data.Articles {
+		if article.ID == 0 || articles[article.ID] || article.Title == "" {
+			v.fail("article %d is missing an ID or title, or listed twice", article.ID)
+		}
+		articles[article.ID] = true
+		if article.Slug != "" && slugs[article.Slug] {
+			v.fail("article %d reuses slug %q", article.ID, article.Slug)
+		}
+		slugs[article.Slug] = true
+		if article.TeamID != nil && !teams[*article.TeamID] {
+			v.fail("article %d belongs to unknown team %d", article.ID, *article.TeamID)
+		}
+		if article.CategoryID != nil {
+			if _, ok := categories[*article.CategoryID]; !ok {
+				v.fail("article %d is filed in unknown category %d", article.ID, *article.CategoryID)
+			}
+		}
+		for _, tag := range article.Tags {
+			if !tags[tag.ID] {
+				v.fail("article %d carries unknown tag %d", article.ID, tag.ID)
+			}
+		}
+		if article.ContentFormat != "" && !markup.ValidFormat(article.ContentFormat) {
+			v.fail("article %d has unknown content format %q", article.ID, article.ContentFormat)
+		}
+		switch article.Visibility {
+		case "", model.VisibilityPublic, model.VisibilityOrganization, model.VisibilityPrivate:
+		default:
+			v.fail("article %d has unknown visibility %q", article.ID, article.Visibility)
+		}
+	}
+
+	for _, comment := range data.Comments {
+		if !articles[comment.ArticleID] {
+			v.fail("comment %d is on unknown article %d", comment.ID, comment.ArticleID)
+		}
+	}
+
+	for i, collaborator := range data.Collaborators {
+		if !articles[collaborator.ArticleID] {
+			v.fail("collaborator %d is on unknown article %d", i+1, collaborator.ArticleID)
+		}
+		if collaborator.TeamID != nil && !teams[*collaborator.TeamID] {
+			v.fail("collaborator %d grants to unknown team %d", i+1, *collaborator.TeamID)
+		}
+		if collaborator.Role != "" && !roles[collaborator.Role] {
+			v.fail("collaborator %d grants to unknown role %q", i+1, collaborator.Role)
+		}
+	}
+
+	attachments := make(map[uint]bool)
+	for _, attachment := range data.Attachments {
+		if attachment.ID == 0 || attachments[attachment.ID] {
+			v.fail("attachment %d is missing an ID or listed twice", attachment.ID)
+		}
+		attachments[attachment.ID] = true
+		if !articles[attachment.ArticleID] {
+			v.fail("attachment %d is on unknown article %d", attachment.ID, attachment.ArticleID)
+		}
+	}
+}
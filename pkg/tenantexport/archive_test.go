@@ -0,0 +1,333 @@
+package tenantexport
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/storage"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/repository"
+)
+
+// fakeTenant hands out fixed rows in the order ReadTenant does.
+type fakeTenant struct {
+	repository.ExportRepository
+	rows []interface{}
+}
+
+func (f *fakeTenant) ReadTenant(_ context.Context, _ uint, fn func(record interface{}) error) error {
+	for _, row := range f.rows {
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const attachmentText = "meeting notes\n"
+
+func tenantRows() []interface{} {
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	published := created.Add(time.Hour)
+	teamID, categoryID, parentID := uint(30), uint(51), uint(50)
+	userID := uint(7)
+	sum := sha256.Sum256([]byte(attachmentText))
+
+	return []interface{}{
+		&model.Organization{Model: gorm.Model{ID: 1, CreatedAt: created}, Name: "Acme", Slug: "acme"},
+		&model.OrganizationSettings{CommentPolicy: "closed", Locale: "en", Features: map[string]bool{"teams": true}},
+		&model.OrganizationRole{Model: gorm.Model{ID: 10}, Name: "reviewer", Description: "Reviews articles"},
+		&model.PolicyRule{Role: "reviewer", Resource: "article", Actions: []string{"view"}},
+		&repository.Member{UserID: 7, Name: "Ada", Email: "ada@example.com", Role: model.RoleOwner, JoinedAt: created},
+		&repository.Member{UserID: 8, Name: "Bob", Email: "bob@example.com", Role: "reviewer", JoinedAt: created},
+		&model.Team{Model: gorm.Model{ID: teamID}, Name: "Writers", Members: []model.User{{Model: gorm.Model{ID: 7}}}},
+		&model.Tag{Model: gorm.Model{ID: 40}, Name: "Go", Slug: "go"},
+		&model.Category{Model: gorm.Model{ID: parentID}, Name: "Engineering", Slug: "engineering"},
+		&model.Category{Model: gorm.Model{ID: categoryID}, ParentID: &parentID, Name: "Backend", Slug: "backend"},
+		&model.Article{
+			Model: gorm.Model{ID: 60, CreatedAt: created, UpdatedAt: published},
+			Title: "Hello", Slug: "hello", Content: "# Hello", ContentFormat: "markdown",
+			Status: "published", PublishedAt: &published, Visibility: model.VisibilityPublic,
+			UserID: 7, TeamID: &teamID, CategoryID: &categoryID,
+			Tags: []model.Tag{{Model: gorm.Model{ID: 40}}},
+		},
+		&model.Comment{Model: gorm.Model{ID: 70, CreatedAt: created, UpdatedAt: created}, ArticleID: 60, AuthorID: 8, Content: "Nice"},
+		&model.ArticleCollaborator{ArticleID: 60, UserID: &userID, Permission: "edit", GrantedBy: 7},
+		&model.Attachment{
+			Model: gorm.Model{ID: 80, CreatedAt: created}, ArticleID: 60, UploadedBy: 7,
+			FileName: "notes.txt", ContentType: "text/plain", Size: int64(len(attachmentText)),
+			Checksum: hex.EncodeToString(sum[:]), StorageKey: "attachments/notes",
+		},
+	}
+}
+
+func writeArchive(t *testing.T, rows []interface{}) ([]byte, storage.Storage) {
+	store, err := storage.NewLocal(t.TempDir())
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, store.Put(ctx, "attachments/notes", bytes.NewReader([]byte(attachmentText)), int64(len(attachmentText)), "text/plain"))
+
+	var buf bytes.Buffer
+	require.NoError(t, Write(ctx, &buf, &fakeTenant{rows: rows}, store, 1))
+	return buf.Bytes(), store
+}
+
+func TestRoundTrip(t *testing.T) {
+	archive, _ := writeArchive(t, tenantRows())
+
+	data, manifest, err := Read(bytes.NewReader(archive), int64(len(archive)))
+	require.NoError(t, err)
+	assert.Equal(t, Format, manifest.Format)
+	assert.Equal(t, Version, manifest.Version)
+	assert.Equal(t, uint(1), manifest.OrganizationID)
+	assert.Equal(t, 2, manifest.Files[membersFile])
+	assert.Equal(t, 2, manifest.Files[categoriesFile])
+	revisions, listed := manifest.Files[revisionsFile]
+	assert.True(t, listed)
+	assert.Zero(t, revisions)
+
+	assert.Equal(t, "Acme", data.Organization.Name)
+	assert.Equal(t, "acme", data.Organization.Slug)
+	require.NotNil(t, data.Settings)
+	assert.Equal(t, "closed", data.Settings.CommentPolicy)
+	assert.Equal(t, map[string]bool{"teams": true}, data.Settings.Features)
+	require.Len(t, data.Roles, 1)
+	assert.Equal(t, "reviewer", data.Roles[0].Name)
+	require.Len(t, data.PolicyRules, 1)
+	assert.Equal(t, []string{"view"}, data.PolicyRules[0].Actions)
+	require.Len(t, data.Members, 2)
+	assert.Equal(t, "bob@example.com", data.Members[1].Email)
+	require.Len(t, data.Teams, 1)
+	require.Len(t, data.Teams[0].Members, 1)
+	assert.Equal(t, uint(7), data.Teams[0].Members[0].ID)
+	require.Len(t, data.Categories, 2)
+	assert.Equal(t, uint(50), *data.Categories[1].ParentID)
+
+	require.Len(t, data.Articles, 1)
+	article := data.Articles[0]
+	assert.Equal(t, "# Hello", article.Content)
+	assert.Equal(t, "markdown", article.ContentFormat)
+	assert.Equal(t, model.VisibilityPublic, article.Visibility)
+	assert.Equal(t, uint(30), *article.TeamID)
+	assert.Equal(t, uint(51), *article.CategoryID)
+	require.Len(t, article.Tags, 1)
+	assert.Equal(t, uint(40), article.Tags[0].ID)
+	assert.True(t, article.PublishedAt.Equal(*tenantRows()[10].(*model.Article).PublishedAt))
+
+	require.Len(t, data.Comments, 1)
+	assert.Equal(t, "Nice", data.Comments[0].Content)
+	require.Len(t, data.Collaborators, 1)
+	assert.Equal(t, uint(7), *data.Collaborators[0].UserID)
+
+	require.Len(t, data.Attachments, 1)
+	assert.Equal(t, attachmentPath(80), data.Attachments[0].StorageKey)
+
+	t.Run("Store Files", func(t *testing.T) {
+		target, err := storage.NewLocal(t.TempDir())
+		require.NoError(t, err)
+		ctx := context.Background()
+
+		keys, err := StoreFiles(ctx, bytes.NewReader(archive), int64(len(archive)), data, target)
+		require.NoError(t, err)
+		require.Len(t, keys, 1)
+		assert.Equal(t, keys[0], data.Attachments[0].StorageKey)
+		assert.Equal(t, "text/plain", data.Attachments[0].ContentType)
+
+		body, err := target.Get(ctx, keys[0])
+		require.NoError(t, err)
+		defer body.Close()
+		content, err := io.ReadAll(body)
+		require.NoError(t, err)
+		assert.Equal(t, attachmentText, string(content))
+	})
+}
+
+// rewrite copies archive, replacing the files in replace and dropping those
+// mapped to nil.
+func rewrite(t *testing.T, archive []byte, replace map[string][]byte) []byte {
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range zr.File {
+		content, replaced := replace[f.Name]
+		if !replaced {
+			rc, err := f.Open()
+			require.NoError(t, err)
+			content, err = io.ReadAll(rc)
+			require.NoError(t, err)
+			rc.Close()
+		} else if content == nil {
+			continue
+		}
+		w, err := zw.Create(f.Name)
+		require.NoError(t, err)
+		_, err = w.Write(content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func jsonLines(t *testing.T, records ...interface{}) []byte {
+	var buf bytes.Buffer
+	for _, record := range records {
+		require.NoError(t, json.NewEncoder(&buf).Encode(record))
+	}
+	return buf.Bytes()
+}
+
+func TestReadRejectsInvalidArchives(t *testing.T) {
+	rows := tenantRows()
+	archive, _ := writeArchive(t, rows)
+	_, baseline := toRecord(rows[10])
+	article := baseline.(articleRecord)
+
+	withArticle := func(change func(*articleRecord)) map[string][]byte {
+		changed := article
+		change(&changed)
+		return map[string][]byte{articlesFile: jsonLines(t, changed)}
+	}
+	unknownTeam, unknownCategory := uint(99), uint(98)
+
+	tests := []struct {
+		name    string
+		replace map[string][]byte
+		problem string
+	}{
+		{
+			name:    "Missing Manifest",
+			replace: map[string][]byte{manifestFile: nil},
+			problem: "manifest.json: missing",
+		},
+		{
+			name:    "Other Version",
+			replace: map[string][]byte{manifestFile: []byte(`{"format":"tenant-export","version":2}`)},
+			problem: "unsupported archive tenant-export version 2, expected tenant-export version 1",
+		},
+		{
+			name:    "Invalid Settings",
+			replace: map[string][]byte{settingsFile: jsonLines(t, settingsRecord{CommentPolicy: "members"})},
+			problem: `settings: comment_policy must be "open" or "closed"`,
+		},
+		{
+			name:    "Listed File Missing",
+			replace: map[string][]byte{commentsFile: nil},
+			problem: "comments.jsonl is listed in the manifest but missing",
+		},
+		{
+			name:    "Record Count Differs From Manifest",
+			replace: map[string][]byte{membersFile: jsonLines(t, memberRecord{UserID: 7, Email: "ada@example.com", Role: model.RoleOwner})},
+			problem: "members.jsonl has 1 records, the manifest lists 2",
+		},
+		{
+			name:    "Unknown Field",
+			replace: map[string][]byte{tagsFile: []byte(`{"id":40,"name":"Go","slug":"go","color":"blue"}` + "\n")},
+			problem: `tags.jsonl record 1: json: unknown field "color"`,
+		},
+		{
+			name:    "Unknown Team",
+			replace: withArticle(func(a *articleRecord) { a.TeamID = &unknownTeam }),
+			problem: "article 60 belongs to unknown team 99",
+		},
+		{
+			name:    "Unknown Category",
+			replace: withArticle(func(a *articleRecord) { a.CategoryID = &unknownCategory }),
+			problem: "article 60 is filed in unknown category 98",
+		},
+		{
+			name:    "Unknown Tag",
+			replace: withArticle(func(a *articleRecord) { a.TagIDs = []uint{41} }),
+			problem: "article 60 carries unknown tag 41",
+		},
+		{
+			name:    "Unknown Visibility",
+			replace: withArticle(func(a *articleRecord) { a.Visibility = "secret" }),
+			problem: `article 60 has unknown visibility "secret"`,
+		},
+		{
+			name: "Category Cycle",
+			replace: map[string][]byte{categoriesFile: jsonLines(t,
+				categoryRecord{ID: 50, ParentID: &[]uint{51}[0], Name: "Engineering", Slug: "engineering"},
+				categoryRecord{ID: 51, ParentID: &[]uint{50}[0], Name: "Backend", Slug: "backend"},
+			)},
+			problem: "category 50 is nested inside itself",
+		},
+		{
+			name: "Member With Unknown Role",
+			replace: map[string][]byte{membersFile: jsonLines(t,
+				memberRecord{UserID: 7, Email: "ada@example.com", Role: model.RoleOwner},
+				memberRecord{UserID: 8, Email: "bob@example.com", Role: "auditor"},
+			)},
+			problem: `member 8 has unknown role "auditor"`,
+		},
+		{
+			name:    "Team Member Outside The Organization",
+			replace: map[string][]byte{teamsFile: jsonLines(t, teamRecord{ID: 30, Name: "Writers", MemberIDs: []uint{9}})},
+			problem: "team 30 lists user 9, who is not a member",
+		},
+		{
+			name:    "Comment On Unknown Article",
+			replace: map[string][]byte{commentsFile: jsonLines(t, commentRecord{ID: 70, ArticleID: 61, AuthorID: 8, Content: "Nice"})},
+			problem: "comment 70 is on unknown article 61",
+		},
+		{
+			name:    "Revisions",
+			replace: map[string][]byte{revisionsFile: []byte(`{"article_id":60}` + "\n")},
+			problem: "revisions.jsonl holds 1 revisions, but articles keep no revision history",
+		},
+		{
+			name:    "Attachment File Missing",
+			replace: map[string][]byte{attachmentPath(80): nil},
+			problem: "attachment 80 has no file of 14 bytes",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			broken := rewrite(t, archive, tt.replace)
+			data, _, err := Read(bytes.NewReader(broken), int64(len(broken)))
+			assert.Nil(t, data)
+
+			var invalid *ValidationError
+			require.True(t, errors.As(err, &invalid), "got %v", err)
+			assert.Contains(t, invalid.Problems, tt.problem)
+		})
+	}
+
+	t.Run("Not A Zip Archive", func(t *testing.T) {
+		_, _, err := Read(bytes.NewReader([]byte("plain text")), 10)
+		var invalid *ValidationError
+		require.True(t, errors.As(err, &invalid))
+		assert.Equal(t, []string{"not a zip archive"}, invalid.Problems)
+	})
+}
+
+func TestStoreFilesRejectsTamperedFiles(t *testing.T) {
+	archive, _ := writeArchive(t, tenantRows())
+	tampered := rewrite(t, archive, map[string][]byte{attachmentPath(80): []byte("meeting n0tes\n")})
+
+	data, _, err := Read(bytes.NewReader(tampered), int64(len(tampered)))
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	target, err := storage.NewLocal(dir)
+	require.NoError(t, err)
+	_, err = StoreFiles(context.Background(), bytes.NewReader(tampered), int64(len(tampered)), data, target)
+
+	var invalid *ValidationError
+	require.True(t, errors.As(err, &invalid), "got %v", err)
+	assert.Equal(t, []string{"attachment 80: checksum mismatch"}, invalid.Problems)
+}
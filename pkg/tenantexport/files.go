@@ -0,0 +1,96 @@
+package tenantexport
+
+import (
+	"archive/zip"
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/media"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/storage"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/repository"
+)
+
+// StoreFiles copies the files of the attachments in data from the archive
+// read by Read to store, and points the attachments at their new storage
+// keys. Content types are sniffed again rather than taken from the archive,
+// and files that are not allowed or do not match their checksum fail with a
+// *ValidationError. It returns the keys it stored, which the caller removes
+// with RemoveFiles if the import does not go through; on error nothing is
+// left behind.
+func StoreFiles(ctx context.Context, r io.ReaderAt, size int64, data *repository.TenantData, store storage.Storage) ([]string, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	var keys []string
+	for i := range data.Attachments {
+		attachment := &data.Attachments[i]
+		key, contentType, err := storeFile(ctx, files[attachment.StorageKey], attachment.Size, attachment.Checksum, store)
+		if err != nil {
+			RemoveFiles(ctx, store, keys)
+			var invalid *ValidationError
+			if errors.As(err, &invalid) {
+				return nil, &ValidationError{Problems: []string{fmt.Sprintf("attachment %d: %s", attachment.ID, invalid.Problems[0])}}
+			}
+			return nil, fmt.Errorf("attachment %d: %w", attachment.ID, err)
+		}
+		keys = append(keys, key)
+		attachment.StorageKey = key
+		attachment.ContentType = contentType
+		// Variants are not exported; the processor generates them anew.
+		if media.Processable(contentType) {
+			attachment.ProcessingStatus = model.ProcessingPending
+		}
+	}
+	return keys, nil
+}
+
+// RemoveFiles deletes files stored by StoreFiles.
+func RemoveFiles(ctx context.Context, store storage.Storage, keys []string) {
+	for _, key := range keys {
+		_ = store.Delete(ctx, key)
+	}
+}
+
+func storeFile(ctx context.Context, f *zip.File, size int64, checksum string, store storage.Storage) (string, string, error) {
+	if f == nil {
+		return "", "", &ValidationError{Problems: []string{"file missing from archive"}}
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return "", "", err
+	}
+	defer rc.Close()
+
+	body := bufio.NewReaderSize(rc, media.SniffLength)
+	head, _ := body.Peek(media.SniffLength)
+	contentType, allowed := media.Sniff(head)
+	if !allowed {
+		return "", "", &ValidationError{Problems: []string{fmt.Sprintf("files of type %s cannot be attached", contentType)}}
+	}
+
+	key, err := media.NewKey()
+	if err != nil {
+		return "", "", err
+	}
+	hash := sha256.New()
+	if err := store.Put(ctx, key, io.TeeReader(body, hash), size, contentType); err != nil {
+		return "", "", err
+	}
+	if sum := hex.EncodeToString(hash.Sum(nil)); checksum != "" && sum != checksum {
+		_ = store.Delete(ctx, key)
+		return "", "", &ValidationError{Problems: []string{"checksum mismatch"}}
+	}
+	return key, contentType, nil
+}
@@ -0,0 +1,325 @@
+package tenantexport
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"time"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/repository"
+	"gorm.io/gorm"
+)
+
+// The records below are the archive's stable form of each entity. They are
+// decoupled from the model so that schema changes do not silently change
+// the archive format.
+
+type organizationRecord struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	Slug      string    `json:"slug"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type settingsRecord struct {
+	CommentPolicy            string          `json:"comment_policy"`
+	DefaultArticleVisibility string          `json:"default_article_visibility"`
+	DeletedAuthorArticles    string          `json:"deleted_author_articles"`
+	Locale                   string          `json:"locale"`
+	Timezone                 string          `json:"timezone"`
+	LogoURL                  string          `json:"logo_url"`
+	PrimaryColor             string          `json:"primary_color"`
+	Features                 map[string]bool `json:"features"`
+}
+
+type roleRecord struct {
+	ID          uint   `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type policyRuleRecord struct {
+	Role       string   `json:"role"`
+	Resource   string   `json:"resource"`
+	Actions    []string `json:"actions"`
+	Conditions []string `json:"conditions"`
+}
+
+type memberRecord struct {
+	UserID   uint      `json:"user_id"`
+	Name     string    `json:"name"`
+	Email    string    `json:"email"`
+	Role     string    `json:"role"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+type teamRecord struct {
+	ID          uint   `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	MemberIDs   []uint `json:"member_ids"`
+}
+
+type tagRecord struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+}
+
+type categoryRecord struct {
+	ID          uint   `json:"id"`
+	ParentID    *uint  `json:"parent_id"`
+	Name        string `json:"name"`
+	Slug        string `json:"slug"`
+	Description string `json:"description"`
+}
+
+type articleRecord struct {
+	ID            uint       `json:"id"`
+	Title         string     `json:"title"`
+	Slug          string     `json:"slug"`
+	Content       string     `json:"content"`
+	ContentFormat string     `json:"content_format,omitempty"`
+	Status        string     `json:"status"`
+	PublishedAt   *time.Time `json:"published_at,omitempty"`
+	Visibility    string     `json:"visibility,omitempty"`
+	UserID        uint       `json:"user_id"`
+	TeamID        *uint      `json:"team_id"`
+	CategoryID    *uint      `json:"category_id,omitempty"`
+	TagIDs        []uint     `json:"tag_ids,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+type commentRecord struct {
+	ID        uint      `json:"id"`
+	ArticleID uint      `json:"article_id"`
+	AuthorID  uint      `json:"author_id"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type attachmentRecord struct {
+	ID          uint      `json:"id"`
+	ArticleID   uint      `json:"article_id"`
+	UploadedBy  uint      `json:"uploaded_by"`
+	FileName    string    `json:"file_name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	Checksum    string    `json:"checksum"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type collaboratorRecord struct {
+	ArticleID  uint   `json:"article_id"`
+	UserID     *uint  `json:"user_id,omitempty"`
+	Role       string `json:"role,omitempty"`
+	TeamID     *uint  `json:"team_id,omitempty"`
+	Permission string `json:"permission"`
+	GrantedBy  uint   `json:"granted_by"`
+}
+
+// toRecord converts a row handed out by ExportRepository.ReadTenant to the
+// file it goes to and its record there.
+func toRecord(row interface{}) (string, interface{}) {
+	switch row := row.(type) {
+	case *model.Organization:
+		return organizationFile, organizationRecord{ID: row.ID, Name: row.Name, Slug: row.Slug, CreatedAt: row.CreatedAt}
+	case *model.OrganizationSettings:
+		return settingsFile, settingsRecord{
+			CommentPolicy:            row.CommentPolicy,
+			DefaultArticleVisibility: row.DefaultArticleVisibility,
+			DeletedAuthorArticles:    row.DeletedAuthorArticles,
+			Locale:                   row.Locale,
+			Timezone:                 row.Timezone,
+			LogoURL:                  row.LogoURL,
+			PrimaryColor:             row.PrimaryColor,
+			Features:                 row.Features,
+		}
+	case *model.OrganizationRole:
+		return rolesFile, roleRecord{ID: row.ID, Name: row.Name, Description: row.Description}
+	case *model.PolicyRule:
+		return policyRulesFile, policyRuleRecord{Role: row.Role, Resource: row.Resource, Actions: row.Actions, Conditions: row.Conditions}
+	case *repository.Member:
+		return membersFile, memberRecord{UserID: row.UserID, Name: row.Name, Email: row.Email, Role: row.Role, JoinedAt: row.JoinedAt}
+	case *model.Team:
+		record := teamRecord{ID: row.ID, Name: row.Name, Description: row.Description, MemberIDs: []uint{}}
+		for _, member := range row.Members {
+			record.MemberIDs = append(record.MemberIDs, member.ID)
+		}
+		return teamsFile, record
+	case *model.Tag:
+		return tagsFile, tagRecord{ID: row.ID, Name: row.Name, Slug: row.Slug}
+	case *model.Category:
+		return categoriesFile, categoryRecord{ID: row.ID, ParentID: row.ParentID, Name: row.Name, Slug: row.Slug, Description: row.Description}
+	case *model.Article:
+		record := articleRecord{
+			ID:            row.ID,
+			Title:         row.Title,
+			Slug:          row.Slug,
+			Content:       row.Content,
+			ContentFormat: row.ContentFormat,
+			Status:        row.Status,
+			PublishedAt:   row.PublishedAt,
+			Visibility:    row.Visibility,
+			UserID:        row.UserID,
+			TeamID:        row.TeamID,
+			CategoryID:    row.CategoryID,
+			CreatedAt:     row.CreatedAt,
+			UpdatedAt:     row.UpdatedAt,
+		}
+		for _, tag := range row.Tags {
+			record.TagIDs = append(record.TagIDs, tag.ID)
+		}
+		return articlesFile, record
+	case *model.Comment:
+		return commentsFile, commentRecord{
+			ID:        row.ID,
+			ArticleID: row.ArticleID,
+			AuthorID:  row.AuthorID,
+			Content:   row.Content,
+			CreatedAt: row.CreatedAt,
+			UpdatedAt: row.UpdatedAt,
+		}
+	case *model.ArticleCollaborator:
+		return collaboratorsFile, collaboratorRecord{
+			ArticleID:  row.ArticleID,
+			UserID:     row.UserID,
+			Role:       row.Role,
+			TeamID:     row.TeamID,
+			Permission: row.Permission,
+			GrantedBy:  row.GrantedBy,
+		}
+	case *model.Attachment:
+		return attachmentsFile, attachmentRecord{
+			ID:          row.ID,
+			ArticleID:   row.ArticleID,
+			UploadedBy:  row.UploadedBy,
+			FileName:    row.FileName,
+			ContentType: row.ContentType,
+			Size:        row.Size,
+			Checksum:    row.Checksum,
+			CreatedAt:   row.CreatedAt,
+		}
+	}
+	return "", nil
+}
+
+func (r organizationRecord) model() model.Organization {
+	return model.Organization{Model: gorm.Model{ID: r.ID, CreatedAt: r.CreatedAt}, Name: r.Name, Slug: r.Slug}
+}
+
+func (r settingsRecord) model() *model.OrganizationSettings {
+	return &model.OrganizationSettings{
+		CommentPolicy:            r.CommentPolicy,
+		DefaultArticleVisibility: r.DefaultArticleVisibility,
+		DeletedAuthorArticles:    r.DeletedAuthorArticles,
+		Locale:                   r.Locale,
+		Timezone:                 r.Timezone,
+		LogoURL:                  r.LogoURL,
+		PrimaryColor:             r.PrimaryColor,
+		Features:                 r.Features,
+	}
+}
+
+func (r roleRecord) model() model.OrganizationRole {
+	return model.OrganizationRole{Model: gorm.Model{ID: r.ID}, Name: r.Name, Description: r.Description}
+}
+
+func (r policyRuleRecord) model() model.PolicyRule {
+	return model.PolicyRule{Role: r.Role, Resource: r.Resource, Actions: r.Actions, Conditions: r.Conditions}
+}
+
+func (r memberRecord) model() repository.Member {
+	return repository.Member{UserID: r.UserID, Name: r.Name, Email: r.Email, Role: r.Role, JoinedAt: r.JoinedAt}
+}
+
+func (r teamRecord) model() model.Team {
+	team := model.Team{Model: gorm.Model{ID: r.ID}, Name: r.Name, Description: r.Description}
+	for _, id := range r.MemberIDs {
+		team.Members = append(team.Members, model.User{Model: gorm.Model{ID: id}})
+	}
+	return team
+}
+
+func (r tagRecord) model() model.Tag {
+	return model.Tag{Model: gorm.Model{ID: r.ID}, Name: r.Name, Slug: r.Slug}
+}
+
+func (r categoryRecord) model() model.Category {
+	return model.Category{Model: gorm.Model{ID: r.ID}, ParentID: r.ParentID, Name: r.Name, Slug: r.Slug, Description: r.Description}
+}
+
+func (r articleRecord) model() model.Article {
+	article := model.Article{
+		Model:         gorm.Model{ID: r.ID, CreatedAt: r.CreatedAt, UpdatedAt: r.UpdatedAt},
+		Title:         r.Title,
+		Slug:          r.Slug,
+		Content:       r.Content,
+		ContentFormat: r.ContentFormat,
+		Status:        r.Status,
+		PublishedAt:   r.PublishedAt,
+		Visibility:    r.Visibility,
+		UserID:        r.UserID,
+		TeamID:        r.TeamID,
+		CategoryID:    r.CategoryID,
+	}
+	for _, id := range r.TagIDs {
+		article.Tags = append(article.Tags, model.Tag{Model: gorm.Model{ID: id}})
+	}
+	return article
+}
+
+func (r commentRecord) model() model.Comment {
+	return model.Comment{
+		Model:     gorm.Model{ID: r.ID, CreatedAt: r.CreatedAt, UpdatedAt: r.UpdatedAt},
+		Content:   r.Content,
+		ArticleID: r.ArticleID,
+		AuthorID:  r.AuthorID,
+	}
+}
+
+func (r collaboratorRecord) model() model.ArticleCollaborator {
+	return model.ArticleCollaborator{
+		ArticleID:  r.ArticleID,
+		UserID:     r.UserID,
+		Role:       r.Role,
+		TeamID:     r.TeamID,
+		Permission: r.Permission,
+		GrantedBy:  r.GrantedBy,
+	}
+}
+
+// model keeps the attachment's file in the archive as its storage key until
+// StoreFiles has copied it to storage.
+func (r attachmentRecord) model() model.Attachment {
+	return model.Attachment{
+		Model:       gorm.Model{ID: r.ID, CreatedAt: r.CreatedAt},
+		ArticleID:   r.ArticleID,
+		UploadedBy:  r.UploadedBy,
+		FileName:    r.FileName,
+		ContentType: r.ContentType,
+		Size:        r.Size,
+		Checksum:    r.Checksum,
+		StorageKey:  attachmentPath(r.ID),
+	}
+}
+
+// decodeLines appends every JSON value from decoder to the slice records
+// points to and returns how many it decoded.
+func decodeLines(decoder *json.Decoder, records interface{}) (int, error) {
+	slice := reflect.ValueOf(records).Elem()
+	for n := 0; ; n++ {
+		record := reflect.New(slice.Type().Elem())
+		if err := decoder.Decode(record.Interface()); err != nil {
+			if errors.Is(err, io.EOF) {
+				return n, nil
+			}
+			return n, err
+		}
+		slice.Set(reflect.Append(slice, record.Elem()))
+	}
+}
@@ -0,0 +1,117 @@
+package tenantexport
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/storage"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/repository"
+)
+
+// Worker writes queued exports to archives in dir, one at a time.
+type Worker struct {
+	exportRepo repository.ExportRepository
+	store      storage.Storage
+	dir        string
+	queue      chan uint
+}
+
+func NewWorker(exportRepo repository.ExportRepository, store storage.Storage, dir string) *Worker {
+	return &Worker{
+		exportRepo: exportRepo,
+		store:      store,
+		dir:        dir,
+		queue:      make(chan uint, 64),
+	}
+}
+
+// Enqueue schedules a queued export. It never blocks the caller.
+func (w *Worker) Enqueue(exportID uint) {
+	select {
+	case w.queue <- exportID:
+	default:
+		go func() { w.queue <- exportID }()
+	}
+}
+
+// Path returns where the archive of a completed export is stored.
+func (w *Worker) Path(export *model.OrganizationExport) string {
+	return filepath.Join(w.dir, export.FileName)
+}
+
+// Run restarts exports left unfinished by a previous run and then processes
+// the queue until ctx is done.
+func (w *Worker) Run(ctx context.Context) {
+	if err := os.MkdirAll(w.dir, 0o750); err != nil {
+		log.Printf("Error creating export directory %s: %v", w.dir, err)
+	}
+
+	unfinished, err := w.exportRepo.GetUnfinishedExports(ctx)
+	if err != nil {
+		log.Printf("Error resuming organization exports: %v", err)
+	}
+	for _, export := range unfinished {
+		w.run(ctx, export.ID)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case exportID := <-w.queue:
+			w.run(ctx, exportID)
+		}
+	}
+}
+
+func (w *Worker) run(ctx context.Context, exportID uint) {
+	export, err := w.exportRepo.StartExport(ctx, exportID)
+	if err != nil {
+		log.Printf("Organization export %d could not start: %v", exportID, err)
+		return
+	}
+
+	fileName := fmt.Sprintf("organization-%d-export-%d.zip", export.OrganizationID, export.ID)
+	size, err := w.write(ctx, export.OrganizationID, fileName)
+	if err != nil {
+		log.Printf("Organization export %d failed: %v", export.ID, err)
+		_ = w.exportRepo.FailExport(ctx, export, err)
+		return
+	}
+	if err := w.exportRepo.CompleteExport(ctx, export, fileName, size); err != nil {
+		return
+	}
+	log.Printf("Exported organization %d to %s", export.OrganizationID, fileName)
+}
+
+// write creates the archive under a temporary name and only moves it into
+// place once it is complete, so a crash never leaves a truncated archive
+// behind under the final name.
+func (w *Worker) write(ctx context.Context, orgID uint, fileName string) (int64, error) {
+	tmp, err := os.CreateTemp(w.dir, fileName+".*.tmp")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := Write(ctx, tmp, w.exportRepo, w.store, orgID); err != nil {
+		tmp.Close()
+		return 0, err
+	}
+	info, err := tmp.Stat()
+	if err != nil {
+		tmp.Close()
+		return 0, err
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, err
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(w.dir, fileName)); err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
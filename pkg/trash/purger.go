@@ -0,0 +1,53 @@
+package trash
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/repository"
+)
+
+// PurgeInterval is how often the purger looks for expired trash.
+const PurgeInterval = time.Hour
+
+// Purger hard-deletes trashed rows once they are older than the retention
+// period.
+type Purger struct {
+	trashRepo repository.TrashRepository
+	retention time.Duration
+}
+
+func NewPurger(trashRepo repository.TrashRepository, retention time.Duration) *Purger {
+	return &Purger{
+		trashRepo: trashRepo,
+		retention: retention,
+	}
+}
+
+// Run purges right away and then every PurgeInterval until ctx is done.
+func (p *Purger) Run(ctx context.Context) {
+	ticker := time.NewTicker(PurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		p.PurgeOnce(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *Purger) PurgeOnce(ctx context.Context) {
+	purge, err := p.trashRepo.PurgeExpired(ctx, time.Now().Add(-p.retention))
+	if err != nil {
+		log.Printf("Error purging trash: %v", err)
+		return
+	}
+	if purge.Organizations+purge.Articles+purge.Comments > 0 {
+		log.Printf("Purged %d organizations, %d articles and %d comments from the trash", purge.Organizations, purge.Articles, purge.Comments)
+	}
+}
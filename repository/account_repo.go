@@ -0,0 +1,391 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrOwnsOrganization is returned when deleting the account of a user who
+	// still owns an organization. Ownership has to be transferred, or the
+	// organization deleted, first.
+	ErrOwnsOrganization = errors.New("user owns an organization")
+	// ErrDeletionPending is returned when the account is already scheduled
+	// for deletion.
+	ErrDeletionPending = errors.New("account deletion is already pending")
+)
+
+// Membership is one organization a user belongs to, as seen from the user.
+type Membership struct {
+	OrganizationID   uint      `json:"organization_id"`
+	OrganizationName string    `json:"organization_name"`
+	Role             string    `json:"role"`
+	JoinedAt         time.Time `json:"joined_at"`
+}
+
+type accountRepository struct {
+	db      *gorm.DB
+	storage TenantStorage
+}
+
+// AccountRepository serves data subject requests: exporting everything held
+// about a user and erasing their account.
+type AccountRepository interface {
+	GetOwnedOrganizations(ctx context.Context, userID uint) ([]model.Organization, error)
+	RequestDeletion(ctx context.Context, userID uint, scheduledFor time.Time) (*model.AccountDeletion, error)
+	GetLatestDeletion(ctx context.Context, userID uint) (*model.AccountDeletion, error)
+	CancelDeletion(ctx context.Context, userID uint) (*model.AccountDeletion, error)
+	GetDueDeletions(ctx context.Context, now time.Time) ([]model.AccountDeletion, error)
+	RunDeletion(ctx context.Context, id uint) (*model.AccountDeletion, error)
+	ReadUserData(ctx context.Context, userID uint, fn func(record interface{}) error) error
+}
+
+func NewAccountRepository(db *gorm.DB, storage TenantStorage) AccountRepository {
+	return &accountRepository{db: db, storage: storage}
+}
+
+func (r *accountRepository) GetOwnedOrganizations(ctx context.Context, userID uint) ([]model.Organization, error) {
+	var orgs []model.Organization
+	if err := conn(ctx, r.db).
+		Joins("JOIN user_organizations ON user_organizations.organization_id = organizations.id").
+		Where("user_organizations.user_id = ? AND user_organizations.role = ?", userID, model.RoleOwner).
+		Order("organizations.id").Find(&orgs).Error; err != nil {
+		log.Printf("Error fetching organizations owned by user ID %d: %v", userID, err)
+		return nil, err
+	}
+	return orgs, nil
+}
+
+// RequestDeletion schedules the account's deletion. Owners of an
+// organization cannot request it.
+func (r *accountRepository) RequestDeletion(ctx context.Context, userID uint, scheduledFor time.Time) (*model.AccountDeletion, error) {
+	deletion := &model.AccountDeletion{UserID: userID, Status: model.AccountDeletionPending, ScheduledFor: scheduledFor}
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&model.User{}, userID).Error; err != nil {
+			return err
+		}
+		if err := requireNotOwner(tx, userID); err != nil {
+			return err
+		}
+
+		var pending int64
+		if err := tx.Model(&model.AccountDeletion{}).
+			Where("user_id = ? AND status = ?", userID, model.AccountDeletionPending).
+			Count(&pending).Error; err != nil {
+			return err
+		}
+		if pending > 0 {
+			return ErrDeletionPending
+		}
+		return tx.Create(deletion).Error
+	})
+	if err != nil {
+		log.Printf("Error scheduling deletion of user ID %d: %v", userID, err)
+		return nil, err
+	}
+	return deletion, nil
+}
+
+// GetLatestDeletion returns the user's most recent deletion request, so a
+// failed one can be seen as well as a pending one.
+func (r *accountRepository) GetLatestDeletion(ctx context.Context, userID uint) (*model.AccountDeletion, error) {
+	var deletion model.AccountDeletion
+	if err := conn(ctx, r.db).Where("user_id = ?", userID).Order("id DESC").First(&deletion).Error; err != nil {
+		return nil, err
+	}
+	return &deletion, nil
+}
+
+func (r *accountRepository) CancelDeletion(ctx context.Context, userID uint) (*model.AccountDeletion, error) {
+	var deletion model.AccountDeletion
+	if err := conn(ctx, r.db).Where("user_id = ? AND status = ?", userID, model.AccountDeletionPending).
+		First(&deletion).Error; err != nil {
+		return nil, err
+	}
+	result := conn(ctx, r.db).Model(&deletion).Where("status = ?", model.AccountDeletionPending).
+		Update("status", model.AccountDeletionCancelled)
+	if result.Error != nil {
+		log.Printf("Error cancelling deletion of user ID %d: %v", userID, result.Error)
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	deletion.Status = model.AccountDeletionCancelled
+	return &deletion, nil
+}
+
+// GetDueDeletions returns pending deletions whose grace period is over.
+func (r *accountRepository) GetDueDeletions(ctx context.Context, now time.Time) ([]model.AccountDeletion, error) {
+	var deletions []model.AccountDeletion
+	if err := conn(ctx, r.db).Where("status = ? AND scheduled_for <= ?", model.AccountDeletionPending, now).
+		Order("id").Find(&deletions).Error; err != nil {
+		log.Printf("Error fetching due account deletions: %v", err)
+		return nil, err
+	}
+	return deletions, nil
+}
+
+// RunDeletion erases the account in one transaction. In every organization
+// the user wrote for, their articles are handed to the owner or deleted as
+// the organization's settings say. Their memberships, team memberships and
+// grants end. The audit log forgets their IPs, which entries they made and
+// what was recorded about them. The user row is kept as an anonymous
+// tombstone, so comments stay in their threads without saying who wrote
+// them.
+func (r *accountRepository) RunDeletion(ctx context.Context, id uint) (*model.AccountDeletion, error) {
+	var deletion model.AccountDeletion
+	if err := conn(ctx, r.db).First(&deletion, id).Error; err != nil {
+		log.Printf("Error fetching account deletion ID %d: %v", id, err)
+		return nil, err
+	}
+	if deletion.Status != model.AccountDeletionPending {
+		return nil, fmt.Errorf("account deletion %d is %s, not pending", deletion.ID, deletion.Status)
+	}
+
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		// Locking the request makes a concurrent cancellation either win or
+		// wait for the erasure to finish.
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("status = ?", model.AccountDeletionPending).First(&deletion, id).Error; err != nil {
+			return err
+		}
+		if err := requireNotOwner(tx, deletion.UserID); err != nil {
+			return err
+		}
+		if err := r.eraseUser(tx, deletion.UserID); err != nil {
+			return err
+		}
+
+		completed := time.Now()
+		deletion.Status = model.AccountDeletionCompleted
+		deletion.CompletedAt = &completed
+		return tx.Model(&deletion).Updates(map[string]interface{}{
+			"status":       deletion.Status,
+			"completed_at": completed,
+		}).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("account deletion %d was cancelled", id)
+	}
+	if err != nil {
+		log.Printf("Error deleting account of user ID %d: %v", deletion.UserID, err)
+		deletion.Status = model.AccountDeletionFailed
+		deletion.Error = err.Error()
+		if updateErr := conn(ctx, r.db).Model(&deletion).Updates(map[string]interface{}{
+			"status": deletion.Status,
+			"error":  deletion.Error,
+		}).Error; updateErr != nil {
+			log.Printf("Error recording failed account deletion ID %d: %v", deletion.ID, updateErr)
+		}
+		return &deletion, err
+	}
+	return &deletion, nil
+}
+
+func (r *accountRepository) eraseUser(tx *gorm.DB, userID uint) error {
+	err := r.storage.eachTenant(tx, func(tx *gorm.DB) error {
+		var orgIDs []uint
+		if err := tx.Unscoped().Model(&model.Article{}).Distinct("organization_id").
+			Where("user_id = ?", userID).Pluck("organization_id", &orgIDs).Error; err != nil {
+			return err
+		}
+		for _, orgID := range orgIDs {
+			if err := handOverArticles(tx, orgID, userID); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Exec("DELETE FROM team_members WHERE user_id = ?", userID).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("user_id = ?", userID).Delete(&model.ArticleCollaborator{}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	var memberships []model.UserOrganization
+	if err := tx.Where("user_id = ?", userID).Find(&memberships).Error; err != nil {
+		return err
+	}
+	for _, membership := range memberships {
+		orgID := membership.OrganizationID
+		if err := releaseQuota(tx, orgID, QuotaMembers, 1); err != nil {
+			return err
+		}
+		if err := tx.Create(&model.RoleChange{
+			ActorID:        userID,
+			UserID:         userID,
+			OrganizationID: &orgID,
+			OldRole:        membership.Role,
+		}).Error; err != nil {
+			return err
+		}
+	}
+	if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&model.UserOrganization{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&model.OwnershipTransfer{}).
+		Where("(from_user_id = ? OR to_user_id = ?) AND status = ?", userID, userID, model.TransferPending).
+		Updates(map[string]interface{}{"status": model.TransferCancelled, "responded_at": time.Now()}).Error; err != nil {
+		return err
+	}
+
+	// The audit log is append-only, so its personal data is cleared by the
+	// function that may bypass that, see database.ProtectAuditLog.
+	if err := tx.Exec("SELECT scrub_audit_log_user(?)", userID).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Model(&model.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"name":     "Deleted user",
+		"email":    fmt.Sprintf("deleted-user-%d@deleted.invalid", userID),
+		"password": "",
+		"role":     model.RoleMember,
+	}).Error; err != nil {
+		return err
+	}
+	return tx.Delete(&model.User{}, userID).Error
+}
+
+// handOverArticles gives the user's articles in the organization, trashed
+// ones included, to its owner, or deletes them for good when the
+// organization has chosen so or has no owner left.
+func handOverArticles(tx *gorm.DB, orgID, userID uint) error {
+	var policy string
+	if err := tx.Model(&model.OrganizationSettings{}).Select("deleted_author_articles").
+		Where("organization_id = ?", orgID).Scan(&policy).Error; err != nil {
+		return err
+	}
+
+	var ownerID uint
+	if err := tx.Model(&model.UserOrganization{}).Select("user_id").
+		Where("organization_id = ? AND role = ?", orgID, model.RoleOwner).Scan(&ownerID).Error; err != nil {
+		return err
+	}
+
+	if policy != model.DeletedAuthorDelete && ownerID != 0 {
+		return tx.Unscoped().Model(&model.Article{}).
+			Where("organization_id = ? AND user_id = ?", orgID, userID).
+			Update("user_id", ownerID).Error
+	}
+
+	articleIDs := tx.Unscoped().Model(&model.Article{}).Select("id").Where("organization_id = ? AND user_id = ?", orgID, userID)
+	var live int64
+	if err := tx.Model(&model.Article{}).Where("organization_id = ? AND user_id = ?", orgID, userID).
+		Count(&live).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("article_id IN (?)", articleIDs).Delete(&model.ArticleCollaborator{}).Error; err != nil {
+		return err
+	}
+	if err := discardAttachments(tx, orgID, articleIDs); err != nil {
+		return err
+	}
+	if err := untagArticles(tx, articleIDs); err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("article_id IN (?)", articleIDs).Delete(&model.Comment{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("resource = ? AND organization_id = ? AND target_id IN (?)", model.SlugResourceArticle, orgID, articleIDs).
+		Delete(&model.SlugRedirect{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("organization_id = ? AND user_id = ?", orgID, userID).Delete(&model.Article{}).Error; err != nil {
+		return err
+	}
+	return releaseQuota(tx, orgID, QuotaArticles, live)
+}
+
+// ReadUserData passes everything held about the user to fn: the user, then
+// their memberships, articles and comments. Records are pointers to model
+// rows, or to a Membership. Trashed articles and comments are included, as
+// they are still held until purged.
+func (r *accountRepository) ReadUserData(ctx context.Context, userID uint, fn func(record interface{}) error) error {
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var user model.User
+		if err := tx.First(&user, userID).Error; err != nil {
+			return err
+		}
+		if err := fn(&user); err != nil {
+			return err
+		}
+
+		var memberships []Membership
+		if err := tx.Table("user_organizations").
+			Joins("JOIN organizations ON organizations.id = user_organizations.organization_id").
+			Where("user_organizations.user_id = ?", userID).
+			Select("organizations.id AS organization_id, organizations.name AS organization_name, user_organizations.role, user_organizations.created_at AS joined_at").
+			Order("user_organizations.created_at").
+			Scan(&memberships).Error; err != nil {
+			return err
+		}
+		for i := range memberships {
+			if err := fn(&memberships[i]); err != nil {
+				return err
+			}
+		}
+
+		var articles []model.Article
+		if err := r.storage.eachTenant(tx, func(tx *gorm.DB) error {
+			var found []model.Article
+			if err := tx.Unscoped().Where("user_id = ?", userID).Order("id").Find(&found).Error; err != nil {
+				return err
+			}
+			articles = append(articles, found...)
+			return nil
+		}); err != nil {
+			return err
+		}
+		for i := range articles {
+			if err := fn(&articles[i]); err != nil {
+				return err
+			}
+		}
+
+		var comments []model.Comment
+		if err := r.storage.eachTenant(tx, func(tx *gorm.DB) error {
+			var found []model.Comment
+			if err := tx.Unscoped().Where("author_id = ?", userID).Order("id").Find(&found).Error; err != nil {
+				return err
+			}
+			comments = append(comments, found...)
+			return nil
+		}); err != nil {
+			return err
+		}
+		for i := range comments {
+			if err := fn(&comments[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		log.Printf("Error reading data of user ID %d: %v", userID, err)
+		return err
+	}
+	return nil
+}
+
+func requireNotOwner(tx *gorm.DB, userID uint) error {
+	var owned int64
+	if err := tx.Model(&model.UserOrganization{}).
+		Where("user_id = ? AND role = ?", userID, model.RoleOwner).Count(&owned).Error; err != nil {
+		return err
+	}
+	if owned > 0 {
+		return ErrOwnsOrganization
+	}
+	return nil
+}
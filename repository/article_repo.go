@@ -2,37 +2,87 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 	"log"
+	"time"
 
 	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/markup"
 	"gorm.io/gorm"
 )
 
 type articleRepository struct {
-	db *gorm.DB
+	db      *gorm.DB
+	storage TenantStorage
+}
+
+// ArticleFilter narrows an organization's article listing. Zero values do
+// not filter.
+type ArticleFilter struct {
+	TeamIDs []uint
+	// Tags are tag slugs; articles must carry all of them.
+	Tags        []string
+	CategoryIDs []uint
+}
+
+// PublishedFilter narrows an organization's published articles to one tag,
+// given by slug, or one author, and selects a page of them. Zero values do
+// not filter.
+type PublishedFilter struct {
+	Tag      string
+	AuthorID uint
+	Page     int
+	PageSize int
 }
 
 type ArticleRepository interface {
 	CreateArticle(ctx context.Context, article *model.Article) (*model.Article, error)
 	GetArticleByID(ctx context.Context, id uint) (*model.Article, error)
+	GetArticleBySlug(ctx context.Context, orgID uint, slug string) (*model.Article, error)
+	GetArticleByOldSlug(ctx context.Context, orgID uint, slug string) (*model.Article, error)
 	GetAllArticles(ctx context.Context) ([]model.Article, error)
-	GetArticlesByOrganization(ctx context.Context, orgID uint) ([]model.Article, error)
-	GetPublishedArticles(ctx context.Context) ([]model.Article, error)
+	GetArticlesByOrganization(ctx context.Context, orgID uint, filter ArticleFilter) ([]model.Article, error)
+	GetPublishedArticlesByOrganization(ctx context.Context, orgID uint, filter PublishedFilter) ([]model.Article, int64, error)
 	UpdateArticle(ctx context.Context, article *model.Article) (*model.Article, error)
 	DeleteArticle(ctx context.Context, id uint) error
 	GetArticlesByUserID(ctx context.Context, userID uint) ([]model.Article, error)
 	CreateComment(ctx context.Context, comment *model.Comment) (*model.Comment, error)
+	GetCommentByID(ctx context.Context, id uint) (*model.Comment, error)
 	GetCommentsByArticleID(ctx context.Context, articleID uint) ([]model.Comment, error)
 	UpdateComment(ctx context.Context, comment *model.Comment) (*model.Comment, error)
 	DeleteComment(ctx context.Context, id uint) error
 }
 
-func NewArticleRepository(db *gorm.DB) ArticleRepository {
-	return &articleRepository{db: db}
+// NewArticleRepository returns an article repository. storage is only used
+// by queries that span organizations and run outside tenant requests.
+func NewArticleRepository(db *gorm.DB, storage TenantStorage) ArticleRepository {
+	return &articleRepository{db: db, storage: storage}
 }
 
+// CreateArticle generates the article's slug from its title unless one was
+// requested. Slugs are unique within the organization. The article counts
+// against the organization's article quota.
 func (r *articleRepository) CreateArticle(ctx context.Context, article *model.Article) (*model.Article, error) {
-	if err := r.db.WithContext(ctx).Create(article).Error; err != nil {
+	renderContent(article)
+	stampPublished(article)
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		articleSlug, err := assignSlug(article.Slug, article.Title, "article", r.slugInUse(tx, article.OrganizationID, 0), r.slugRedirected(tx, article.OrganizationID))
+		if err != nil {
+			return err
+		}
+		article.Slug = articleSlug
+		if err := recordSlugChange(tx, model.SlugResourceArticle, article.OrganizationID, 0, "", article.Slug); err != nil {
+			return err
+		}
+		if err := consumeQuota(tx, article.OrganizationID, QuotaArticles, 1); err != nil {
+			return err
+		}
+		if err := tx.Omit("Tags", "Category").Create(article).Error; err != nil {
+			return err
+		}
+		return saveTaxonomy(tx, article)
+	})
+	if err != nil {
 		log.Printf("Error creating article: %v", err)
 		return nil, err
 	}
@@ -41,59 +91,228 @@ func (r *articleRepository) CreateArticle(ctx context.Context, article *model.Ar
 
 func (r *articleRepository) GetArticleByID(ctx context.Context, id uint) (*model.Article, error) {
 	var article model.Article
-	if err := r.db.WithContext(ctx).Preload("User").Preload("Organization").Preload("Comments").First(&article, id).Error; err != nil {
+	if err := conn(ctx, r.db).Preload("User").Preload("Organization").Preload("Category").Preload("Tags", orderTags).Preload("Comments").First(&article, id).Error; err != nil {
 		log.Printf("Error fetching article by ID %d: %v", id, err)
 		return nil, err
 	}
 	return &article, nil
 }
 
+func (r *articleRepository) GetArticleBySlug(ctx context.Context, orgID uint, slug string) (*model.Article, error) {
+	var article model.Article
+	if err := conn(ctx, r.db).Preload("User").Preload("Organization").Preload("Category").Preload("Tags", orderTags).Preload("Comments").
+		Where("organization_id = ? AND slug = ?", orgID, slug).First(&article).Error; err != nil {
+		log.Printf("Error fetching article by slug %s: %v", slug, err)
+		return nil, err
+	}
+	return &article, nil
+}
+
+// GetArticleByOldSlug returns the article of the organization that used to
+// be known by slug before it was renamed.
+func (r *articleRepository) GetArticleByOldSlug(ctx context.Context, orgID uint, slug string) (*model.Article, error) {
+	targetID, err := redirectTarget(conn(ctx, r.db), model.SlugResourceArticle, orgID, slug)
+	if err != nil {
+		return nil, err
+	}
+	return r.GetArticleByID(ctx, targetID)
+}
+
 func (r *articleRepository) GetAllArticles(ctx context.Context) ([]model.Article, error) {
 	var articles []model.Article
-	if err := r.db.WithContext(ctx).Preload("User").Preload("Organization").Find(&articles).Error; err != nil {
+	if err := conn(ctx, r.db).Preload("User").Preload("Organization").Preload("Category").Preload("Tags", orderTags).Find(&articles).Error; err != nil {
 		log.Printf("Error fetching all articles: %v", err)
 		return nil, err
 	}
 	return articles, nil
 }
 
-func (r *articleRepository) GetArticlesByOrganization(ctx context.Context, orgID uint) ([]model.Article, error) {
+func (r *articleRepository) GetArticlesByOrganization(ctx context.Context, orgID uint, filter ArticleFilter) ([]model.Article, error) {
 	var articles []model.Article
-	if err := r.db.WithContext(ctx).Preload("User").Preload("Organization").Where("organization_id = ?", orgID).Find(&articles).Error; err != nil {
+	query := conn(ctx, r.db).Preload("User").Preload("Organization").Preload("Category").Preload("Tags", orderTags).Where("organization_id = ?", orgID)
+	if filter.TeamIDs != nil {
+		query = query.Where("team_id IN ?", filter.TeamIDs)
+	}
+	if filter.CategoryIDs != nil {
+		query = query.Where("category_id IN ?", filter.CategoryIDs)
+	}
+	if len(filter.Tags) > 0 {
+		tagged := conn(ctx, r.db).Table("article_tags").Select("article_tags.article_id").
+			Joins("JOIN tags ON tags.id = article_tags.tag_id").
+			Where("tags.organization_id = ? AND tags.slug IN ?", orgID, filter.Tags).
+			Group("article_tags.article_id").
+			Having("COUNT(DISTINCT tags.slug) = ?", distinctCount(filter.Tags))
+		query = query.Where("articles.id IN (?)", tagged)
+	}
+	if err := query.Find(&articles).Error; err != nil {
 		log.Printf("Error fetching articles by organization ID %d: %v", orgID, err)
 		return nil, err
 	}
 	return articles, nil
 }
 
-func (r *articleRepository) GetPublishedArticles(ctx context.Context) ([]model.Article, error) {
+// GetPublishedArticlesByOrganization returns a page of the organization's
+// published public articles, most recently published first, and how many
+// match in total.
+func (r *articleRepository) GetPublishedArticlesByOrganization(ctx context.Context, orgID uint, filter PublishedFilter) ([]model.Article, int64, error) {
+	query := conn(ctx, r.db).Model(&model.Article{}).
+		Where("articles.organization_id = ? AND articles.status = ? AND articles.visibility = ?", orgID, "published", model.VisibilityPublic)
+	if filter.Tag != "" {
+		query = query.Where("articles.id IN (?)", conn(ctx, r.db).Table("article_tags").Select("article_tags.article_id").
+			Joins("JOIN tags ON tags.id = article_tags.tag_id").
+			Where("tags.organization_id = ? AND tags.slug = ?", orgID, filter.Tag))
+	}
+	if filter.AuthorID != 0 {
+		query = query.Where("articles.user_id = ?", filter.AuthorID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		log.Printf("Error counting published articles of organization ID %d: %v", orgID, err)
+		return nil, 0, err
+	}
+
 	var articles []model.Article
-	if err := r.db.WithContext(ctx).Preload("User").Preload("Organization").Where("status = ?", "published").Find(&articles).Error; err != nil {
-		log.Printf("Error fetching published articles: %v", err)
-		return nil, err
+	if err := query.Preload("User").Preload("Category").Preload("Tags", orderTags).
+		Order("articles.published_at DESC, articles.id DESC").
+		Offset((filter.Page - 1) * filter.PageSize).Limit(filter.PageSize).
+		Find(&articles).Error; err != nil {
+		log.Printf("Error fetching published articles of organization ID %d: %v", orgID, err)
+		return nil, 0, err
 	}
-	return articles, nil
+	return articles, total, nil
 }
 
+// UpdateArticle saves the article. When its slug changed, the old slug keeps
+// redirecting to it; an empty slug keeps the current one.
 func (r *articleRepository) UpdateArticle(ctx context.Context, article *model.Article) (*model.Article, error) {
-	if err := r.db.WithContext(ctx).Save(article).Error; err != nil {
+	renderContent(article)
+	stampPublished(article)
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var current model.Article
+		if err := tx.Unscoped().Select("slug").First(&current, article.ID).Error; err != nil {
+			return err
+		}
+
+		switch {
+		case article.Slug == "":
+			article.Slug = current.Slug
+		case article.Slug != current.Slug:
+			if _, err := assignSlug(article.Slug, "", "", r.slugInUse(tx, article.OrganizationID, article.ID), nil); err != nil {
+				return err
+			}
+			if err := recordSlugChange(tx, model.SlugResourceArticle, article.OrganizationID, article.ID, current.Slug, article.Slug); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Omit("Tags", "Category").Save(article).Error; err != nil {
+			return err
+		}
+		return saveTaxonomy(tx, article)
+	})
+	if err != nil {
 		log.Printf("Error updating article ID %d: %v", article.ID, err)
 		return nil, err
 	}
 	return article, nil
 }
 
+// DeleteArticle moves the article and its comments to the trash.
 func (r *articleRepository) DeleteArticle(ctx context.Context, id uint) error {
-	if err := r.db.WithContext(ctx).Delete(&model.Article{}, id).Error; err != nil {
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var article model.Article
+		if err := tx.Select("id", "organization_id").First(&article, id).Error; err != nil {
+			return err
+		}
+		if err := trashArticle(tx, id, time.Now()); err != nil {
+			return err
+		}
+		return releaseQuota(tx, article.OrganizationID, QuotaArticles, 1)
+	})
+	if err != nil {
 		log.Printf("Error deleting article ID %d: %v", id, err)
 		return err
 	}
 	return nil
 }
 
+// renderContent caches the article's content as sanitized HTML. Content
+// without a format is Markdown.
+func renderContent(article *model.Article) {
+	if article.ContentFormat == "" {
+		article.ContentFormat = model.ContentFormatMarkdown
+	}
+	article.ContentHTML = markup.Render(article.ContentFormat, article.Content)
+}
+
+// stampPublished records when the article was first published. Articles
+// that are unpublished and published again keep their original date.
+func stampPublished(article *model.Article) {
+	if article.Status == "published" && article.PublishedAt == nil {
+		now := time.Now()
+		article.PublishedAt = &now
+	}
+}
+
+// saveTaxonomy stores the article's tags and loads its category, which
+// the article is saved without.
+func saveTaxonomy(tx *gorm.DB, article *model.Article) error {
+	if err := assignTags(tx, article); err != nil {
+		return err
+	}
+	article.Category = nil
+	if article.CategoryID == nil {
+		return nil
+	}
+	var category model.Category
+	if err := tx.First(&category, *article.CategoryID).Error; err != nil {
+		return err
+	}
+	article.Category = &category
+	return nil
+}
+
+func orderTags(query *gorm.DB) *gorm.DB {
+	return query.Order("tags.name")
+}
+
+func distinctCount(values []string) int {
+	seen := make(map[string]bool)
+	for _, value := range values {
+		seen[value] = true
+	}
+	return len(seen)
+}
+
+func (r *articleRepository) slugInUse(tx *gorm.DB, orgID, selfID uint) func(string) (bool, error) {
+	return func(candidate string) (bool, error) {
+		return slugInUse(tx, &model.Article{}, selfID, candidate, func(query *gorm.DB) *gorm.DB {
+			return query.Where("organization_id = ?", orgID)
+		})
+	}
+}
+
+func (r *articleRepository) slugRedirected(tx *gorm.DB, orgID uint) func(string) (bool, error) {
+	return func(candidate string) (bool, error) {
+		return redirectExists(tx, model.SlugResourceArticle, orgID, candidate)
+	}
+}
+
+// GetArticlesByUserID returns the user's articles in every organization.
 func (r *articleRepository) GetArticlesByUserID(ctx context.Context, userID uint) ([]model.Article, error) {
 	var articles []model.Article
-	if err := r.db.WithContext(ctx).Preload("User").Preload("Organization").Where("user_id = ?", userID).Find(&articles).Error; err != nil {
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		return r.storage.eachTenant(tx, func(tx *gorm.DB) error {
+			var found []model.Article
+			if err := tx.Preload("User").Preload("Organization").Preload("Category").Preload("Tags", orderTags).Where("user_id = ?", userID).Find(&found).Error; err != nil {
+				return err
+			}
+			articles = append(articles, found...)
+			return nil
+		})
+	}, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
 		log.Printf("Error fetching articles by user ID %d: %v", userID, err)
 		return nil, err
 	}
@@ -101,16 +320,25 @@ func (r *articleRepository) GetArticlesByUserID(ctx context.Context, userID uint
 }
 
 func (r *articleRepository) CreateComment(ctx context.Context, comment *model.Comment) (*model.Comment, error) {
-	if err := r.db.WithContext(ctx).Create(comment).Error; err != nil {
+	if err := conn(ctx, r.db).Create(comment).Error; err != nil {
 		log.Printf("Error creating comment: %v", err)
 		return nil, err
 	}
 	return comment, nil
 }
 
+func (r *articleRepository) GetCommentByID(ctx context.Context, id uint) (*model.Comment, error) {
+	var comment model.Comment
+	if err := conn(ctx, r.db).First(&comment, id).Error; err != nil {
+		log.Printf("Error fetching comment by ID %d: %v", id, err)
+		return nil, err
+	}
+	return &comment, nil
+}
+
 func (r *articleRepository) GetCommentsByArticleID(ctx context.Context, articleID uint) ([]model.Comment, error) {
 	var comments []model.Comment
-	if err := r.db.WithContext(ctx).Preload("Author").Where("article_id = ?", articleID).Find(&comments).Error; err != nil {
+	if err := conn(ctx, r.db).Preload("Author").Where("article_id = ?", articleID).Find(&comments).Error; err != nil {
 		log.Printf("Error fetching comments by article ID %d: %v", articleID, err)
 		return nil, err
 	}
@@ -118,7 +346,7 @@ func (r *articleRepository) GetCommentsByArticleID(ctx context.Context, articleI
 }
 
 func (r *articleRepository) UpdateComment(ctx context.Context, comment *model.Comment) (*model.Comment, error) {
-	if err := r.db.WithContext(ctx).Save(comment).Error; err != nil {
+	if err := conn(ctx, r.db).Save(comment).Error; err != nil {
 		log.Printf("Error updating comment ID %d: %v", comment.ID, err)
 		return nil, err
 	}
@@ -126,7 +354,7 @@ func (r *articleRepository) UpdateComment(ctx context.Context, comment *model.Co
 }
 
 func (r *articleRepository) DeleteComment(ctx context.Context, id uint) error {
-	if err := r.db.WithContext(ctx).Delete(&model.Comment{}, id).Error; err != nil {
+	if err := conn(ctx, r.db).Delete(&model.Comment{}, id).Error; err != nil {
 		log.Printf("Error deleting comment ID %d: %v", id, err)
 		return err
 	}
@@ -0,0 +1,229 @@
+package repository
+
+import (
+	"context"
+	"log"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type attachmentRepository struct {
+	db *gorm.DB
+}
+
+// AttachmentRepository keeps track of the files uploaded to articles and
+// counts their size against the organization's storage quota.
+type AttachmentRepository interface {
+	CreateAttachment(ctx context.Context, attachment *model.Attachment) (*model.Attachment, error)
+	GetAttachments(ctx context.Context, orgID, articleID uint) ([]model.Attachment, error)
+	GetAttachmentByID(ctx context.Context, orgID, articleID, id uint) (*model.Attachment, error)
+	GetAttachment(ctx context.Context, id uint) (*model.Attachment, error)
+	DeleteAttachment(ctx context.Context, attachment *model.Attachment) error
+	GetDeletedAttachments(ctx context.Context, limit int) ([]model.Attachment, error)
+	PurgeAttachment(ctx context.Context, id uint) error
+	GetUnprocessedAttachments(ctx context.Context, limit int) ([]model.Attachment, error)
+	StartProcessing(ctx context.Context, id uint) (*model.Attachment, error)
+	CompleteProcessing(ctx context.Context, attachment *model.Attachment, variants []model.AttachmentVariant) error
+	FailProcessing(ctx context.Context, attachment *model.Attachment, cause error) error
+}
+
+func NewAttachmentRepository(db *gorm.DB) AttachmentRepository {
+	return &attachmentRepository{db: db}
+}
+
+// CreateAttachment records an uploaded file, failing with a
+// *QuotaExceededError when it does not fit into the organization's storage.
+func (r *attachmentRepository) CreateAttachment(ctx context.Context, attachment *model.Attachment) (*model.Attachment, error) {
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := consumeQuota(tx, attachment.OrganizationID, QuotaStorage, attachment.Size); err != nil {
+			return err
+		}
+		return tx.Create(attachment).Error
+	})
+	if err != nil {
+		log.Printf("Error creating attachment for article ID %d: %v", attachment.ArticleID, err)
+		return nil, err
+	}
+	return attachment, nil
+}
+
+func (r *attachmentRepository) GetAttachments(ctx context.Context, orgID, articleID uint) ([]model.Attachment, error) {
+	var attachments []model.Attachment
+	if err := conn(ctx, r.db).Preload("Variants").Where("organization_id = ? AND article_id = ?", orgID, articleID).
+		Order("id").Find(&attachments).Error; err != nil {
+		log.Printf("Error fetching attachments of article ID %d: %v", articleID, err)
+		return nil, err
+	}
+	return attachments, nil
+}
+
+func (r *attachmentRepository) GetAttachmentByID(ctx context.Context, orgID, articleID, id uint) (*model.Attachment, error) {
+	var attachment model.Attachment
+	if err := conn(ctx, r.db).Preload("Variants").Where("organization_id = ? AND article_id = ?", orgID, articleID).
+		First(&attachment, id).Error; err != nil {
+		log.Printf("Error fetching attachment ID %d: %v", id, err)
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+// GetAttachment looks an attachment up by ID alone, for downloads through a
+// signed URL that carry no organization context.
+func (r *attachmentRepository) GetAttachment(ctx context.Context, id uint) (*model.Attachment, error) {
+	var attachment model.Attachment
+	if err := conn(ctx, r.db).Preload("Variants").First(&attachment, id).Error; err != nil {
+		log.Printf("Error fetching attachment ID %d: %v", id, err)
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+// DeleteAttachment marks the attachment deleted and gives its size back to
+// the organization. The file is removed from storage later.
+func (r *attachmentRepository) DeleteAttachment(ctx context.Context, attachment *model.Attachment) error {
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(attachment)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return releaseQuota(tx, attachment.OrganizationID, QuotaStorage, attachment.Size)
+	})
+	if err != nil {
+		log.Printf("Error deleting attachment ID %d: %v", attachment.ID, err)
+	}
+	return err
+}
+
+// GetDeletedAttachments returns up to limit deleted attachments, with their
+// variants, whose files still have to be removed from storage.
+func (r *attachmentRepository) GetDeletedAttachments(ctx context.Context, limit int) ([]model.Attachment, error) {
+	var attachments []model.Attachment
+	if err := conn(ctx, r.db).Unscoped().Preload("Variants").Where("deleted_at IS NOT NULL").
+		Order("id").Limit(limit).Find(&attachments).Error; err != nil {
+		log.Printf("Error fetching deleted attachments: %v", err)
+		return nil, err
+	}
+	return attachments, nil
+}
+
+// PurgeAttachment removes the rows of a deleted attachment and its variants
+// once their files are gone.
+func (r *attachmentRepository) PurgeAttachment(ctx context.Context, id uint) error {
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().Where("deleted_at IS NOT NULL").Delete(&model.Attachment{}, id)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return tx.Where("attachment_id = ?", id).Delete(&model.AttachmentVariant{}).Error
+	})
+	if err != nil {
+		log.Printf("Error purging attachment ID %d: %v", id, err)
+	}
+	return err
+}
+
+// GetUnprocessedAttachments returns up to limit images that are waiting to
+// be processed or whose processing was interrupted.
+func (r *attachmentRepository) GetUnprocessedAttachments(ctx context.Context, limit int) ([]model.Attachment, error) {
+	var attachments []model.Attachment
+	if err := conn(ctx, r.db).Where("processing_status IN ?", []string{model.ProcessingPending, model.ProcessingRunning}).
+		Order("id").Limit(limit).Find(&attachments).Error; err != nil {
+		log.Printf("Error fetching unprocessed attachments: %v", err)
+		return nil, err
+	}
+	return attachments, nil
+}
+
+// StartProcessing claims an image for processing. It fails with
+// gorm.ErrRecordNotFound when the attachment is gone, not committed yet or
+// already processed.
+func (r *attachmentRepository) StartProcessing(ctx context.Context, id uint) (*model.Attachment, error) {
+	var attachment model.Attachment
+	if err := conn(ctx, r.db).First(&attachment, id).Error; err != nil {
+		return nil, err
+	}
+
+	result := conn(ctx, r.db).Model(&attachment).
+		Where("processing_status IN ?", []string{model.ProcessingPending, model.ProcessingRunning}).
+		Update("processing_status", model.ProcessingRunning)
+	if result.Error != nil {
+		log.Printf("Error starting processing of attachment ID %d: %v", id, result.Error)
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &attachment, nil
+}
+
+// CompleteProcessing records the dimensions, blurhash and variants of a
+// processed image. It fails with gorm.ErrRecordNotFound when the attachment
+// was deleted in the meantime, leaving the caller to remove the variants'
+// files.
+func (r *attachmentRepository) CompleteProcessing(ctx context.Context, attachment *model.Attachment, variants []model.AttachmentVariant) error {
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var live model.Attachment
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&live, attachment.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("attachment_id = ?", attachment.ID).Delete(&model.AttachmentVariant{}).Error; err != nil {
+			return err
+		}
+		for i := range variants {
+			variants[i].AttachmentID = attachment.ID
+		}
+		if len(variants) > 0 {
+			if err := tx.Create(&variants).Error; err != nil {
+				return err
+			}
+		}
+		attachment.ProcessingStatus = model.ProcessingCompleted
+		attachment.ProcessingError = ""
+		attachment.Variants = variants
+		return tx.Model(attachment).Updates(map[string]interface{}{
+			"processing_status": attachment.ProcessingStatus,
+			"processing_error":  "",
+			"width":             attachment.Width,
+			"height":            attachment.Height,
+			"blurhash":          attachment.Blurhash,
+		}).Error
+	})
+	if err != nil {
+		log.Printf("Error completing processing of attachment ID %d: %v", attachment.ID, err)
+	}
+	return err
+}
+
+func (r *attachmentRepository) FailProcessing(ctx context.Context, attachment *model.Attachment, cause error) error {
+	attachment.ProcessingStatus = model.ProcessingFailed
+	attachment.ProcessingError = cause.Error()
+	if err := conn(ctx, r.db).Model(attachment).Updates(map[string]interface{}{
+		"processing_status": attachment.ProcessingStatus,
+		"processing_error":  attachment.ProcessingError,
+	}).Error; err != nil {
+		log.Printf("Error recording failed processing of attachment ID %d: %v", attachment.ID, err)
+		return err
+	}
+	return nil
+}
+
+// discardAttachments marks the live attachments of the selected articles
+// deleted and releases their storage, for when the articles themselves are
+// removed for good.
+func discardAttachments(tx *gorm.DB, orgID uint, articleIDs *gorm.DB) error {
+	var size int64
+	if err := tx.Model(&model.Attachment{}).Select("COALESCE(SUM(size), 0)").
+		Where("organization_id = ? AND article_id IN (?)", orgID, articleIDs).Scan(&size).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("organization_id = ? AND article_id IN (?)", orgID, articleIDs).
+		Delete(&model.Attachment{}).Error; err != nil {
+		return err
+	}
+	return releaseQuota(tx, orgID, QuotaStorage, size)
+}
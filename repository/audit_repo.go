@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+	"gorm.io/gorm"
+)
+
+// auditExportBatch is how many entries an export reads at a time.
+const auditExportBatch = 500
+
+// AuditFilter narrows an organization's audit log. Zero values do not
+// filter; From and To bound created_at inclusively and exclusively.
+type AuditFilter struct {
+	ActorID    uint
+	Action     string
+	TargetType string
+	TargetID   uint
+	From       time.Time
+	To         time.Time
+	Page       int
+	PageSize   int
+}
+
+type auditRepository struct {
+	db *gorm.DB
+}
+
+// AuditRepository appends to and reads the audit log. It has no way to
+// change or remove entries, and the table refuses updates and deletes.
+type AuditRepository interface {
+	RecordAudit(ctx context.Context, entry *model.AuditLog) error
+	GetAuditLogs(ctx context.Context, orgID uint, filter AuditFilter) ([]model.AuditLog, int64, error)
+	EachAuditLog(ctx context.Context, orgID uint, filter AuditFilter, fn func(*model.AuditLog) error) error
+}
+
+func NewAuditRepository(db *gorm.DB) AuditRepository {
+	return &auditRepository{db: db}
+}
+
+func (r *auditRepository) RecordAudit(ctx context.Context, entry *model.AuditLog) error {
+	if err := conn(ctx, r.db).Create(entry).Error; err != nil {
+		log.Printf("Error recording audit entry %s: %v", entry.Action, err)
+		return err
+	}
+	return nil
+}
+
+func (r *auditRepository) GetAuditLogs(ctx context.Context, orgID uint, filter AuditFilter) ([]model.AuditLog, int64, error) {
+	query := r.filtered(ctx, orgID, filter)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		log.Printf("Error counting audit entries of organization ID %d: %v", orgID, err)
+		return nil, 0, err
+	}
+
+	var entries []model.AuditLog
+	if err := query.Order("id DESC").Offset((filter.Page - 1) * filter.PageSize).Limit(filter.PageSize).
+		Find(&entries).Error; err != nil {
+		log.Printf("Error fetching audit entries of organization ID %d: %v", orgID, err)
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
+
+// EachAuditLog calls fn for every matching entry, oldest first, reading them
+// in batches so exports of long histories do not load everything at once.
+// Paging fields of filter are ignored.
+func (r *auditRepository) EachAuditLog(ctx context.Context, orgID uint, filter AuditFilter, fn func(*model.AuditLog) error) error {
+	var entries []model.AuditLog
+	result := r.filtered(ctx, orgID, filter).Order("id").
+		FindInBatches(&entries, auditExportBatch, func(tx *gorm.DB, batch int) error {
+			for i := range entries {
+				if err := fn(&entries[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	if result.Error != nil {
+		log.Printf("Error exporting audit entries of organization ID %d: %v", orgID, result.Error)
+		return result.Error
+	}
+	return nil
+}
+
+func (r *auditRepository) filtered(ctx context.Context, orgID uint, filter AuditFilter) *gorm.DB {
+	query := conn(ctx, r.db).Model(&model.AuditLog{}).Where("organization_id = ?", orgID)
+	if filter.ActorID != 0 {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.TargetType != "" {
+		query = query.Where("target_type = ?", filter.TargetType)
+	}
+	if filter.TargetID != 0 {
+		query = query.Where("target_id = ?", filter.TargetID)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("created_at < ?", filter.To)
+	}
+	return query
+}
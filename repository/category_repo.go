@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+	"gorm.io/gorm"
+)
+
+// ErrCategoryCycle is returned when a category would be moved below itself.
+var ErrCategoryCycle = errors.New("a category cannot be nested inside itself or its subcategories")
+
+type categoryRepository struct {
+	db *gorm.DB
+}
+
+type CategoryRepository interface {
+	CreateCategory(ctx context.Context, category *model.Category) (*model.Category, error)
+	GetCategoryByID(ctx context.Context, id uint) (*model.Category, error)
+	GetCategoriesByOrganization(ctx context.Context, orgID uint) ([]model.Category, error)
+	GetCategorySubtreeIDs(ctx context.Context, id uint) ([]uint, error)
+	UpdateCategory(ctx context.Context, category *model.Category) (*model.Category, error)
+	DeleteCategory(ctx context.Context, category *model.Category) error
+}
+
+func NewCategoryRepository(db *gorm.DB) CategoryRepository {
+	return &categoryRepository{db: db}
+}
+
+// CreateCategory generates the category's slug from its name unless one
+// was requested. Slugs are unique within the organization.
+func (r *categoryRepository) CreateCategory(ctx context.Context, category *model.Category) (*model.Category, error) {
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		categorySlug, err := assignSlug(category.Slug, category.Name, "category", r.slugInUse(tx, category.OrganizationID, 0), noRedirects)
+		if err != nil {
+			return err
+		}
+		category.Slug = categorySlug
+		return tx.Create(category).Error
+	})
+	if err != nil {
+		log.Printf("Error creating category: %v", err)
+		return nil, err
+	}
+	return category, nil
+}
+
+func (r *categoryRepository) GetCategoryByID(ctx context.Context, id uint) (*model.Category, error) {
+	var category model.Category
+	if err := conn(ctx, r.db).First(&category, id).Error; err != nil {
+		log.Printf("Error fetching category by ID %d: %v", id, err)
+		return nil, err
+	}
+	return &category, nil
+}
+
+func (r *categoryRepository) GetCategoriesByOrganization(ctx context.Context, orgID uint) ([]model.Category, error) {
+	var categories []model.Category
+	if err := conn(ctx, r.db).Where("organization_id = ?", orgID).Order("name").Find(&categories).Error; err != nil {
+		log.Printf("Error fetching categories by organization ID %d: %v", orgID, err)
+		return nil, err
+	}
+	return categories, nil
+}
+
+// GetCategorySubtreeIDs returns the ID of the category and of every
+// category below it.
+func (r *categoryRepository) GetCategorySubtreeIDs(ctx context.Context, id uint) ([]uint, error) {
+	ids, err := categorySubtree(conn(ctx, r.db), id)
+	if err != nil {
+		log.Printf("Error fetching subcategories of category ID %d: %v", id, err)
+		return nil, err
+	}
+	return ids, nil
+}
+
+// UpdateCategory saves the category. It fails with ErrCategoryCycle when
+// the new parent is the category itself or one of its subcategories.
+func (r *categoryRepository) UpdateCategory(ctx context.Context, category *model.Category) (*model.Category, error) {
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if category.ParentID != nil {
+			subtree, err := categorySubtree(tx, category.ID)
+			if err != nil {
+				return err
+			}
+			for _, id := range subtree {
+				if id == *category.ParentID {
+					return ErrCategoryCycle
+				}
+			}
+		}
+
+		categorySlug, err := assignSlug(category.Slug, category.Name, "category", r.slugInUse(tx, category.OrganizationID, category.ID), noRedirects)
+		if err != nil {
+			return err
+		}
+		category.Slug = categorySlug
+		return tx.Save(category).Error
+	})
+	if err != nil {
+		log.Printf("Error updating category ID %d: %v", category.ID, err)
+		return nil, err
+	}
+	return category, nil
+}
+
+// DeleteCategory removes the category for good. Its subcategories and
+// articles, including trashed ones, move up to its parent.
+func (r *categoryRepository) DeleteCategory(ctx context.Context, category *model.Category) error {
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.Category{}).Where("parent_id = ?", category.ID).
+			Update("parent_id", category.ParentID).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&model.Article{}).Where("category_id = ?", category.ID).
+			Update("category_id", category.ParentID).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&model.Category{}, category.ID).Error
+	})
+	if err != nil {
+		log.Printf("Error deleting category ID %d: %v", category.ID, err)
+		return err
+	}
+	return nil
+}
+
+func (r *categoryRepository) slugInUse(tx *gorm.DB, orgID, selfID uint) func(string) (bool, error) {
+	return func(candidate string) (bool, error) {
+		return slugInUse(tx, &model.Category{}, selfID, candidate, func(query *gorm.DB) *gorm.DB {
+			return query.Where("organization_id = ?", orgID)
+		})
+	}
+}
+
+// noRedirects is the redirect check for slugs that are never redirected.
+func noRedirects(string) (bool, error) {
+	return false, nil
+}
+
+func categorySubtree(tx *gorm.DB, id uint) ([]uint, error) {
+	var ids []uint
+	err := tx.Raw(`WITH RECURSIVE subtree AS (
+		SELECT id FROM categories WHERE id = ? AND deleted_at IS NULL
+		UNION
+		SELECT categories.id FROM categories JOIN subtree ON categories.parent_id = subtree.id
+		WHERE categories.deleted_at IS NULL
+	) SELECT id FROM subtree`, id).Scan(&ids).Error
+	return ids, err
+}
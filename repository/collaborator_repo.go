@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"log"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+	"gorm.io/gorm"
+)
+
+type collaboratorRepository struct {
+	db *gorm.DB
+}
+
+type CollaboratorRepository interface {
+	AddCollaborator(ctx context.Context, collaborator *model.ArticleCollaborator) (*model.ArticleCollaborator, error)
+	GetCollaboratorByID(ctx context.Context, id uint) (*model.ArticleCollaborator, error)
+	GetCollaboratorsByArticleID(ctx context.Context, articleID uint) ([]model.ArticleCollaborator, error)
+	GetGrantsForUser(ctx context.Context, articleID, userID uint, role string) ([]model.ArticleCollaborator, error)
+	GetOrganizationGrantsForUser(ctx context.Context, orgID, userID uint, role string) ([]model.ArticleCollaborator, error)
+	DeleteCollaborator(ctx context.Context, id uint) error
+}
+
+func NewCollaboratorRepository(db *gorm.DB) CollaboratorRepository {
+	return &collaboratorRepository{db: db}
+}
+
+func (r *collaboratorRepository) AddCollaborator(ctx context.Context, collaborator *model.ArticleCollaborator) (*model.ArticleCollaborator, error) {
+	if err := conn(ctx, r.db).Create(collaborator).Error; err != nil {
+		log.Printf("Error adding collaborator to article ID %d: %v", collaborator.ArticleID, err)
+		return nil, err
+	}
+	return collaborator, nil
+}
+
+func (r *collaboratorRepository) GetCollaboratorByID(ctx context.Context, id uint) (*model.ArticleCollaborator, error) {
+	var collaborator model.ArticleCollaborator
+	if err := conn(ctx, r.db).First(&collaborator, id).Error; err != nil {
+		log.Printf("Error fetching collaborator by ID %d: %v", id, err)
+		return nil, err
+	}
+	return &collaborator, nil
+}
+
+func (r *collaboratorRepository) GetCollaboratorsByArticleID(ctx context.Context, articleID uint) ([]model.ArticleCollaborator, error) {
+	var collaborators []model.ArticleCollaborator
+	if err := conn(ctx, r.db).Where("article_id = ?", articleID).Find(&collaborators).Error; err != nil {
+		log.Printf("Error fetching collaborators by article ID %d: %v", articleID, err)
+		return nil, err
+	}
+	return collaborators, nil
+}
+
+// GetGrantsForUser returns the grants on an article that apply to a user,
+// either directly, through the organization role they hold, or through a team
+// they belong to.
+func (r *collaboratorRepository) GetGrantsForUser(ctx context.Context, articleID, userID uint, role string) ([]model.ArticleCollaborator, error) {
+	var grants []model.ArticleCollaborator
+	query := r.grantsForUser(conn(ctx, r.db).Where("article_id = ?", articleID), userID, role)
+	if err := query.Find(&grants).Error; err != nil {
+		log.Printf("Error fetching grants of user %d on article ID %d: %v", userID, articleID, err)
+		return nil, err
+	}
+	return grants, nil
+}
+
+// GetOrganizationGrantsForUser returns the grants on any of the
+// organization's articles that apply to a user, like GetGrantsForUser.
+func (r *collaboratorRepository) GetOrganizationGrantsForUser(ctx context.Context, orgID, userID uint, role string) ([]model.ArticleCollaborator, error) {
+	var grants []model.ArticleCollaborator
+	orgArticles := r.db.Model(&model.Article{}).Select("id").Where("organization_id = ?", orgID)
+	query := r.grantsForUser(conn(ctx, r.db).Where("article_id IN (?)", orgArticles), userID, role)
+	if err := query.Find(&grants).Error; err != nil {
+		log.Printf("Error fetching grants of user %d in organization ID %d: %v", userID, orgID, err)
+		return nil, err
+	}
+	return grants, nil
+}
+
+func (r *collaboratorRepository) grantsForUser(query *gorm.DB, userID uint, role string) *gorm.DB {
+	userTeams := r.db.Table("team_members").Select("team_id").Where("user_id = ?", userID)
+	if role != "" {
+		return query.Where("user_id = ? OR role = ? OR team_id IN (?)", userID, role, userTeams)
+	}
+	return query.Where("user_id = ? OR team_id IN (?)", userID, userTeams)
+}
+
+func (r *collaboratorRepository) DeleteCollaborator(ctx context.Context, id uint) error {
+	if err := conn(ctx, r.db).Delete(&model.ArticleCollaborator{}, id).Error; err != nil {
+		log.Printf("Error deleting collaborator ID %d: %v", id, err)
+		return err
+	}
+	return nil
+}
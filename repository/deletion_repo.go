@@ -0,0 +1,169 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidConfirmation is returned when a deletion is confirmed with a
+// token that does not match a pending, unexpired request.
+var ErrInvalidConfirmation = errors.New("invalid or expired confirmation token")
+
+type deletionRepository struct {
+	db      *gorm.DB
+	storage TenantStorage
+}
+
+type DeletionRepository interface {
+	CreateDeletion(ctx context.Context, deletion *model.OrganizationDeletion) (*model.OrganizationDeletion, error)
+	GetDeletionByID(ctx context.Context, id uint) (*model.OrganizationDeletion, error)
+	ConfirmDeletion(ctx context.Context, orgID uint, tokenHash string) (*model.OrganizationDeletion, error)
+	GetUnfinishedDeletions(ctx context.Context) ([]model.OrganizationDeletion, error)
+	RunDeletion(ctx context.Context, id uint) (*model.OrganizationDeletion, error)
+}
+
+func NewDeletionRepository(db *gorm.DB, storage TenantStorage) DeletionRepository {
+	return &deletionRepository{db: db, storage: storage}
+}
+
+func (r *deletionRepository) CreateDeletion(ctx context.Context, deletion *model.OrganizationDeletion) (*model.OrganizationDeletion, error) {
+	if err := conn(ctx, r.db).Create(deletion).Error; err != nil {
+		log.Printf("Error creating deletion request for organization ID %d: %v", deletion.OrganizationID, err)
+		return nil, err
+	}
+	return deletion, nil
+}
+
+func (r *deletionRepository) GetDeletionByID(ctx context.Context, id uint) (*model.OrganizationDeletion, error) {
+	var deletion model.OrganizationDeletion
+	if err := conn(ctx, r.db).First(&deletion, id).Error; err != nil {
+		log.Printf("Error fetching deletion ID %d: %v", id, err)
+		return nil, err
+	}
+	return &deletion, nil
+}
+
+// ConfirmDeletion queues the pending deletion request whose token hashes to
+// tokenHash. A token can only be used once. It commits on its own, outside
+// any tenant transaction, so the worker sees the queued job right away.
+func (r *deletionRepository) ConfirmDeletion(ctx context.Context, orgID uint, tokenHash string) (*model.OrganizationDeletion, error) {
+	var deletion model.OrganizationDeletion
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("organization_id = ? AND token_hash = ? AND status = ? AND expires_at > ?",
+			orgID, tokenHash, model.DeletionPendingConfirmation, time.Now()).First(&deletion).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidConfirmation
+		}
+		if err != nil {
+			return err
+		}
+
+		deletion.Status = model.DeletionQueued
+		return tx.Model(&deletion).Update("status", deletion.Status).Error
+	})
+	if err != nil {
+		log.Printf("Error confirming deletion of organization ID %d: %v", orgID, err)
+		return nil, err
+	}
+	return &deletion, nil
+}
+
+// GetUnfinishedDeletions returns confirmed deletions that have not finished,
+// e.g. because the server stopped while they were running.
+func (r *deletionRepository) GetUnfinishedDeletions(ctx context.Context) ([]model.OrganizationDeletion, error) {
+	var deletions []model.OrganizationDeletion
+	if err := conn(ctx, r.db).Where("status IN ?", []string{model.DeletionQueued, model.DeletionRunning}).
+		Order("id").Find(&deletions).Error; err != nil {
+		log.Printf("Error fetching unfinished deletions: %v", err)
+		return nil, err
+	}
+	return deletions, nil
+}
+
+// RunDeletion carries out a confirmed deletion. The organization, its
+// articles, their comments and its memberships go to the trash and its
+// domains are released in one transaction, so a failure leaves the
+// organization untouched. Tenant configuration is kept until the trash is
+// purged, so the organization's content can still be restored.
+func (r *deletionRepository) RunDeletion(ctx context.Context, id uint) (*model.OrganizationDeletion, error) {
+	deletion, err := r.GetDeletionByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	started := time.Now()
+	result := conn(ctx, r.db).Model(deletion).
+		Where("status IN ?", []string{model.DeletionQueued, model.DeletionRunning}).
+		Updates(map[string]interface{}{
+			"status":     model.DeletionRunning,
+			"started_at": started,
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, fmt.Errorf("deletion %d is %s, not queued", deletion.ID, deletion.Status)
+	}
+	deletion.Status = model.DeletionRunning
+	deletion.StartedAt = &started
+
+	err = conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var org model.Organization
+		if err := tx.First(&org, deletion.OrganizationID).Error; err != nil {
+			return err
+		}
+		if err := r.storage.scope(tx, &org); err != nil {
+			return err
+		}
+		if err := deleteOrganization(tx, org.ID, time.Now()); err != nil {
+			return err
+		}
+
+		completed := time.Now()
+		deletion.Status = model.DeletionCompleted
+		deletion.CompletedAt = &completed
+		return tx.Model(deletion).Updates(map[string]interface{}{
+			"status":       deletion.Status,
+			"completed_at": completed,
+		}).Error
+	})
+	if err != nil {
+		log.Printf("Error deleting organization ID %d: %v", deletion.OrganizationID, err)
+		deletion.Status = model.DeletionFailed
+		deletion.Error = err.Error()
+		if updateErr := conn(ctx, r.db).Model(deletion).Updates(map[string]interface{}{
+			"status": deletion.Status,
+			"error":  deletion.Error,
+		}).Error; updateErr != nil {
+			log.Printf("Error recording failed deletion ID %d: %v", deletion.ID, updateErr)
+		}
+		return deletion, err
+	}
+	return deletion, nil
+}
+
+// deleteOrganization trashes the organization with its content and
+// memberships, giving back their member quota, and frees its subdomain and
+// custom domains for other organizations.
+func deleteOrganization(tx *gorm.DB, id uint, now time.Time) error {
+	if err := tx.Unscoped().Where("organization_id = ?", id).Delete(&model.OrganizationDomain{}).Error; err != nil {
+		return err
+	}
+	memberships := tx.Model(&model.UserOrganization{}).Where("organization_id = ?", id).Update("deleted_at", now)
+	if memberships.Error != nil {
+		return memberships.Error
+	}
+	if err := releaseQuota(tx, id, QuotaMembers, memberships.RowsAffected); err != nil {
+		return err
+	}
+	if err := tx.Model(&model.Organization{}).Where("id = ?", id).Update("subdomain", nil).Error; err != nil {
+		return err
+	}
+	return trashOrganization(tx, id, now)
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrDomainClaimed is returned when the organization already claimed the
+	// host.
+	ErrDomainClaimed = errors.New("domain is already registered")
+	// ErrDomainTaken is returned when verifying a host another organization
+	// has already verified.
+	ErrDomainTaken = errors.New("domain is verified by another organization")
+)
+
+type domainRepository struct {
+	db *gorm.DB
+}
+
+type DomainRepository interface {
+	CreateDomain(ctx context.Context, domain *model.OrganizationDomain) (*model.OrganizationDomain, error)
+	GetDomainByID(ctx context.Context, id uint) (*model.OrganizationDomain, error)
+	GetDomainsByOrganization(ctx context.Context, orgID uint) ([]model.OrganizationDomain, error)
+	MarkDomainVerified(ctx context.Context, domain *model.OrganizationDomain) (*model.OrganizationDomain, error)
+	DeleteDomain(ctx context.Context, id uint) error
+}
+
+func NewDomainRepository(db *gorm.DB) DomainRepository {
+	return &domainRepository{db: db}
+}
+
+func (r *domainRepository) CreateDomain(ctx context.Context, domain *model.OrganizationDomain) (*model.OrganizationDomain, error) {
+	if err := conn(ctx, r.db).Create(domain).Error; err != nil {
+		log.Printf("Error creating domain %s: %v", domain.Host, err)
+		if isUniqueViolation(err) {
+			return nil, ErrDomainClaimed
+		}
+		return nil, err
+	}
+	return domain, nil
+}
+
+func (r *domainRepository) GetDomainByID(ctx context.Context, id uint) (*model.OrganizationDomain, error) {
+	var domain model.OrganizationDomain
+	if err := conn(ctx, r.db).First(&domain, id).Error; err != nil {
+		log.Printf("Error fetching domain by ID %d: %v", id, err)
+		return nil, err
+	}
+	return &domain, nil
+}
+
+func (r *domainRepository) GetDomainsByOrganization(ctx context.Context, orgID uint) ([]model.OrganizationDomain, error) {
+	var domains []model.OrganizationDomain
+	if err := conn(ctx, r.db).Where("organization_id = ?", orgID).Order("host").Find(&domains).Error; err != nil {
+		log.Printf("Error fetching domains by organization ID %d: %v", orgID, err)
+		return nil, err
+	}
+	return domains, nil
+}
+
+func (r *domainRepository) MarkDomainVerified(ctx context.Context, domain *model.OrganizationDomain) (*model.OrganizationDomain, error) {
+	now := time.Now()
+	if err := conn(ctx, r.db).Model(domain).Update("verified_at", now).Error; err != nil {
+		log.Printf("Error marking domain ID %d verified: %v", domain.ID, err)
+		if isUniqueViolation(err) {
+			return nil, ErrDomainTaken
+		}
+		return nil, err
+	}
+	domain.VerifiedAt = &now
+	return domain, nil
+}
+
+func (r *domainRepository) DeleteDomain(ctx context.Context, id uint) error {
+	if err := conn(ctx, r.db).Unscoped().Delete(&model.OrganizationDomain{}, id).Error; err != nil {
+		log.Printf("Error deleting domain ID %d: %v", id, err)
+		return err
+	}
+	return nil
+}
+
+// isUniqueViolation reports whether err comes from a unique index. Other
+// organizations' domains may be hidden by row-level security, so the index
+// is the only reliable place to detect a conflict.
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
@@ -0,0 +1,703 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrOrganizationNameTaken is returned when importing an organization under
+// a name another organization already uses.
+var ErrOrganizationNameTaken = errors.New("organization name is already taken")
+
+// errDryRun rolls back an import that was only meant to be checked.
+var errDryRun = errors.New("dry run")
+
+const tenantExportBatch = 500
+
+// TenantData is an organization's content as read from an export archive.
+// IDs are the ones the rows had where they were exported; ImportTenant maps
+// them to the IDs of the rows it creates.
+type TenantData struct {
+	Organization model.Organization
+	Settings     *model.OrganizationSettings
+	Roles        []model.OrganizationRole
+	PolicyRules  []model.PolicyRule
+	Members      []Member
+	Teams        []model.Team
+	Tags         []model.Tag
+	Categories   []model.Category
+	// Articles refer to their tags by ID only.
+	Articles      []model.Article
+	Comments      []model.Comment
+	Collaborators []model.ArticleCollaborator
+	// Attachments carry the storage key their file has here, which the
+	// importer has to store before calling ImportTenant.
+	Attachments []model.Attachment
+}
+
+// ImportOptions control how ImportTenant recreates an organization. OwnerID
+// becomes its owner, and PlanID overrides the default plan when set.
+type ImportOptions struct {
+	OwnerID uint
+	PlanID  *uint
+	DryRun  bool
+}
+
+// ImportResult reports what an import created, or would have created in a
+// dry run. Members without an account here are listed in UnmatchedUsers;
+// whatever they wrote is attributed to the owner instead.
+type ImportResult struct {
+	Organization   *model.Organization `json:"organization,omitempty"`
+	Created        map[string]int      `json:"created"`
+	UnmatchedUsers []string            `json:"unmatched_users,omitempty"`
+	DryRun         bool                `json:"dry_run"`
+}
+
+type exportRepository struct {
+	db           *gorm.DB
+	storage      TenantStorage
+	provisioners []TenantProvisioner
+}
+
+// ExportRepository tracks export jobs and moves whole organizations in and
+// out of the database.
+type ExportRepository interface {
+	CreateExport(ctx context.Context, export *model.OrganizationExport) (*model.OrganizationExport, error)
+	GetExportByID(ctx context.Context, orgID, id uint) (*model.OrganizationExport, error)
+	GetExports(ctx context.Context, orgID uint) ([]model.OrganizationExport, error)
+	GetUnfinishedExports(ctx context.Context) ([]model.OrganizationExport, error)
+	StartExport(ctx context.Context, id uint) (*model.OrganizationExport, error)
+	CompleteExport(ctx context.Context, export *model.OrganizationExport, fileName string, size int64) error
+	FailExport(ctx context.Context, export *model.OrganizationExport, cause error) error
+	ReadTenant(ctx context.Context, orgID uint, fn func(record interface{}) error) error
+	ImportTenant(ctx context.Context, data *TenantData, opts ImportOptions) (*ImportResult, error)
+}
+
+func NewExportRepository(db *gorm.DB, storage TenantStorage, provisioners ...TenantProvisioner) ExportRepository {
+	return &exportRepository{db: db, storage: storage, provisioners: provisioners}
+}
+
+// CreateExport queues an export. It commits on its own, outside any tenant
+// transaction, so the worker sees the job right away.
+func (r *exportRepository) CreateExport(ctx context.Context, export *model.OrganizationExport) (*model.OrganizationExport, error) {
+	export.Status = model.ExportQueued
+	if err := r.db.WithContext(ctx).Create(export).Error; err != nil {
+		log.Printf("Error creating export for organization ID %d: %v", export.OrganizationID, err)
+		return nil, err
+	}
+	return export, nil
+}
+
+func (r *exportRepository) GetExportByID(ctx context.Context, orgID, id uint) (*model.OrganizationExport, error) {
+	var export model.OrganizationExport
+	if err := conn(ctx, r.db).Where("organization_id = ?", orgID).First(&export, id).Error; err != nil {
+		log.Printf("Error fetching export ID %d: %v", id, err)
+		return nil, err
+	}
+	return &export, nil
+}
+
+func (r *exportRepository) GetExports(ctx context.Context, orgID uint) ([]model.OrganizationExport, error) {
+	var exports []model.OrganizationExport
+	if err := conn(ctx, r.db).Where("organization_id = ?", orgID).Order("id DESC").Find(&exports).Error; err != nil {
+		log.Printf("Error fetching exports of organization ID %d: %v", orgID, err)
+		return nil, err
+	}
+	return exports, nil
+}
+
+// GetUnfinishedExports returns exports that are queued or were interrupted
+// while running.
+func (r *exportRepository) GetUnfinishedExports(ctx context.Context) ([]model.OrganizationExport, error) {
+	var exports []model.OrganizationExport
+	if err := conn(ctx, r.db).Where("status IN ?", []string{model.ExportQueued, model.ExportRunning}).
+		Order("id").Find(&exports).Error; err != nil {
+		log.Printf("Error fetching unfinished exports: %v", err)
+		return nil, err
+	}
+	return exports, nil
+}
+
+func (r *exportRepository) StartExport(ctx context.Context, id uint) (*model.OrganizationExport, error) {
+	var export model.OrganizationExport
+	if err := conn(ctx, r.db).First(&export, id).Error; err != nil {
+		log.Printf("Error fetching export ID %d: %v", id, err)
+		return nil, err
+	}
+
+	started := time.Now()
+	result := conn(ctx, r.db).Model(&export).
+		Where("status IN ?", []string{model.ExportQueued, model.ExportRunning}).
+		Updates(map[string]interface{}{
+			"status":     model.ExportRunning,
+			"started_at": started,
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, fmt.Errorf("export %d is %s, not queued", export.ID, export.Status)
+	}
+	return &export, nil
+}
+
+func (r *exportRepository) CompleteExport(ctx context.Context, export *model.OrganizationExport, fileName string, size int64) error {
+	completed := time.Now()
+	export.Status = model.ExportCompleted
+	export.FileName = fileName
+	export.Size = size
+	export.CompletedAt = &completed
+	if err := conn(ctx, r.db).Model(export).Updates(map[string]interface{}{
+		"status":       export.Status,
+		"file_name":    fileName,
+		"size":         size,
+		"completed_at": completed,
+	}).Error; err != nil {
+		log.Printf("Error completing export ID %d: %v", export.ID, err)
+		return err
+	}
+	return nil
+}
+
+func (r *exportRepository) FailExport(ctx context.Context, export *model.OrganizationExport, cause error) error {
+	export.Status = model.ExportFailed
+	export.Error = cause.Error()
+	if err := conn(ctx, r.db).Model(export).Updates(map[string]interface{}{
+		"status": export.Status,
+		"error":  export.Error,
+	}).Error; err != nil {
+		log.Printf("Error recording failed export ID %d: %v", export.ID, err)
+		return err
+	}
+	return nil
+}
+
+// ReadTenant passes every live row of the organization to fn, one entity
+// after the other: the organization, its settings, roles, policy rules,
+// members, teams, tags, categories, articles, comments, collaborators and
+// attachments.
+// Records are pointers to model rows, or to a Member. Everything is read
+// from one snapshot, so the rows stay consistent with each other while the
+// tenant is in use.
+func (r *exportRepository) ReadTenant(ctx context.Context, orgID uint, fn func(record interface{}) error) error {
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var org model.Organization
+		if err := tx.First(&org, orgID).Error; err != nil {
+			return err
+		}
+		if err := fn(&org); err != nil {
+			return err
+		}
+
+		var settings model.OrganizationSettings
+		result := tx.Where("organization_id = ?", orgID).Limit(1).Find(&settings)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected > 0 {
+			if err := fn(&settings); err != nil {
+				return err
+			}
+		}
+
+		var roles []model.OrganizationRole
+		if err := tx.Where("organization_id = ?", orgID).Order("id").Find(&roles).Error; err != nil {
+			return err
+		}
+		for i := range roles {
+			if err := fn(&roles[i]); err != nil {
+				return err
+			}
+		}
+
+		var rules []model.PolicyRule
+		if err := tx.Where("organization_id = ?", orgID).Order("id").Find(&rules).Error; err != nil {
+			return err
+		}
+		for i := range rules {
+			if err := fn(&rules[i]); err != nil {
+				return err
+			}
+		}
+
+		var members []Member
+		if err := tx.Table("user_organizations").
+			Joins("JOIN users ON users.id = user_organizations.user_id AND users.deleted_at IS NULL").
+			Where("user_organizations.organization_id = ?", orgID).
+			Select("users.id AS user_id, users.name, users.email, user_organizations.role, user_organizations.created_at AS joined_at").
+			Order("user_organizations.created_at, users.id").
+			Scan(&members).Error; err != nil {
+			return err
+		}
+		for i := range members {
+			if err := fn(&members[i]); err != nil {
+				return err
+			}
+		}
+
+		if err := r.storage.scope(tx, &org); err != nil {
+			return err
+		}
+
+		var teams []model.Team
+		if err := tx.Preload("Members", func(db *gorm.DB) *gorm.DB {
+			return db.Select("users.id")
+		}).Where("organization_id = ?", orgID).Order("id").Find(&teams).Error; err != nil {
+			return err
+		}
+		for i := range teams {
+			if err := fn(&teams[i]); err != nil {
+				return err
+			}
+		}
+
+		var tags []model.Tag
+		if err := tx.Where("organization_id = ?", orgID).Order("id").Find(&tags).Error; err != nil {
+			return err
+		}
+		for i := range tags {
+			if err := fn(&tags[i]); err != nil {
+				return err
+			}
+		}
+
+		var categories []model.Category
+		if err := tx.Where("organization_id = ?", orgID).Order("id").Find(&categories).Error; err != nil {
+			return err
+		}
+		for i := range categories {
+			if err := fn(&categories[i]); err != nil {
+				return err
+			}
+		}
+
+		var articles []model.Article
+		if err := tx.Preload("Tags", func(db *gorm.DB) *gorm.DB {
+			return db.Select("tags.id")
+		}).Where("organization_id = ?", orgID).
+			FindInBatches(&articles, tenantExportBatch, func(*gorm.DB, int) error {
+				for i := range articles {
+					if err := fn(&articles[i]); err != nil {
+						return err
+					}
+				}
+				return nil
+			}).Error; err != nil {
+			return err
+		}
+
+		liveArticles := tx.Model(&model.Article{}).Select("id").Where("organization_id = ?", orgID)
+
+		var comments []model.Comment
+		if err := tx.Where("article_id IN (?)", liveArticles).
+			FindInBatches(&comments, tenantExportBatch, func(*gorm.DB, int) error {
+				for i := range comments {
+					if err := fn(&comments[i]); err != nil {
+						return err
+					}
+				}
+				return nil
+			}).Error; err != nil {
+			return err
+		}
+
+		var collaborators []model.ArticleCollaborator
+		if err := tx.Where("article_id IN (?)", liveArticles).
+			FindInBatches(&collaborators, tenantExportBatch, func(*gorm.DB, int) error {
+				for i := range collaborators {
+					if err := fn(&collaborators[i]); err != nil {
+						return err
+					}
+				}
+				return nil
+			}).Error; err != nil {
+			return err
+		}
+
+		var attachments []model.Attachment
+		return tx.Where("organization_id = ? AND article_id IN (?)", orgID, liveArticles).
+			FindInBatches(&attachments, tenantExportBatch, func(*gorm.DB, int) error {
+				for i := range attachments {
+					if err := fn(&attachments[i]); err != nil {
+						return err
+					}
+				}
+				return nil
+			}).Error
+	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		log.Printf("Error reading organization ID %d for export: %v", orgID, err)
+		return err
+	}
+	return nil
+}
+
+// ImportTenant recreates the organization in data as a new organization
+// owned by opts.OwnerID, in one transaction. Members are matched to existing
+// accounts by email. Usage is recounted from the imported rows rather than
+// checked against the plan, the same as when an organization changes plans.
+// A dry run does all of it and rolls back, so it also catches conflicts with
+// rows already in the database.
+func (r *exportRepository) ImportTenant(ctx context.Context, data *TenantData, opts ImportOptions) (*ImportResult, error) {
+	result := &ImportResult{Created: map[string]int{"members": 0, "collaborators": 0}, DryRun: opts.DryRun}
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		imp := &tenantImport{
+			tx:         tx,
+			data:       data,
+			ownerID:    opts.OwnerID,
+			result:     result,
+			users:      make(map[uint]uint),
+			teams:      make(map[uint]uint),
+			tags:       make(map[uint]uint),
+			categories: make(map[uint]uint),
+			articles:   make(map[uint]uint),
+		}
+		org, err := imp.organization(opts.PlanID, r.provisioners)
+		if err != nil {
+			return err
+		}
+		if err := r.storage.scope(tx, org); err != nil {
+			return err
+		}
+		if err := imp.content(org.ID); err != nil {
+			return err
+		}
+		if err := RecountUsage(tx, org.ID); err != nil {
+			return err
+		}
+
+		if opts.DryRun {
+			return errDryRun
+		}
+		result.Organization = org
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		log.Printf("Error importing organization %s: %v", data.Organization.Name, err)
+		return nil, err
+	}
+	return result, nil
+}
+
+// tenantImport maps the IDs of imported rows to the IDs they get here.
+type tenantImport struct {
+	tx      *gorm.DB
+	data    *TenantData
+	ownerID uint
+	result  *ImportResult
+
+	users      map[uint]uint
+	teams      map[uint]uint
+	tags       map[uint]uint
+	categories map[uint]uint
+	articles   map[uint]uint
+}
+
+func (imp *tenantImport) organization(planID *uint, provisioners []TenantProvisioner) (*model.Organization, error) {
+	tx := imp.tx
+	var count int64
+	if err := tx.Unscoped().Model(&model.Organization{}).Where("name = ?", imp.data.Organization.Name).
+		Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, ErrOrganizationNameTaken
+	}
+
+	// Keep the exported slug when it is free here, otherwise generate one.
+	org := &model.Organization{Name: imp.data.Organization.Name, Slug: imp.data.Organization.Slug, PlanID: planID}
+	if org.Slug != "" {
+		taken, err := orgSlugInUse(tx, 0)(org.Slug)
+		if err != nil {
+			return nil, err
+		}
+		if taken {
+			org.Slug = ""
+		}
+	}
+	if err := createOrganization(tx, org, imp.ownerID, provisioners); err != nil {
+		return nil, err
+	}
+
+	if imp.data.Settings != nil {
+		settings := *imp.data.Settings
+		settings.OrganizationID = org.ID
+		if err := tx.Create(&settings).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	for _, role := range imp.data.Roles {
+		if err := tx.Create(&model.OrganizationRole{
+			OrganizationID: org.ID,
+			Name:           role.Name,
+			Description:    role.Description,
+		}).Error; err != nil {
+			return nil, err
+		}
+	}
+	imp.result.Created["roles"] = len(imp.data.Roles)
+
+	for _, rule := range imp.data.PolicyRules {
+		if err := tx.Create(&model.PolicyRule{
+			OrganizationID: org.ID,
+			Role:           rule.Role,
+			Resource:       rule.Resource,
+			Actions:        rule.Actions,
+			Conditions:     rule.Conditions,
+		}).Error; err != nil {
+			return nil, err
+		}
+	}
+	imp.result.Created["policy_rules"] = len(imp.data.PolicyRules)
+
+	return org, imp.members(org.ID)
+}
+
+// members adds the exported members that have an account here. The
+// exported owner becomes an admin unless they are the new owner.
+func (imp *tenantImport) members(orgID uint) error {
+	tx := imp.tx
+	for _, member := range imp.data.Members {
+		var user model.User
+		result := tx.Where("email = ?", member.Email).Limit(1).Find(&user)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			imp.result.UnmatchedUsers = append(imp.result.UnmatchedUsers, member.Email)
+			continue
+		}
+		imp.users[member.UserID] = user.ID
+		if user.ID == imp.ownerID {
+			continue
+		}
+
+		role := member.Role
+		if role == model.RoleOwner {
+			role = model.RoleAdmin
+		}
+		if err := tx.Create(&model.UserOrganization{
+			UserID:         user.ID,
+			OrganizationID: orgID,
+			Role:           role,
+			CreatedAt:      member.JoinedAt,
+		}).Error; err != nil {
+			return err
+		}
+		imp.result.Created["members"]++
+	}
+	return nil
+}
+
+// content imports the tenant tables. tx must already be scoped to the new
+// organization's storage.
+func (imp *tenantImport) content(orgID uint) error {
+	tx := imp.tx
+	for _, team := range imp.data.Teams {
+		created := &model.Team{OrganizationID: orgID, Name: team.Name, Description: team.Description}
+		if err := tx.Create(created).Error; err != nil {
+			return err
+		}
+		imp.teams[team.ID] = created.ID
+		for _, member := range team.Members {
+			userID, ok := imp.users[member.ID]
+			if !ok {
+				continue
+			}
+			if err := tx.Table("team_members").Clauses(clause.OnConflict{DoNothing: true}).
+				Create(map[string]interface{}{"team_id": created.ID, "user_id": userID}).Error; err != nil {
+				return err
+			}
+		}
+	}
+	imp.result.Created["teams"] = len(imp.data.Teams)
+
+	if err := imp.taxonomy(orgID); err != nil {
+		return err
+	}
+
+	for _, article := range imp.data.Articles {
+		created := &model.Article{
+			Model:          gorm.Model{CreatedAt: article.CreatedAt, UpdatedAt: article.UpdatedAt},
+			Title:          article.Title,
+			Slug:           article.Slug,
+			Content:        article.Content,
+			ContentFormat:  article.ContentFormat,
+			Status:         article.Status,
+			PublishedAt:    article.PublishedAt,
+			Visibility:     article.Visibility,
+			OrganizationID: orgID,
+			UserID:         imp.user(article.UserID),
+		}
+		renderContent(created)
+		if created.Status == "published" && created.PublishedAt == nil {
+			// Archives from before publication dates were recorded.
+			created.PublishedAt = &created.CreatedAt
+		}
+		if article.TeamID != nil {
+			teamID, err := lookupImported(imp.teams, "team", *article.TeamID)
+			if err != nil {
+				return err
+			}
+			created.TeamID = &teamID
+		}
+		if article.CategoryID != nil {
+			categoryID, err := lookupImported(imp.categories, "category", *article.CategoryID)
+			if err != nil {
+				return err
+			}
+			created.CategoryID = &categoryID
+		}
+		if err := tx.Create(created).Error; err != nil {
+			return err
+		}
+		imp.articles[article.ID] = created.ID
+		for _, tag := range article.Tags {
+			tagID, err := lookupImported(imp.tags, "tag", tag.ID)
+			if err != nil {
+				return err
+			}
+			if err := tx.Table("article_tags").Clauses(clause.OnConflict{DoNothing: true}).
+				Create(map[string]interface{}{"article_id": created.ID, "tag_id": tagID}).Error; err != nil {
+				return err
+			}
+		}
+	}
+	imp.result.Created["articles"] = len(imp.data.Articles)
+
+	for _, comment := range imp.data.Comments {
+		articleID, err := lookupImported(imp.articles, "article", comment.ArticleID)
+		if err != nil {
+			return err
+		}
+		if err := tx.Create(&model.Comment{
+			Model:     gorm.Model{CreatedAt: comment.CreatedAt, UpdatedAt: comment.UpdatedAt},
+			Content:   comment.Content,
+			ArticleID: articleID,
+			AuthorID:  imp.user(comment.AuthorID),
+		}).Error; err != nil {
+			return err
+		}
+	}
+	imp.result.Created["comments"] = len(imp.data.Comments)
+
+	for _, collaborator := range imp.data.Collaborators {
+		articleID, err := lookupImported(imp.articles, "article", collaborator.ArticleID)
+		if err != nil {
+			return err
+		}
+		created := &model.ArticleCollaborator{
+			ArticleID:  articleID,
+			Role:       collaborator.Role,
+			Permission: collaborator.Permission,
+			GrantedBy:  imp.user(collaborator.GrantedBy),
+		}
+		if collaborator.UserID != nil {
+			userID, ok := imp.users[*collaborator.UserID]
+			if !ok {
+				// A grant to someone who is not here would go to the owner,
+				// who has access anyway.
+				continue
+			}
+			created.UserID = &userID
+		}
+		if collaborator.TeamID != nil {
+			teamID, err := lookupImported(imp.teams, "team", *collaborator.TeamID)
+			if err != nil {
+				return err
+			}
+			created.TeamID = &teamID
+		}
+		if err := tx.Create(created).Error; err != nil {
+			return err
+		}
+		imp.result.Created["collaborators"]++
+	}
+
+	for _, attachment := range imp.data.Attachments {
+		articleID, err := lookupImported(imp.articles, "article", attachment.ArticleID)
+		if err != nil {
+			return err
+		}
+		if err := tx.Create(&model.Attachment{
+			Model:            gorm.Model{CreatedAt: attachment.CreatedAt},
+			OrganizationID:   orgID,
+			ArticleID:        articleID,
+			UploadedBy:       imp.user(attachment.UploadedBy),
+			FileName:         attachment.FileName,
+			ContentType:      attachment.ContentType,
+			Size:             attachment.Size,
+			Checksum:         attachment.Checksum,
+			StorageKey:       attachment.StorageKey,
+			ProcessingStatus: attachment.ProcessingStatus,
+		}).Error; err != nil {
+			return err
+		}
+	}
+	imp.result.Created["attachments"] = len(imp.data.Attachments)
+	return nil
+}
+
+// taxonomy imports the tags and categories. Categories are created first
+// and nested once all of them have their new IDs, so parents may come after
+// their children in the archive.
+func (imp *tenantImport) taxonomy(orgID uint) error {
+	tx := imp.tx
+	for _, tag := range imp.data.Tags {
+		created := &model.Tag{OrganizationID: orgID, Name: tag.Name, Slug: tag.Slug}
+		if err := tx.Create(created).Error; err != nil {
+			return err
+		}
+		imp.tags[tag.ID] = created.ID
+	}
+	imp.result.Created["tags"] = len(imp.data.Tags)
+
+	for _, category := range imp.data.Categories {
+		created := &model.Category{OrganizationID: orgID, Name: category.Name, Slug: category.Slug, Description: category.Description}
+		if err := tx.Create(created).Error; err != nil {
+			return err
+		}
+		imp.categories[category.ID] = created.ID
+	}
+	for _, category := range imp.data.Categories {
+		if category.ParentID == nil {
+			continue
+		}
+		parentID, err := lookupImported(imp.categories, "category", *category.ParentID)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&model.Category{}).Where("id = ?", imp.categories[category.ID]).
+			Update("parent_id", parentID).Error; err != nil {
+			return err
+		}
+	}
+	imp.result.Created["categories"] = len(imp.data.Categories)
+	return nil
+}
+
+// user maps an exported user ID, falling back to the owner for users who
+// have no account here or had left the organization before the export.
+func (imp *tenantImport) user(id uint) uint {
+	if userID, ok := imp.users[id]; ok {
+		return userID
+	}
+	return imp.ownerID
+}
+
+func lookupImported(ids map[uint]uint, kind string, id uint) (uint, error) {
+	newID, ok := ids[id]
+	if !ok {
+		return 0, fmt.Errorf("unknown %s %d", kind, id)
+	}
+	return newID, nil
+}
@@ -3,39 +3,103 @@ package repository
 import (
 	"context"
 	"log"
+	"time"
 
 	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
 	"gorm.io/gorm"
 )
 
+// TenantProvisioner prepares storage for a newly created organization. It
+// runs inside the transaction that creates the organization.
+type TenantProvisioner func(tx *gorm.DB, org *model.Organization) error
+
 type orgRepository struct {
-	db *gorm.DB
+	db           *gorm.DB
+	provisioners []TenantProvisioner
 }
 
 type OrgRepository interface {
-	CreateOrganization(ctx context.Context, org *model.Organization) (*model.Organization, error)
+	CreateOrganization(ctx context.Context, org *model.Organization, ownerID uint) (*model.Organization, error)
 	GetOrganizationByID(ctx context.Context, id uint) (*model.Organization, error)
 	GetOrganizationByName(ctx context.Context, name string) (*model.Organization, error)
+	GetOrganizationBySlug(ctx context.Context, slug string) (*model.Organization, error)
+	GetOrganizationByOldSlug(ctx context.Context, slug string) (*model.Organization, error)
+	GetOrganizationBySubdomain(ctx context.Context, subdomain string) (*model.Organization, error)
+	GetOrganizationByVerifiedDomain(ctx context.Context, host string) (*model.Organization, error)
 	UpdateOrganization(ctx context.Context, org *model.Organization) (*model.Organization, error)
 	DeleteOrganization(ctx context.Context, id uint) error
 	GetAllOrganizations(ctx context.Context) ([]model.Organization, error)
 }
 
-func NewOrgRepository(db *gorm.DB) OrgRepository {
-	return &orgRepository{db: db}
+func NewOrgRepository(db *gorm.DB, provisioners ...TenantProvisioner) OrgRepository {
+	return &orgRepository{db: db, provisioners: provisioners}
 }
 
-func (r *orgRepository) CreateOrganization(ctx context.Context, org *model.Organization) (*model.Organization, error) {
-	if err := r.db.WithContext(ctx).Create(org).Error; err != nil {
+// CreateOrganization generates the organization's slug from its name unless
+// one was requested, puts it on the default plan unless it has one, makes
+// ownerID its owner and provisions its storage, all in one transaction.
+func (r *orgRepository) CreateOrganization(ctx context.Context, org *model.Organization, ownerID uint) (*model.Organization, error) {
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		return createOrganization(tx, org, ownerID, r.provisioners)
+	})
+	if err != nil {
 		log.Printf("Error creating organization: %v", err)
 		return nil, err
 	}
 	return org, nil
 }
 
+func createOrganization(tx *gorm.DB, org *model.Organization, ownerID uint, provisioners []TenantProvisioner) error {
+	orgSlug, err := assignSlug(org.Slug, org.Name, "org", orgSlugInUse(tx, 0), orgSlugRedirected(tx))
+	if err != nil {
+		return err
+	}
+	org.Slug = orgSlug
+	if err := recordSlugChange(tx, model.SlugResourceOrganization, 0, 0, "", org.Slug); err != nil {
+		return err
+	}
+
+	if org.PlanID == nil {
+		var plan model.Plan
+		err := tx.Where("is_default = ?", true).Order("id").Limit(1).Find(&plan).Error
+		if err != nil {
+			return err
+		}
+		if plan.ID != 0 {
+			org.PlanID = &plan.ID
+		}
+	}
+
+	if err := tx.Create(org).Error; err != nil {
+		return err
+	}
+
+	membership := &model.UserOrganization{UserID: ownerID, OrganizationID: org.ID, Role: model.RoleOwner}
+	if err := tx.Create(membership).Error; err != nil {
+		return err
+	}
+	if err := consumeQuota(tx, org.ID, QuotaMembers, 1); err != nil {
+		return err
+	}
+	if err := tx.Create(&model.RoleChange{
+		ActorID:        ownerID,
+		UserID:         ownerID,
+		OrganizationID: &org.ID,
+		NewRole:        model.RoleOwner,
+	}).Error; err != nil {
+		return err
+	}
+	for _, provision := range provisioners {
+		if err := provision(tx, org); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (r *orgRepository) GetOrganizationByID(ctx context.Context, id uint) (*model.Organization, error) {
 	var org model.Organization
-	if err := r.db.WithContext(ctx).First(&org, id).Error; err != nil {
+	if err := conn(ctx, r.db).First(&org, id).Error; err != nil {
 		log.Printf("Error fetching organization by ID %d: %v", id, err)
 		return nil, err
 	}
@@ -44,31 +108,111 @@ func (r *orgRepository) GetOrganizationByID(ctx context.Context, id uint) (*mode
 
 func (r *orgRepository) GetOrganizationByName(ctx context.Context, name string) (*model.Organization, error) {
 	var org model.Organization
-	if err := r.db.WithContext(ctx).Where("name = ?", name).First(&org).Error; err != nil {
+	if err := conn(ctx, r.db).Where("name = ?", name).First(&org).Error; err != nil {
 		log.Printf("Error fetching organization by name %s: %v", name, err)
 		return nil, err
 	}
 	return &org, nil
 }
 
+func (r *orgRepository) GetOrganizationBySubdomain(ctx context.Context, subdomain string) (*model.Organization, error) {
+	var org model.Organization
+	if err := conn(ctx, r.db).Where("subdomain = ?", subdomain).First(&org).Error; err != nil {
+		log.Printf("Error fetching organization by subdomain %s: %v", subdomain, err)
+		return nil, err
+	}
+	return &org, nil
+}
+
+func (r *orgRepository) GetOrganizationByVerifiedDomain(ctx context.Context, host string) (*model.Organization, error) {
+	var org model.Organization
+	if err := conn(ctx, r.db).Joins("JOIN organization_domains ON organization_domains.organization_id = organizations.id").
+		Where("organization_domains.host = ? AND organization_domains.verified_at IS NOT NULL AND organization_domains.deleted_at IS NULL", host).
+		First(&org).Error; err != nil {
+		log.Printf("Error fetching organization by domain %s: %v", host, err)
+		return nil, err
+	}
+	return &org, nil
+}
+
+func (r *orgRepository) GetOrganizationBySlug(ctx context.Context, slug string) (*model.Organization, error) {
+	var org model.Organization
+	if err := conn(ctx, r.db).Where("slug = ?", slug).First(&org).Error; err != nil {
+		log.Printf("Error fetching organization by slug %s: %v", slug, err)
+		return nil, err
+	}
+	return &org, nil
+}
+
+// GetOrganizationByOldSlug returns the organization that used to be known by
+// slug before it was renamed.
+func (r *orgRepository) GetOrganizationByOldSlug(ctx context.Context, slug string) (*model.Organization, error) {
+	db := conn(ctx, r.db)
+	targetID, err := redirectTarget(db, model.SlugResourceOrganization, 0, slug)
+	if err != nil {
+		return nil, err
+	}
+	return r.GetOrganizationByID(ctx, targetID)
+}
+
+// UpdateOrganization saves the organization. When its slug changed, the old
+// slug keeps redirecting to it; an empty slug keeps the current one.
 func (r *orgRepository) UpdateOrganization(ctx context.Context, org *model.Organization) (*model.Organization, error) {
-	if err := r.db.WithContext(ctx).Save(org).Error; err != nil {
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var current model.Organization
+		if err := tx.Unscoped().Select("slug").First(&current, org.ID).Error; err != nil {
+			return err
+		}
+
+		switch {
+		case org.Slug == "":
+			org.Slug = current.Slug
+		case org.Slug != current.Slug:
+			if _, err := assignSlug(org.Slug, "", "", orgSlugInUse(tx, org.ID), nil); err != nil {
+				return err
+			}
+			if err := recordSlugChange(tx, model.SlugResourceOrganization, 0, org.ID, current.Slug, org.Slug); err != nil {
+				return err
+			}
+		}
+
+		return tx.Save(org).Error
+	})
+	if err != nil {
 		log.Printf("Error updating organization ID %d: %v", org.ID, err)
 		return nil, err
 	}
 	return org, nil
 }
 
+func orgSlugInUse(tx *gorm.DB, selfID uint) func(string) (bool, error) {
+	return func(candidate string) (bool, error) {
+		return slugInUse(tx, &model.Organization{}, selfID, candidate, nil)
+	}
+}
+
+func orgSlugRedirected(tx *gorm.DB) func(string) (bool, error) {
+	return func(candidate string) (bool, error) {
+		return redirectExists(tx, model.SlugResourceOrganization, 0, candidate)
+	}
+}
+
+// DeleteOrganization moves the organization, its articles, their comments
+// and its memberships to the trash and releases its domains.
 func (r *orgRepository) DeleteOrganization(ctx context.Context, id uint) error {
-	if err := r.db.WithContext(ctx).Delete(&model.Organization{}, id).Error; err != nil {
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		return deleteOrganization(tx, id, time.Now())
+	})
+	if err != nil {
 		log.Printf("Error deleting organization ID %d: %v", id, err)
+		return err
 	}
 	return nil
 }
 
 func (r *orgRepository) GetAllOrganizations(ctx context.Context) ([]model.Organization, error) {
 	var orgs []model.Organization
-	if err := r.db.WithContext(ctx).Find(&orgs).Error; err != nil {
+	if err := conn(ctx, r.db).Find(&orgs).Error; err != nil {
 		log.Printf("Error fetching all organizations: %v", err)
 		return nil, err
 	}
@@ -0,0 +1,248 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrNotMember is returned when ownership would go to someone who is
+	// not a member of the organization.
+	ErrNotMember = errors.New("user is not a member of the organization")
+	// ErrOwnerChanged is returned when accepting a transfer offered by
+	// someone who is no longer the owner.
+	ErrOwnerChanged = errors.New("organization owner has changed")
+)
+
+type ownershipRepository struct {
+	db *gorm.DB
+}
+
+// OwnershipRepository keeps exactly one owner per organization. The owner is
+// the member whose membership role is model.RoleOwner; ownership moves in a
+// single transaction that demotes the previous owner to admin.
+type OwnershipRepository interface {
+	GetOwner(ctx context.Context, orgID uint) (*model.UserOrganization, error)
+	CreateTransfer(ctx context.Context, transfer *model.OwnershipTransfer) (*model.OwnershipTransfer, error)
+	GetTransferByID(ctx context.Context, id uint) (*model.OwnershipTransfer, error)
+	GetPendingTransfers(ctx context.Context, orgID uint) ([]model.OwnershipTransfer, error)
+	GetIncomingTransfers(ctx context.Context, userID uint) ([]model.OwnershipTransfer, error)
+	AcceptTransfer(ctx context.Context, transfer *model.OwnershipTransfer) (*model.OwnershipTransfer, error)
+	CloseTransfer(ctx context.Context, transfer *model.OwnershipTransfer, status string) (*model.OwnershipTransfer, error)
+	AssignOwner(ctx context.Context, actorID, orgID, userID uint) error
+}
+
+func NewOwnershipRepository(db *gorm.DB) OwnershipRepository {
+	return &ownershipRepository{db: db}
+}
+
+func (r *ownershipRepository) GetOwner(ctx context.Context, orgID uint) (*model.UserOrganization, error) {
+	var membership model.UserOrganization
+	if err := conn(ctx, r.db).Where("organization_id = ? AND role = ?", orgID, model.RoleOwner).First(&membership).Error; err != nil {
+		return nil, err
+	}
+	return &membership, nil
+}
+
+// CreateTransfer offers ownership to transfer.ToUserID, replacing any offer
+// still pending for the organization.
+func (r *ownershipRepository) CreateTransfer(ctx context.Context, transfer *model.OwnershipTransfer) (*model.OwnershipTransfer, error) {
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := requireMember(tx, transfer.OrganizationID, transfer.ToUserID); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if err := tx.Model(&model.OwnershipTransfer{}).
+			Where("organization_id = ? AND status = ?", transfer.OrganizationID, model.TransferPending).
+			Updates(map[string]interface{}{"status": model.TransferCancelled, "responded_at": now}).Error; err != nil {
+			return err
+		}
+
+		transfer.Status = model.TransferPending
+		return tx.Create(transfer).Error
+	})
+	if err != nil {
+		log.Printf("Error creating ownership transfer for organization ID %d: %v", transfer.OrganizationID, err)
+		return nil, err
+	}
+	return transfer, nil
+}
+
+func (r *ownershipRepository) GetTransferByID(ctx context.Context, id uint) (*model.OwnershipTransfer, error) {
+	var transfer model.OwnershipTransfer
+	if err := conn(ctx, r.db).First(&transfer, id).Error; err != nil {
+		log.Printf("Error fetching ownership transfer ID %d: %v", id, err)
+		return nil, err
+	}
+	return &transfer, nil
+}
+
+func (r *ownershipRepository) GetPendingTransfers(ctx context.Context, orgID uint) ([]model.OwnershipTransfer, error) {
+	var transfers []model.OwnershipTransfer
+	if err := conn(ctx, r.db).Where("organization_id = ? AND status = ?", orgID, model.TransferPending).
+		Order("created_at DESC").Find(&transfers).Error; err != nil {
+		log.Printf("Error fetching ownership transfers for organization ID %d: %v", orgID, err)
+		return nil, err
+	}
+	return transfers, nil
+}
+
+func (r *ownershipRepository) GetIncomingTransfers(ctx context.Context, userID uint) ([]model.OwnershipTransfer, error) {
+	var transfers []model.OwnershipTransfer
+	if err := conn(ctx, r.db).Where("to_user_id = ? AND status = ?", userID, model.TransferPending).
+		Order("created_at DESC").Find(&transfers).Error; err != nil {
+		log.Printf("Error fetching ownership transfers for user ID %d: %v", userID, err)
+		return nil, err
+	}
+	return transfers, nil
+}
+
+// AcceptTransfer makes the recipient the owner and the previous owner an
+// admin, provided the offer is still pending, its sender still owns the
+// organization and the recipient is still a member.
+func (r *ownershipRepository) AcceptTransfer(ctx context.Context, transfer *model.OwnershipTransfer) (*model.OwnershipTransfer, error) {
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("status = ?", model.TransferPending).First(transfer, transfer.ID).Error; err != nil {
+			return err
+		}
+
+		var owner model.UserOrganization
+		err := tx.Where("organization_id = ? AND role = ?", transfer.OrganizationID, model.RoleOwner).First(&owner).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && owner.UserID != transfer.FromUserID) {
+			return ErrOwnerChanged
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := moveOwnership(tx, transfer.FromUserID, transfer.OrganizationID, transfer.ToUserID, transfer.ToUserID); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		transfer.Status = model.TransferAccepted
+		transfer.RespondedAt = &now
+		return tx.Model(transfer).Updates(map[string]interface{}{"status": transfer.Status, "responded_at": now}).Error
+	})
+	if err != nil {
+		log.Printf("Error accepting ownership transfer ID %d: %v", transfer.ID, err)
+		return nil, err
+	}
+	return transfer, nil
+}
+
+// CloseTransfer declines or cancels a pending offer.
+func (r *ownershipRepository) CloseTransfer(ctx context.Context, transfer *model.OwnershipTransfer, status string) (*model.OwnershipTransfer, error) {
+	now := time.Now()
+	result := conn(ctx, r.db).Model(transfer).Where("status = ?", model.TransferPending).
+		Updates(map[string]interface{}{"status": status, "responded_at": now})
+	if result.Error != nil {
+		log.Printf("Error closing ownership transfer ID %d: %v", transfer.ID, result.Error)
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	transfer.Status = status
+	transfer.RespondedAt = &now
+	return transfer, nil
+}
+
+// AssignOwner makes userID the owner without an offer. It is meant for
+// platform administrators recovering organizations whose owner is gone. An
+// organization without an owner, such as one that had no members when
+// owners were backfilled, may get an owner who is not a member yet.
+func (r *ownershipRepository) AssignOwner(ctx context.Context, actorID, orgID, userID uint) error {
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var owner model.UserOrganization
+		err := tx.Where("organization_id = ? AND role = ?", orgID, model.RoleOwner).First(&owner).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err := requireMember(tx, orgID, userID)
+			if errors.Is(err, ErrNotMember) {
+				return addOwner(tx, actorID, orgID, userID)
+			}
+			if err != nil {
+				return err
+			}
+		} else if err != nil {
+			return err
+		}
+		return moveOwnership(tx, owner.UserID, orgID, userID, actorID)
+	})
+	if err != nil {
+		log.Printf("Error assigning owner of organization ID %d: %v", orgID, err)
+		return err
+	}
+	return nil
+}
+
+// moveOwnership demotes the current owner, if any, to admin and promotes
+// the new owner, recording both role changes.
+func moveOwnership(tx *gorm.DB, fromUserID, orgID, toUserID, actorID uint) error {
+	if err := requireMember(tx, orgID, toUserID); err != nil {
+		return err
+	}
+
+	if fromUserID != 0 && fromUserID != toUserID {
+		if err := changeMembershipRole(tx, actorID, fromUserID, orgID, model.RoleAdmin); err != nil {
+			return err
+		}
+	}
+	return changeMembershipRole(tx, actorID, toUserID, orgID, model.RoleOwner)
+}
+
+func changeMembershipRole(tx *gorm.DB, actorID, userID, orgID uint, role string) error {
+	var membership model.UserOrganization
+	if err := tx.Where("user_id = ? AND organization_id = ?", userID, orgID).First(&membership).Error; err != nil {
+		return err
+	}
+	if membership.Role == role {
+		return nil
+	}
+
+	if err := tx.Model(&model.UserOrganization{}).
+		Where("user_id = ? AND organization_id = ?", userID, orgID).
+		Update("role", role).Error; err != nil {
+		return err
+	}
+	return tx.Create(&model.RoleChange{
+		ActorID:        actorID,
+		UserID:         userID,
+		OrganizationID: &orgID,
+		OldRole:        membership.Role,
+		NewRole:        role,
+	}).Error
+}
+
+func addOwner(tx *gorm.DB, actorID, orgID, userID uint) error {
+	if err := tx.Create(&model.UserOrganization{UserID: userID, OrganizationID: orgID, Role: model.RoleOwner}).Error; err != nil {
+		return err
+	}
+	if err := consumeQuota(tx, orgID, QuotaMembers, 1); err != nil {
+		return err
+	}
+	return tx.Create(&model.RoleChange{
+		ActorID:        actorID,
+		UserID:         userID,
+		OrganizationID: &orgID,
+		NewRole:        model.RoleOwner,
+	}).Error
+}
+
+func requireMember(tx *gorm.DB, orgID, userID uint) error {
+	var count int64
+	if err := tx.Model(&model.UserOrganization{}).
+		Where("user_id = ? AND organization_id = ?", userID, orgID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrNotMember
+	}
+	return nil
+}
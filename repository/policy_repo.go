@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"log"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+	"gorm.io/gorm"
+)
+
+type policyRepository struct {
+	db *gorm.DB
+}
+
+type PolicyRepository interface {
+	GetPolicyRules(ctx context.Context, orgID uint) ([]model.PolicyRule, error)
+	ReplacePolicyRules(ctx context.Context, orgID uint, rules []model.PolicyRule) ([]model.PolicyRule, error)
+}
+
+func NewPolicyRepository(db *gorm.DB) PolicyRepository {
+	return &policyRepository{db: db}
+}
+
+func (r *policyRepository) GetPolicyRules(ctx context.Context, orgID uint) ([]model.PolicyRule, error) {
+	var rules []model.PolicyRule
+	if err := conn(ctx, r.db).Where("organization_id = ?", orgID).Order("id").Find(&rules).Error; err != nil {
+		log.Printf("Error fetching policy rules for organization ID %d: %v", orgID, err)
+		return nil, err
+	}
+	return rules, nil
+}
+
+// ReplacePolicyRules swaps the organization's rule set in one transaction so
+// a policy is never observed half-written. Rules of custom roles are owned by
+// those roles and left untouched.
+func (r *policyRepository) ReplacePolicyRules(ctx context.Context, orgID uint, rules []model.PolicyRule) ([]model.PolicyRule, error) {
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		customRoles := tx.Model(&model.OrganizationRole{}).Select("name").Where("organization_id = ?", orgID)
+		if err := tx.Unscoped().Where("organization_id = ? AND role NOT IN (?)", orgID, customRoles).
+			Delete(&model.PolicyRule{}).Error; err != nil {
+			return err
+		}
+		for i := range rules {
+			rules[i].OrganizationID = orgID
+		}
+		if len(rules) == 0 {
+			return nil
+		}
+		return tx.Create(&rules).Error
+	})
+	if err != nil {
+		log.Printf("Error replacing policy rules for organization ID %d: %v", orgID, err)
+		return nil, err
+	}
+	return rules, nil
+}
@@ -0,0 +1,326 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+	"gorm.io/gorm"
+)
+
+// ErrLastAdmin is returned when a change would leave the organization
+// without anyone holding the owner or admin role.
+var ErrLastAdmin = errors.New("organization needs at least one admin")
+
+// Member is a user together with their membership in an organization.
+type Member struct {
+	UserID   uint      `json:"user_id"`
+	Name     string    `json:"name"`
+	Email    string    `json:"email,omitempty"`
+	Role     string    `json:"role"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+// MemberFilter narrows and pages a member listing. An empty Role does not
+// filter.
+type MemberFilter struct {
+	Role     string
+	Page     int
+	PageSize int
+}
+
+// ErrOwnerRole is returned when a role change would add or remove the
+// organization's owner; ownership only moves through a transfer.
+var ErrOwnerRole = errors.New("ownership can only change through a transfer")
+
+type roleRepository struct {
+	db *gorm.DB
+}
+
+type RoleRepository interface {
+	GetMembership(ctx context.Context, userID, orgID uint) (*model.UserOrganization, error)
+	GetMembers(ctx context.Context, orgID uint, filter MemberFilter) ([]Member, int64, error)
+	RemoveMember(ctx context.Context, actorID, userID, orgID uint) error
+	SetOrganizationRole(ctx context.Context, actorID, userID, orgID uint, role string) (*model.RoleChange, error)
+	SetGlobalRole(ctx context.Context, actorID, userID uint, role string) (*model.RoleChange, error)
+	RecordRoleChange(ctx context.Context, change *model.RoleChange) error
+	GetRoleChangesByOrganization(ctx context.Context, orgID uint) ([]model.RoleChange, error)
+	GetGlobalRoleChanges(ctx context.Context) ([]model.RoleChange, error)
+	GetOrganizationRoles(ctx context.Context, orgID uint) ([]model.OrganizationRole, error)
+	GetOrganizationRoleByName(ctx context.Context, orgID uint, name string) (*model.OrganizationRole, error)
+	SaveOrganizationRole(ctx context.Context, role *model.OrganizationRole, rules []model.PolicyRule) (*model.OrganizationRole, error)
+	DeleteOrganizationRole(ctx context.Context, role *model.OrganizationRole) error
+	CountMembersWithRole(ctx context.Context, orgID uint, name string) (int64, error)
+}
+
+func NewRoleRepository(db *gorm.DB) RoleRepository {
+	return &roleRepository{db: db}
+}
+
+func (r *roleRepository) GetMembership(ctx context.Context, userID, orgID uint) (*model.UserOrganization, error) {
+	var membership model.UserOrganization
+	if err := conn(ctx, r.db).Where("user_id = ? AND organization_id = ?", userID, orgID).First(&membership).Error; err != nil {
+		return nil, err
+	}
+	return &membership, nil
+}
+
+// SetOrganizationRole updates a member's role inside an organization and
+// records the change in the same transaction. It never touches ownership.
+func (r *roleRepository) SetOrganizationRole(ctx context.Context, actorID, userID, orgID uint, role string) (*model.RoleChange, error) {
+	var change *model.RoleChange
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var membership model.UserOrganization
+		if err := tx.Where("user_id = ? AND organization_id = ?", userID, orgID).First(&membership).Error; err != nil {
+			return err
+		}
+		if membership.Role == model.RoleOwner || role == model.RoleOwner {
+			return ErrOwnerRole
+		}
+		if membership.Role == model.RoleAdmin && role != model.RoleAdmin {
+			if err := requireOtherAdmin(tx, userID, orgID); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&model.UserOrganization{}).
+			Where("user_id = ? AND organization_id = ?", userID, orgID).
+			Update("role", role).Error; err != nil {
+			return err
+		}
+
+		change = &model.RoleChange{
+			ActorID:        actorID,
+			UserID:         userID,
+			OrganizationID: &orgID,
+			OldRole:        membership.Role,
+			NewRole:        role,
+		}
+		return tx.Create(change).Error
+	})
+	if err != nil {
+		log.Printf("Error setting role of user %d in organization %d: %v", userID, orgID, err)
+		return nil, err
+	}
+	return change, nil
+}
+
+func (r *roleRepository) GetMembers(ctx context.Context, orgID uint, filter MemberFilter) ([]Member, int64, error) {
+	query := conn(ctx, r.db).Table("user_organizations").
+		Joins("JOIN users ON users.id = user_organizations.user_id AND users.deleted_at IS NULL").
+		Where("user_organizations.organization_id = ?", orgID)
+	if filter.Role != "" {
+		query = query.Where("user_organizations.role = ?", filter.Role)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		log.Printf("Error counting members of organization ID %d: %v", orgID, err)
+		return nil, 0, err
+	}
+
+	var members []Member
+	if err := query.Select("users.id AS user_id, users.name, users.email, user_organizations.role, user_organizations.created_at AS joined_at").
+		Order("user_organizations.created_at, users.id").
+		Offset((filter.Page - 1) * filter.PageSize).Limit(filter.PageSize).
+		Scan(&members).Error; err != nil {
+		log.Printf("Error fetching members of organization ID %d: %v", orgID, err)
+		return nil, 0, err
+	}
+	return members, total, nil
+}
+
+// RemoveMember ends a membership along with the member's team memberships,
+// personal grants on the organization's articles and pending ownership
+// offers. The owner cannot be removed, and neither can the last admin.
+func (r *roleRepository) RemoveMember(ctx context.Context, actorID, userID, orgID uint) error {
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var membership model.UserOrganization
+		if err := tx.Where("user_id = ? AND organization_id = ?", userID, orgID).First(&membership).Error; err != nil {
+			return err
+		}
+		if membership.Role == model.RoleOwner {
+			return ErrOwnerRole
+		}
+		if membership.Role == model.RoleAdmin {
+			if err := requireOtherAdmin(tx, userID, orgID); err != nil {
+				return err
+			}
+		}
+
+		teamIDs := tx.Model(&model.Team{}).Select("id").Where("organization_id = ?", orgID)
+		if err := tx.Exec("DELETE FROM team_members WHERE user_id = ? AND team_id IN (?)", userID, teamIDs).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("user_id = ? AND article_id IN (?)", userID, orgArticleIDs(tx, orgID)).
+			Delete(&model.ArticleCollaborator{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&model.OwnershipTransfer{}).
+			Where("organization_id = ? AND to_user_id = ? AND status = ?", orgID, userID, model.TransferPending).
+			Updates(map[string]interface{}{"status": model.TransferCancelled, "responded_at": time.Now()}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Unscoped().Where("user_id = ? AND organization_id = ?", userID, orgID).Delete(&model.UserOrganization{}).Error; err != nil {
+			return err
+		}
+		if err := releaseQuota(tx, orgID, QuotaMembers, 1); err != nil {
+			return err
+		}
+		return tx.Create(&model.RoleChange{
+			ActorID:        actorID,
+			UserID:         userID,
+			OrganizationID: &orgID,
+			OldRole:        membership.Role,
+		}).Error
+	})
+	if err != nil {
+		log.Printf("Error removing user %d from organization %d: %v", userID, orgID, err)
+		return err
+	}
+	return nil
+}
+
+// requireOtherAdmin fails with ErrLastAdmin unless someone other than userID
+// holds the owner or admin role in the organization.
+func requireOtherAdmin(tx *gorm.DB, userID, orgID uint) error {
+	var count int64
+	if err := tx.Model(&model.UserOrganization{}).
+		Where("organization_id = ? AND user_id <> ? AND role IN ?", orgID, userID, []string{model.RoleOwner, model.RoleAdmin}).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrLastAdmin
+	}
+	return nil
+}
+
+// SetGlobalRole updates a user's platform-wide role and records the change
+// in the same transaction.
+func (r *roleRepository) SetGlobalRole(ctx context.Context, actorID, userID uint, role string) (*model.RoleChange, error) {
+	var change *model.RoleChange
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var user model.User
+		if err := tx.First(&user, userID).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&user).Update("role", role).Error; err != nil {
+			return err
+		}
+
+		change = &model.RoleChange{
+			ActorID: actorID,
+			UserID:  userID,
+			OldRole: user.Role,
+			NewRole: role,
+		}
+		return tx.Create(change).Error
+	})
+	if err != nil {
+		log.Printf("Error setting global role of user %d: %v", userID, err)
+		return nil, err
+	}
+	return change, nil
+}
+
+func (r *roleRepository) RecordRoleChange(ctx context.Context, change *model.RoleChange) error {
+	if err := conn(ctx, r.db).Create(change).Error; err != nil {
+		log.Printf("Error recording role change for user %d: %v", change.UserID, err)
+		return err
+	}
+	return nil
+}
+
+func (r *roleRepository) GetRoleChangesByOrganization(ctx context.Context, orgID uint) ([]model.RoleChange, error) {
+	var changes []model.RoleChange
+	if err := conn(ctx, r.db).Where("organization_id = ?", orgID).Order("created_at DESC").Find(&changes).Error; err != nil {
+		log.Printf("Error fetching role changes for organization ID %d: %v", orgID, err)
+		return nil, err
+	}
+	return changes, nil
+}
+
+func (r *roleRepository) GetGlobalRoleChanges(ctx context.Context) ([]model.RoleChange, error) {
+	var changes []model.RoleChange
+	if err := conn(ctx, r.db).Where("organization_id IS NULL").Order("created_at DESC").Find(&changes).Error; err != nil {
+		log.Printf("Error fetching global role changes: %v", err)
+		return nil, err
+	}
+	return changes, nil
+}
+
+func (r *roleRepository) GetOrganizationRoles(ctx context.Context, orgID uint) ([]model.OrganizationRole, error) {
+	var roles []model.OrganizationRole
+	if err := conn(ctx, r.db).Where("organization_id = ?", orgID).Order("name").Find(&roles).Error; err != nil {
+		log.Printf("Error fetching roles for organization ID %d: %v", orgID, err)
+		return nil, err
+	}
+	return roles, nil
+}
+
+func (r *roleRepository) GetOrganizationRoleByName(ctx context.Context, orgID uint, name string) (*model.OrganizationRole, error) {
+	var role model.OrganizationRole
+	if err := conn(ctx, r.db).Where("organization_id = ? AND name = ?", orgID, name).First(&role).Error; err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+// SaveOrganizationRole creates or updates a custom role and replaces the
+// policy rules granted to it in the same transaction.
+func (r *roleRepository) SaveOrganizationRole(ctx context.Context, role *model.OrganizationRole, rules []model.PolicyRule) (*model.OrganizationRole, error) {
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(role).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Unscoped().Where("organization_id = ? AND role = ?", role.OrganizationID, role.Name).
+			Delete(&model.PolicyRule{}).Error; err != nil {
+			return err
+		}
+
+		for i := range rules {
+			rules[i].OrganizationID = role.OrganizationID
+			rules[i].Role = role.Name
+		}
+		if len(rules) == 0 {
+			return nil
+		}
+		return tx.Create(&rules).Error
+	})
+	if err != nil {
+		log.Printf("Error saving role %s for organization ID %d: %v", role.Name, role.OrganizationID, err)
+		return nil, err
+	}
+	return role, nil
+}
+
+func (r *roleRepository) DeleteOrganizationRole(ctx context.Context, role *model.OrganizationRole) error {
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("organization_id = ? AND role = ?", role.OrganizationID, role.Name).
+			Delete(&model.PolicyRule{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(role).Error
+	})
+	if err != nil {
+		log.Printf("Error deleting role %s for organization ID %d: %v", role.Name, role.OrganizationID, err)
+		return err
+	}
+	return nil
+}
+
+func (r *roleRepository) CountMembersWithRole(ctx context.Context, orgID uint, name string) (int64, error) {
+	var count int64
+	if err := conn(ctx, r.db).Model(&model.UserOrganization{}).
+		Where("organization_id = ? AND role = ?", orgID, name).Count(&count).Error; err != nil {
+		log.Printf("Error counting members with role %s in organization ID %d: %v", name, orgID, err)
+		return 0, err
+	}
+	return count, nil
+}
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"log"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+	"gorm.io/gorm"
+)
+
+type settingsRepository struct {
+	db *gorm.DB
+}
+
+type SettingsRepository interface {
+	GetSettings(ctx context.Context, orgID uint) (*model.OrganizationSettings, error)
+	SaveSettings(ctx context.Context, settings *model.OrganizationSettings) (*model.OrganizationSettings, error)
+}
+
+func NewSettingsRepository(db *gorm.DB) SettingsRepository {
+	return &settingsRepository{db: db}
+}
+
+// GetSettings returns the organization's stored settings, or nil when it has
+// not stored any yet.
+func (r *settingsRepository) GetSettings(ctx context.Context, orgID uint) (*model.OrganizationSettings, error) {
+	var settings []model.OrganizationSettings
+	if err := conn(ctx, r.db).Where("organization_id = ?", orgID).Limit(1).Find(&settings).Error; err != nil {
+		log.Printf("Error fetching settings for organization ID %d: %v", orgID, err)
+		return nil, err
+	}
+	if len(settings) == 0 {
+		return nil, nil
+	}
+	return &settings[0], nil
+}
+
+func (r *settingsRepository) SaveSettings(ctx context.Context, settings *model.OrganizationSettings) (*model.OrganizationSettings, error) {
+	if err := conn(ctx, r.db).Save(settings).Error; err != nil {
+		log.Printf("Error saving settings for organization ID %d: %v", settings.OrganizationID, err)
+		return nil, err
+	}
+	return settings, nil
+}
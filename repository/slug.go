@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/slug"
+	"gorm.io/gorm"
+)
+
+// ErrSlugTaken is returned when an explicitly requested slug is already used
+// by another organization, or another article of the same organization.
+var ErrSlugTaken = errors.New("slug is already taken")
+
+// slugInUse reports whether a live or soft-deleted row other than selfID
+// uses candidate. scope narrows the lookup, e.g. to one organization.
+func slugInUse(tx *gorm.DB, table interface{}, selfID uint, candidate string, scope func(*gorm.DB) *gorm.DB) (bool, error) {
+	var count int64
+	query := tx.Unscoped().Model(table).Where("slug = ? AND id <> ?", candidate, selfID)
+	if scope != nil {
+		query = scope(query)
+	}
+	if err := query.Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func redirectExists(tx *gorm.DB, resource string, orgID uint, candidate string) (bool, error) {
+	var count int64
+	if err := tx.Model(&model.SlugRedirect{}).
+		Where("resource = ? AND organization_id = ? AND slug = ?", resource, orgID, candidate).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// assignSlug picks the slug for a row being created or updated. A requested
+// slug must be free; a generated one gets a numeric suffix on collision and
+// also avoids slugs that still redirect somewhere else.
+func assignSlug(requested, source, fallback string, inUse func(string) (bool, error), redirected func(string) (bool, error)) (string, error) {
+	if requested != "" {
+		taken, err := inUse(requested)
+		if err != nil {
+			return "", err
+		}
+		if taken {
+			return "", ErrSlugTaken
+		}
+		return requested, nil
+	}
+
+	return slug.Unique(slug.Make(source, fallback), func(candidate string) (bool, error) {
+		if taken, err := inUse(candidate); err != nil || taken {
+			return taken, err
+		}
+		return redirected(candidate)
+	})
+}
+
+// recordSlugChange keeps oldSlug redirecting to the renamed row and drops a
+// redirect newSlug may have had, since the slug now belongs to the row.
+func recordSlugChange(tx *gorm.DB, resource string, orgID, targetID uint, oldSlug, newSlug string) error {
+	if err := tx.Where("resource = ? AND organization_id = ? AND slug = ?", resource, orgID, newSlug).
+		Delete(&model.SlugRedirect{}).Error; err != nil {
+		return err
+	}
+	if oldSlug == "" {
+		return nil
+	}
+
+	if err := tx.Where("resource = ? AND organization_id = ? AND slug = ?", resource, orgID, oldSlug).
+		Delete(&model.SlugRedirect{}).Error; err != nil {
+		return err
+	}
+	return tx.Create(&model.SlugRedirect{
+		Resource:       resource,
+		OrganizationID: orgID,
+		Slug:           oldSlug,
+		TargetID:       targetID,
+	}).Error
+}
+
+func redirectTarget(tx *gorm.DB, resource string, orgID uint, oldSlug string) (uint, error) {
+	var redirect model.SlugRedirect
+	if err := tx.Where("resource = ? AND organization_id = ? AND slug = ?", resource, orgID, oldSlug).
+		First(&redirect).Error; err != nil {
+		return 0, err
+	}
+	return redirect.TargetID, nil
+}
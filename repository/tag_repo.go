@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"context"
+	"log"
+	"sort"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+	"github.com/adityadeshlahre/multi-tenant-backend-app/pkg/slug"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type tagRepository struct {
+	db *gorm.DB
+}
+
+// TagCount is a tag together with how many live articles carry it.
+type TagCount struct {
+	model.Tag
+	ArticleCount int64 `json:"article_count"`
+}
+
+type TagRepository interface {
+	CreateTag(ctx context.Context, tag *model.Tag) (*model.Tag, error)
+	GetTagByID(ctx context.Context, id uint) (*model.Tag, error)
+	GetTagBySlug(ctx context.Context, orgID uint, slug string) (*model.Tag, error)
+	GetTagCounts(ctx context.Context, orgID uint, status string, publicOnly bool) ([]TagCount, error)
+	UpdateTag(ctx context.Context, tag *model.Tag) (*model.Tag, error)
+	DeleteTag(ctx context.Context, id uint) error
+}
+
+func NewTagRepository(db *gorm.DB) TagRepository {
+	return &tagRepository{db: db}
+}
+
+// CreateTag derives the tag's slug from its name. It fails with
+// ErrSlugTaken when the organization already has a tag by that slug.
+func (r *tagRepository) CreateTag(ctx context.Context, tag *model.Tag) (*model.Tag, error) {
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := r.assignSlug(tx, tag); err != nil {
+			return err
+		}
+		return tx.Create(tag).Error
+	})
+	if err != nil {
+		log.Printf("Error creating tag: %v", err)
+		return nil, err
+	}
+	return tag, nil
+}
+
+func (r *tagRepository) GetTagByID(ctx context.Context, id uint) (*model.Tag, error) {
+	var tag model.Tag
+	if err := conn(ctx, r.db).First(&tag, id).Error; err != nil {
+		log.Printf("Error fetching tag by ID %d: %v", id, err)
+		return nil, err
+	}
+	return &tag, nil
+}
+
+func (r *tagRepository) GetTagBySlug(ctx context.Context, orgID uint, slug string) (*model.Tag, error) {
+	var tag model.Tag
+	if err := conn(ctx, r.db).Where("organization_id = ? AND slug = ?", orgID, slug).First(&tag).Error; err != nil {
+		log.Printf("Error fetching tag by slug %s: %v", slug, err)
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// GetTagCounts returns the organization's tags by name with the number of
+// live articles carrying them, for tag clouds. With a status, only articles
+// in it are counted and tags without any are left out. publicOnly counts
+// published public articles only, whatever the status.
+func (r *tagRepository) GetTagCounts(ctx context.Context, orgID uint, status string, publicOnly bool) ([]TagCount, error) {
+	articles := "LEFT JOIN articles ON articles.id = article_tags.article_id AND articles.deleted_at IS NULL"
+	var args []interface{}
+	if publicOnly {
+		status = "published"
+		articles += " AND articles.visibility = ?"
+		args = append(args, model.VisibilityPublic)
+	}
+	if status != "" {
+		articles += " AND articles.status = ?"
+		args = append(args, status)
+	}
+	query := conn(ctx, r.db).Model(&model.Tag{}).Select("tags.*, COUNT(articles.id) AS article_count").
+		Joins("LEFT JOIN article_tags ON article_tags.tag_id = tags.id").
+		Joins(articles, args...).
+		Where("tags.organization_id = ?", orgID).
+		Group("tags.id").Order("tags.name")
+	if status != "" {
+		query = query.Having("COUNT(articles.id) > 0")
+	}
+
+	var counts []TagCount
+	if err := query.Scan(&counts).Error; err != nil {
+		log.Printf("Error counting tags of organization ID %d: %v", orgID, err)
+		return nil, err
+	}
+	return counts, nil
+}
+
+// UpdateTag saves a renamed tag under the slug of its new name.
+func (r *tagRepository) UpdateTag(ctx context.Context, tag *model.Tag) (*model.Tag, error) {
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := r.assignSlug(tx, tag); err != nil {
+			return err
+		}
+		return tx.Save(tag).Error
+	})
+	if err != nil {
+		log.Printf("Error updating tag ID %d: %v", tag.ID, err)
+		return nil, err
+	}
+	return tag, nil
+}
+
+// DeleteTag removes the tag from its articles and deletes it.
+func (r *tagRepository) DeleteTag(ctx context.Context, id uint) error {
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM article_tags WHERE tag_id = ?", id).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&model.Tag{}, id).Error
+	})
+	if err != nil {
+		log.Printf("Error deleting tag ID %d: %v", id, err)
+		return err
+	}
+	return nil
+}
+
+func (r *tagRepository) assignSlug(tx *gorm.DB, tag *model.Tag) error {
+	tag.Slug = slug.Make(tag.Name, "tag")
+	taken, err := slugInUse(tx, &model.Tag{}, tag.ID, tag.Slug, func(query *gorm.DB) *gorm.DB {
+		return query.Where("organization_id = ?", tag.OrganizationID)
+	})
+	if err != nil {
+		return err
+	}
+	if taken {
+		return ErrSlugTaken
+	}
+	return nil
+}
+
+// assignTags gives the article the tags named in article.Tags, creating
+// the ones its organization does not have yet. Tags whose names share a
+// slug are merged. When article.Tags is nil the article keeps its tags.
+func assignTags(tx *gorm.DB, article *model.Article) error {
+	if article.Tags == nil {
+		return nil
+	}
+
+	tags := make([]model.Tag, 0, len(article.Tags))
+	seen := make(map[string]bool)
+	for _, requested := range article.Tags {
+		tagSlug := slug.Make(requested.Name, "tag")
+		if seen[tagSlug] {
+			continue
+		}
+		seen[tagSlug] = true
+
+		tag := model.Tag{OrganizationID: article.OrganizationID, Name: requested.Name, Slug: tagSlug}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&tag).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("organization_id = ? AND slug = ?", article.OrganizationID, tagSlug).First(&tag).Error; err != nil {
+			return err
+		}
+		tags = append(tags, tag)
+	}
+
+	if err := tx.Exec("DELETE FROM article_tags WHERE article_id = ?", article.ID).Error; err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		if err := tx.Table("article_tags").Create(map[string]interface{}{"article_id": article.ID, "tag_id": tag.ID}).Error; err != nil {
+			return err
+		}
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+	article.Tags = tags
+	return nil
+}
+
+// untagArticles removes the tags of the selected articles, for when the
+// articles themselves are removed for good.
+func untagArticles(tx *gorm.DB, articleIDs *gorm.DB) error {
+	return tx.Exec("DELETE FROM article_tags WHERE article_id IN (?)", articleIDs).Error
+}
@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"log"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+	"gorm.io/gorm"
+)
+
+type teamRepository struct {
+	db *gorm.DB
+}
+
+type TeamRepository interface {
+	CreateTeam(ctx context.Context, team *model.Team) (*model.Team, error)
+	GetTeamByID(ctx context.Context, id uint) (*model.Team, error)
+	GetTeamsByOrganization(ctx context.Context, orgID uint) ([]model.Team, error)
+	GetTeamIDsForUser(ctx context.Context, orgID, userID uint) ([]uint, error)
+	UpdateTeam(ctx context.Context, team *model.Team) (*model.Team, error)
+	DeleteTeam(ctx context.Context, id uint) error
+	GetTeamMembers(ctx context.Context, teamID uint) ([]model.User, error)
+	IsTeamMember(ctx context.Context, teamID, userID uint) (bool, error)
+	AddTeamMember(ctx context.Context, teamID, userID uint) error
+	RemoveTeamMember(ctx context.Context, teamID, userID uint) error
+}
+
+func NewTeamRepository(db *gorm.DB) TeamRepository {
+	return &teamRepository{db: db}
+}
+
+func (r *teamRepository) CreateTeam(ctx context.Context, team *model.Team) (*model.Team, error) {
+	if err := conn(ctx, r.db).Create(team).Error; err != nil {
+		log.Printf("Error creating team: %v", err)
+		return nil, err
+	}
+	return team, nil
+}
+
+func (r *teamRepository) GetTeamByID(ctx context.Context, id uint) (*model.Team, error) {
+	var team model.Team
+	if err := conn(ctx, r.db).First(&team, id).Error; err != nil {
+		log.Printf("Error fetching team by ID %d: %v", id, err)
+		return nil, err
+	}
+	return &team, nil
+}
+
+func (r *teamRepository) GetTeamsByOrganization(ctx context.Context, orgID uint) ([]model.Team, error) {
+	var teams []model.Team
+	if err := conn(ctx, r.db).Where("organization_id = ?", orgID).Order("name").Find(&teams).Error; err != nil {
+		log.Printf("Error fetching teams by organization ID %d: %v", orgID, err)
+		return nil, err
+	}
+	return teams, nil
+}
+
+func (r *teamRepository) GetTeamIDsForUser(ctx context.Context, orgID, userID uint) ([]uint, error) {
+	var teamIDs []uint
+	if err := conn(ctx, r.db).Model(&model.Team{}).
+		Joins("JOIN team_members ON team_members.team_id = teams.id").
+		Where("teams.organization_id = ? AND team_members.user_id = ?", orgID, userID).
+		Pluck("teams.id", &teamIDs).Error; err != nil {
+		log.Printf("Error fetching teams of user %d in organization ID %d: %v", userID, orgID, err)
+		return nil, err
+	}
+	return teamIDs, nil
+}
+
+func (r *teamRepository) UpdateTeam(ctx context.Context, team *model.Team) (*model.Team, error) {
+	if err := conn(ctx, r.db).Omit("Members").Save(team).Error; err != nil {
+		log.Printf("Error updating team ID %d: %v", team.ID, err)
+		return nil, err
+	}
+	return team, nil
+}
+
+// DeleteTeam removes a team for good: its memberships and collaborator grants
+// are dropped and the articles it owned go back to being owned by their
+// authors alone.
+func (r *teamRepository) DeleteTeam(ctx context.Context, id uint) error {
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM team_members WHERE team_id = ?", id).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("team_id = ?", id).Delete(&model.ArticleCollaborator{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&model.Article{}).Where("team_id = ?", id).Update("team_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&model.Team{}, id).Error
+	})
+	if err != nil {
+		log.Printf("Error deleting team ID %d: %v", id, err)
+		return err
+	}
+	return nil
+}
+
+func (r *teamRepository) GetTeamMembers(ctx context.Context, teamID uint) ([]model.User, error) {
+	var users []model.User
+	if err := conn(ctx, r.db).Joins("JOIN team_members ON users.id = team_members.user_id").
+		Where("team_members.team_id = ?", teamID).Find(&users).Error; err != nil {
+		log.Printf("Error fetching members of team ID %d: %v", teamID, err)
+		return nil, err
+	}
+	return users, nil
+}
+
+func (r *teamRepository) IsTeamMember(ctx context.Context, teamID, userID uint) (bool, error) {
+	var count int64
+	if err := conn(ctx, r.db).Table("team_members").
+		Where("team_id = ? AND user_id = ?", teamID, userID).Count(&count).Error; err != nil {
+		log.Printf("Error checking membership of user %d in team ID %d: %v", userID, teamID, err)
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *teamRepository) AddTeamMember(ctx context.Context, teamID, userID uint) error {
+	if err := conn(ctx, r.db).Exec("INSERT INTO team_members (team_id, user_id) VALUES (?, ?) ON CONFLICT DO NOTHING", teamID, userID).Error; err != nil {
+		log.Printf("Error adding user %d to team ID %d: %v", userID, teamID, err)
+		return err
+	}
+	return nil
+}
+
+func (r *teamRepository) RemoveTeamMember(ctx context.Context, teamID, userID uint) error {
+	if err := conn(ctx, r.db).Exec("DELETE FROM team_members WHERE team_id = ? AND user_id = ?", teamID, userID).Error; err != nil {
+		log.Printf("Error removing user %d from team ID %d: %v", userID, teamID, err)
+		return err
+	}
+	return nil
+}
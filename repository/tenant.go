@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+	"gorm.io/gorm"
+)
+
+// TenantStorage tells maintenance work running outside a tenant request how
+// to reach an organization's tables. The zero value fits shared tables.
+type TenantStorage struct {
+	// Scope points tx at the organization's tables, e.g. its schema.
+	Scope func(tx *gorm.DB, org *model.Organization) error
+	// Teardown removes storage dedicated to the organization once it is
+	// purged.
+	Teardown func(tx *gorm.DB, org *model.Organization) error
+}
+
+func (s TenantStorage) scope(tx *gorm.DB, org *model.Organization) error {
+	if s.Scope == nil {
+		return nil
+	}
+	return s.Scope(tx, org)
+}
+
+func (s TenantStorage) teardown(tx *gorm.DB, org *model.Organization) error {
+	if s.Teardown == nil {
+		return nil
+	}
+	return s.Teardown(tx, org)
+}
+
+// eachTenant calls fn so that queries on tenant tables reach every tenant:
+// once when tables are shared, otherwise once per organization with tx
+// scoped to its storage.
+func (s TenantStorage) eachTenant(tx *gorm.DB, fn func(tx *gorm.DB) error) error {
+	if s.Scope == nil {
+		return fn(tx)
+	}
+
+	var orgs []model.Organization
+	if err := tx.Unscoped().Order("id").Find(&orgs).Error; err != nil {
+		return err
+	}
+	for i := range orgs {
+		if err := s.Scope(tx, &orgs[i]); err != nil {
+			return err
+		}
+		if err := fn(tx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
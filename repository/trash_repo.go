@@ -0,0 +1,315 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+	"gorm.io/gorm"
+)
+
+// ErrParentInTrash is returned when restoring a comment whose article is
+// itself still in the trash.
+var ErrParentInTrash = errors.New("parent is in the trash")
+
+// TrashPurge counts the rows a purge removed for good.
+type TrashPurge struct {
+	Organizations int64
+	Articles      int64
+	Comments      int64
+}
+
+type trashRepository struct {
+	db      *gorm.DB
+	storage TenantStorage
+}
+
+// TrashRepository exposes soft-deleted rows. Deleting an article or an
+// organization trashes everything below it with the same timestamp, and
+// restoring brings back exactly the rows that were trashed with it; rows
+// deleted on their own before stay in the trash.
+type TrashRepository interface {
+	GetTrashedArticles(ctx context.Context, orgID uint) ([]model.Article, error)
+	GetTrashedComments(ctx context.Context, orgID uint) ([]model.Comment, error)
+	RestoreArticle(ctx context.Context, orgID, id uint) (*model.Article, error)
+	RestoreComment(ctx context.Context, orgID, id uint) (*model.Comment, error)
+	GetTrashedOrganizations(ctx context.Context) ([]model.Organization, error)
+	RestoreOrganization(ctx context.Context, id uint) (*model.Organization, error)
+	PurgeExpired(ctx context.Context, cutoff time.Time) (*TrashPurge, error)
+}
+
+func NewTrashRepository(db *gorm.DB, storage TenantStorage) TrashRepository {
+	return &trashRepository{db: db, storage: storage}
+}
+
+func (r *trashRepository) GetTrashedArticles(ctx context.Context, orgID uint) ([]model.Article, error) {
+	var articles []model.Article
+	if err := conn(ctx, r.db).Unscoped().Preload("User").
+		Where("organization_id = ? AND deleted_at IS NOT NULL", orgID).
+		Order("deleted_at DESC").Find(&articles).Error; err != nil {
+		log.Printf("Error fetching trashed articles for organization ID %d: %v", orgID, err)
+		return nil, err
+	}
+	return articles, nil
+}
+
+func (r *trashRepository) GetTrashedComments(ctx context.Context, orgID uint) ([]model.Comment, error) {
+	var comments []model.Comment
+	if err := conn(ctx, r.db).Unscoped().Preload("Author").
+		Where("deleted_at IS NOT NULL AND article_id IN (?)", orgArticleIDs(conn(ctx, r.db), orgID)).
+		Order("deleted_at DESC").Find(&comments).Error; err != nil {
+		log.Printf("Error fetching trashed comments for organization ID %d: %v", orgID, err)
+		return nil, err
+	}
+	return comments, nil
+}
+
+func (r *trashRepository) RestoreArticle(ctx context.Context, orgID, id uint) (*model.Article, error) {
+	var article model.Article
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("organization_id = ? AND deleted_at IS NOT NULL", orgID).
+			First(&article, id).Error; err != nil {
+			return err
+		}
+		if err := consumeQuota(tx, orgID, QuotaArticles, 1); err != nil {
+			return err
+		}
+		return restoreArticle(tx, article.ID)
+	})
+	if err != nil {
+		log.Printf("Error restoring article ID %d: %v", id, err)
+		return nil, err
+	}
+	article.DeletedAt = gorm.DeletedAt{}
+	return &article, nil
+}
+
+func (r *trashRepository) RestoreComment(ctx context.Context, orgID, id uint) (*model.Comment, error) {
+	var comment model.Comment
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("deleted_at IS NOT NULL AND article_id IN (?)", orgArticleIDs(tx, orgID)).
+			First(&comment, id).Error; err != nil {
+			return err
+		}
+
+		var article model.Article
+		if err := tx.Unscoped().Select("id", "deleted_at").First(&article, comment.ArticleID).Error; err != nil {
+			return err
+		}
+		if article.DeletedAt.Valid {
+			return ErrParentInTrash
+		}
+
+		return tx.Unscoped().Model(&comment).Update("deleted_at", nil).Error
+	})
+	if err != nil {
+		log.Printf("Error restoring comment ID %d: %v", id, err)
+		return nil, err
+	}
+	comment.DeletedAt = gorm.DeletedAt{}
+	return &comment, nil
+}
+
+func (r *trashRepository) GetTrashedOrganizations(ctx context.Context) ([]model.Organization, error) {
+	var orgs []model.Organization
+	if err := conn(ctx, r.db).Unscoped().Where("deleted_at IS NOT NULL").
+		Order("deleted_at DESC").Find(&orgs).Error; err != nil {
+		log.Printf("Error fetching trashed organizations: %v", err)
+		return nil, err
+	}
+	return orgs, nil
+}
+
+// RestoreOrganization brings back the organization together with the
+// articles, comments and memberships that were trashed along with it. Its
+// subdomain and custom domains were released for other organizations when
+// it was deleted and are not restored; its owner sets them up again.
+func (r *trashRepository) RestoreOrganization(ctx context.Context, id uint) (*model.Organization, error) {
+	var org model.Organization
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("deleted_at IS NOT NULL").First(&org, id).Error; err != nil {
+			return err
+		}
+		if err := r.storage.scope(tx, &org); err != nil {
+			return err
+		}
+
+		trashedWithOrg := tx.Unscoped().Model(&model.Organization{}).Select("deleted_at").Where("id = ?", org.ID)
+		if err := tx.Unscoped().Model(&model.Comment{}).
+			Where("article_id IN (?) AND deleted_at = (?)", orgArticleIDs(tx, org.ID), trashedWithOrg).
+			Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&model.Article{}).
+			Where("organization_id = ? AND deleted_at = (?)", org.ID, trashedWithOrg).
+			Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&model.UserOrganization{}).
+			Where("organization_id = ? AND deleted_at = (?)", org.ID, trashedWithOrg).
+			Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&org).Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		return RecountUsage(tx, org.ID)
+	})
+	if err != nil {
+		log.Printf("Error restoring organization ID %d: %v", id, err)
+		return nil, err
+	}
+	org.DeletedAt = gorm.DeletedAt{}
+	return &org, nil
+}
+
+// PurgeExpired hard-deletes whatever has been in the trash since before
+// cutoff. Each organization is purged in its own transaction.
+func (r *trashRepository) PurgeExpired(ctx context.Context, cutoff time.Time) (*TrashPurge, error) {
+	var orgs []model.Organization
+	if err := conn(ctx, r.db).Unscoped().Find(&orgs).Error; err != nil {
+		log.Printf("Error fetching organizations to purge: %v", err)
+		return nil, err
+	}
+
+	purge := &TrashPurge{}
+	for i := range orgs {
+		org := &orgs[i]
+		err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+			if err := r.storage.scope(tx, org); err != nil {
+				return err
+			}
+			if org.DeletedAt.Valid && org.DeletedAt.Time.Before(cutoff) {
+				return r.purgeOrganization(tx, org, purge)
+			}
+			return purgeExpiredContent(tx, org.ID, cutoff, purge)
+		})
+		if err != nil {
+			log.Printf("Error purging trash of organization ID %d: %v", org.ID, err)
+			return purge, err
+		}
+	}
+	return purge, nil
+}
+
+func purgeExpiredContent(tx *gorm.DB, orgID uint, cutoff time.Time, purge *TrashPurge) error {
+	expiredArticles := tx.Unscoped().Model(&model.Article{}).Select("id").
+		Where("organization_id = ? AND deleted_at < ?", orgID, cutoff)
+
+	if err := tx.Unscoped().Where("article_id IN (?)", expiredArticles).
+		Delete(&model.ArticleCollaborator{}).Error; err != nil {
+		return err
+	}
+	if err := discardAttachments(tx, orgID, expiredArticles); err != nil {
+		return err
+	}
+	if err := untagArticles(tx, expiredArticles); err != nil {
+		return err
+	}
+
+	result := tx.Unscoped().
+		Where("article_id IN (?) AND (deleted_at < ? OR article_id IN (?))", orgArticleIDs(tx, orgID), cutoff, expiredArticles).
+		Delete(&model.Comment{})
+	if result.Error != nil {
+		return result.Error
+	}
+	purge.Comments += result.RowsAffected
+
+	result = tx.Unscoped().Where("organization_id = ? AND deleted_at < ?", orgID, cutoff).Delete(&model.Article{})
+	if result.Error != nil {
+		return result.Error
+	}
+	purge.Articles += result.RowsAffected
+	return nil
+}
+
+// purgeOrganization removes the organization and everything that belongs to
+// it, including memberships and tenant configuration.
+func (r *trashRepository) purgeOrganization(tx *gorm.DB, org *model.Organization, purge *TrashPurge) error {
+	articleIDs := orgArticleIDs(tx, org.ID)
+
+	if err := tx.Unscoped().Where("article_id IN (?)", articleIDs).Delete(&model.ArticleCollaborator{}).Error; err != nil {
+		return err
+	}
+	if err := discardAttachments(tx, org.ID, articleIDs); err != nil {
+		return err
+	}
+	if err := untagArticles(tx, articleIDs); err != nil {
+		return err
+	}
+	result := tx.Unscoped().Where("article_id IN (?)", articleIDs).Delete(&model.Comment{})
+	if result.Error != nil {
+		return result.Error
+	}
+	purge.Comments += result.RowsAffected
+
+	result = tx.Unscoped().Where("organization_id = ?", org.ID).Delete(&model.Article{})
+	if result.Error != nil {
+		return result.Error
+	}
+	purge.Articles += result.RowsAffected
+
+	teamIDs := tx.Unscoped().Model(&model.Team{}).Select("id").Where("organization_id = ?", org.ID)
+	if err := tx.Exec("DELETE FROM team_members WHERE team_id IN (?)", teamIDs).Error; err != nil {
+		return err
+	}
+
+	orgScoped := []interface{}{&model.Team{}, &model.Tag{}, &model.Category{}, &model.UserOrganization{}, &model.PolicyRule{}, &model.OrganizationRole{}, &model.OrganizationDomain{}, &model.RoleChange{}, &model.OrganizationUsage{}, &model.OrganizationSettings{}, &model.OrganizationExport{}}
+	for _, table := range orgScoped {
+		if err := tx.Unscoped().Where("organization_id = ?", org.ID).Delete(table).Error; err != nil {
+			return err
+		}
+	}
+	if err := tx.Where("organization_id = ? OR (resource = ? AND target_id = ?)", org.ID, model.SlugResourceOrganization, org.ID).
+		Delete(&model.SlugRedirect{}).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Unscoped().Delete(org).Error; err != nil {
+		return err
+	}
+	purge.Organizations++
+
+	return r.storage.teardown(tx, org)
+}
+
+// orgArticleIDs selects the IDs of all articles of the organization,
+// including trashed ones.
+func orgArticleIDs(tx *gorm.DB, orgID uint) *gorm.DB {
+	return tx.Unscoped().Model(&model.Article{}).Select("id").Where("organization_id = ?", orgID)
+}
+
+// trashArticle soft-deletes the article and its live comments with one
+// timestamp.
+func trashArticle(tx *gorm.DB, id uint, now time.Time) error {
+	if err := tx.Model(&model.Comment{}).Where("article_id = ?", id).Update("deleted_at", now).Error; err != nil {
+		return err
+	}
+	return tx.Model(&model.Article{}).Where("id = ?", id).Update("deleted_at", now).Error
+}
+
+// trashOrganization soft-deletes the organization, its live articles and
+// their live comments with one timestamp.
+func trashOrganization(tx *gorm.DB, id uint, now time.Time) error {
+	liveArticles := tx.Model(&model.Article{}).Select("id").Where("organization_id = ?", id)
+	if err := tx.Model(&model.Comment{}).Where("article_id IN (?)", liveArticles).Update("deleted_at", now).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&model.Article{}).Where("organization_id = ?", id).Update("deleted_at", now).Error; err != nil {
+		return err
+	}
+	return tx.Model(&model.Organization{}).Where("id = ?", id).Update("deleted_at", now).Error
+}
+
+// restoreArticle restores the article and the comments trashed with it.
+func restoreArticle(tx *gorm.DB, id uint) error {
+	trashedWithArticle := tx.Unscoped().Model(&model.Article{}).Select("deleted_at").Where("id = ?", id)
+	if err := tx.Unscoped().Model(&model.Comment{}).
+		Where("article_id = ? AND deleted_at = (?)", id, trashedWithArticle).
+		Update("deleted_at", nil).Error; err != nil {
+		return err
+	}
+	return tx.Unscoped().Model(&model.Article{}).Where("id = ?", id).Update("deleted_at", nil).Error
+}
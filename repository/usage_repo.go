@@ -0,0 +1,208 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	QuotaMembers     = "members"
+	QuotaArticles    = "articles"
+	QuotaStorage     = "storage"
+	QuotaAPIRequests = "api_requests"
+)
+
+// quotaColumns maps a quota to its counter in organization_usages and the
+// plan limit the counter is checked against.
+var quotaColumns = map[string]struct{ usage, limit string }{
+	QuotaMembers:     {"members", "max_members"},
+	QuotaArticles:    {"articles", "max_articles"},
+	QuotaStorage:     {"storage_bytes", "max_storage_bytes"},
+	QuotaAPIRequests: {"api_requests", "max_api_requests_per_month"},
+}
+
+// QuotaExceededError is returned when an operation would take an
+// organization past a limit of its plan.
+type QuotaExceededError struct {
+	Quota string
+	Limit int64
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("plan limit of %d %s reached", e.Limit, e.Quota)
+}
+
+type usageRepository struct {
+	db *gorm.DB
+}
+
+// UsageRepository manages plans and reads the usage counters that the other
+// repositories keep up to date as members, articles and files come and go.
+type UsageRepository interface {
+	GetPlans(ctx context.Context) ([]model.Plan, error)
+	GetPlanByName(ctx context.Context, name string) (*model.Plan, error)
+	SetOrganizationPlan(ctx context.Context, orgID, planID uint) error
+	GetUsage(ctx context.Context, orgID uint, now time.Time) (*model.Plan, *model.OrganizationUsage, error)
+	RecordAPIRequest(ctx context.Context, orgID uint, now time.Time) error
+}
+
+func NewUsageRepository(db *gorm.DB) UsageRepository {
+	return &usageRepository{db: db}
+}
+
+func (r *usageRepository) GetPlans(ctx context.Context) ([]model.Plan, error) {
+	var plans []model.Plan
+	if err := conn(ctx, r.db).Order("id").Find(&plans).Error; err != nil {
+		log.Printf("Error fetching plans: %v", err)
+		return nil, err
+	}
+	return plans, nil
+}
+
+func (r *usageRepository) GetPlanByName(ctx context.Context, name string) (*model.Plan, error) {
+	var plan model.Plan
+	if err := conn(ctx, r.db).Where("name = ?", name).First(&plan).Error; err != nil {
+		log.Printf("Error fetching plan %s: %v", name, err)
+		return nil, err
+	}
+	return &plan, nil
+}
+
+// SetOrganizationPlan moves the organization to another plan. Usage above
+// the new limits is kept, but nothing more can be added until it drops
+// below them.
+func (r *usageRepository) SetOrganizationPlan(ctx context.Context, orgID, planID uint) error {
+	result := conn(ctx, r.db).Model(&model.Organization{}).Where("id = ?", orgID).Update("plan_id", planID)
+	if result.Error != nil {
+		log.Printf("Error setting plan of organization ID %d: %v", orgID, result.Error)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetUsage returns the organization's plan, nil when it has none and is
+// therefore unlimited, and its usage as of now.
+func (r *usageRepository) GetUsage(ctx context.Context, orgID uint, now time.Time) (*model.Plan, *model.OrganizationUsage, error) {
+	db := conn(ctx, r.db)
+
+	var org model.Organization
+	if err := db.Preload("Plan").Select("id", "plan_id").First(&org, orgID).Error; err != nil {
+		log.Printf("Error fetching plan of organization ID %d: %v", orgID, err)
+		return nil, nil, err
+	}
+
+	usage := model.OrganizationUsage{OrganizationID: orgID}
+	if err := db.Where("organization_id = ?", orgID).Limit(1).Find(&usage).Error; err != nil {
+		log.Printf("Error fetching usage of organization ID %d: %v", orgID, err)
+		return nil, nil, err
+	}
+	if period := monthStart(now); usage.PeriodStart.Before(period) {
+		usage.APIRequests = 0
+		usage.PeriodStart = period
+	}
+	return org.Plan, &usage, nil
+}
+
+// RecordAPIRequest counts one API request against the current month,
+// starting a new count when the month has turned.
+func (r *usageRepository) RecordAPIRequest(ctx context.Context, orgID uint, now time.Time) error {
+	db := conn(ctx, r.db)
+	period := monthStart(now)
+	if err := ensureUsage(db, orgID, period); err != nil {
+		log.Printf("Error recording API request of organization ID %d: %v", orgID, err)
+		return err
+	}
+
+	result := db.Exec(`UPDATE organization_usages SET
+			api_requests = CASE WHEN organization_usages.period_start < ? THEN 1 ELSE organization_usages.api_requests + 1 END,
+			period_start = GREATEST(organization_usages.period_start, ?)
+		FROM organizations LEFT JOIN plans ON plans.id = organizations.plan_id
+		WHERE organizations.id = organization_usages.organization_id AND organization_usages.organization_id = ?
+			AND (plans.id IS NULL OR plans.max_api_requests_per_month = 0
+				OR organization_usages.period_start < ? OR organization_usages.api_requests < plans.max_api_requests_per_month)`,
+		period, period, orgID, period)
+	if result.Error != nil {
+		log.Printf("Error recording API request of organization ID %d: %v", orgID, result.Error)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return quotaExceeded(db, orgID, QuotaAPIRequests)
+	}
+	return nil
+}
+
+// RecountUsage recomputes the member, article and storage counters of the
+// organization from its rows. Articles are counted on tx's search_path, so
+// in schema-per-tenant mode tx has to be scoped to the tenant's schema.
+func RecountUsage(tx *gorm.DB, orgID uint) error {
+	if err := ensureUsage(tx, orgID, monthStart(time.Now())); err != nil {
+		return err
+	}
+	return tx.Exec(`UPDATE organization_usages SET
+			members = (SELECT count(*) FROM user_organizations WHERE organization_id = ? AND deleted_at IS NULL),
+			articles = (SELECT count(*) FROM articles WHERE organization_id = ? AND deleted_at IS NULL),
+			storage_bytes = (SELECT COALESCE(SUM(size), 0) FROM attachments WHERE organization_id = ? AND deleted_at IS NULL),
+			updated_at = ?
+		WHERE organization_id = ?`, orgID, orgID, orgID, time.Now(), orgID).Error
+}
+
+// consumeQuota adds amount to the quota's counter, failing with a
+// *QuotaExceededError instead when that would go past the plan's limit.
+// The check and the update are one statement, so concurrent requests cannot
+// both squeeze in under the limit.
+func consumeQuota(tx *gorm.DB, orgID uint, quota string, amount int64) error {
+	columns := quotaColumns[quota]
+	if err := ensureUsage(tx, orgID, monthStart(time.Now())); err != nil {
+		return err
+	}
+
+	result := tx.Exec(fmt.Sprintf(`UPDATE organization_usages SET %[1]s = organization_usages.%[1]s + ?, updated_at = ?
+		FROM organizations LEFT JOIN plans ON plans.id = organizations.plan_id
+		WHERE organizations.id = organization_usages.organization_id AND organization_usages.organization_id = ?
+			AND (plans.id IS NULL OR plans.%[2]s = 0 OR organization_usages.%[1]s + ? <= plans.%[2]s)`, columns.usage, columns.limit),
+		amount, time.Now(), orgID, amount)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return quotaExceeded(tx, orgID, quota)
+	}
+	return nil
+}
+
+// releaseQuota gives back amount of the quota, never going below zero.
+func releaseQuota(tx *gorm.DB, orgID uint, quota string, amount int64) error {
+	column := quotaColumns[quota].usage
+	return tx.Exec(fmt.Sprintf(`UPDATE organization_usages SET %[1]s = GREATEST(%[1]s - ?, 0), updated_at = ?
+		WHERE organization_id = ?`, column), amount, time.Now(), orgID).Error
+}
+
+func quotaExceeded(tx *gorm.DB, orgID uint, quota string) error {
+	var limit int64
+	if err := tx.Table("organizations").
+		Joins("JOIN plans ON plans.id = organizations.plan_id").
+		Where("organizations.id = ?", orgID).
+		Select("plans." + quotaColumns[quota].limit).Scan(&limit).Error; err != nil {
+		return err
+	}
+	return &QuotaExceededError{Quota: quota, Limit: limit}
+}
+
+func ensureUsage(tx *gorm.DB, orgID uint, period time.Time) error {
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&model.OrganizationUsage{OrganizationID: orgID, PeriodStart: period}).Error
+}
+
+func monthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
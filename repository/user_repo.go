@@ -6,6 +6,7 @@ import (
 
 	"github.com/adityadeshlahre/multi-tenant-backend-app/model"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type userRepository struct {
@@ -28,7 +29,7 @@ func NewUserRepository(db *gorm.DB) UserRepository {
 }
 
 func (r *userRepository) CreateUser(ctx context.Context, user *model.User) (*model.User, error) {
-	if err := r.db.WithContext(ctx).Create(user).Error; err != nil {
+	if err := conn(ctx, r.db).Create(user).Error; err != nil {
 		log.Printf("Error creating user: %v", err)
 		return nil, err
 	}
@@ -37,7 +38,7 @@ func (r *userRepository) CreateUser(ctx context.Context, user *model.User) (*mod
 
 func (r *userRepository) GetUserByID(ctx context.Context, id uint) (*model.User, error) {
 	var user model.User
-	if err := r.db.WithContext(ctx).Preload("Organizations").First(&user, id).Error; err != nil {
+	if err := conn(ctx, r.db).Preload("Organizations").First(&user, id).Error; err != nil {
 		log.Printf("Error fetching user by ID %d: %v", id, err)
 		return nil, err
 	}
@@ -46,7 +47,7 @@ func (r *userRepository) GetUserByID(ctx context.Context, id uint) (*model.User,
 
 func (r *userRepository) GetUserByEmail(ctx context.Context, email string) (*model.User, error) {
 	var user model.User
-	if err := r.db.WithContext(ctx).Preload("Organizations").Where("email = ?", email).First(&user).Error; err != nil {
+	if err := conn(ctx, r.db).Preload("Organizations").Where("email = ?", email).First(&user).Error; err != nil {
 		log.Printf("Error fetching user by email %s: %v", email, err)
 		return nil, err
 	}
@@ -54,7 +55,7 @@ func (r *userRepository) GetUserByEmail(ctx context.Context, email string) (*mod
 }
 
 func (r *userRepository) UpdateUser(ctx context.Context, user *model.User) (*model.User, error) {
-	if err := r.db.WithContext(ctx).Save(user).Error; err != nil {
+	if err := conn(ctx, r.db).Save(user).Error; err != nil {
 		log.Printf("Error updating user ID %d: %v", user.ID, err)
 		return nil, err
 	}
@@ -62,7 +63,7 @@ func (r *userRepository) UpdateUser(ctx context.Context, user *model.User) (*mod
 }
 
 func (r *userRepository) DeleteUser(ctx context.Context, id uint) error {
-	if err := r.db.WithContext(ctx).Delete(&model.User{}, id).Error; err != nil {
+	if err := conn(ctx, r.db).Delete(&model.User{}, id).Error; err != nil {
 		log.Printf("Error deleting user ID %d: %v", id, err)
 		return err
 	}
@@ -71,7 +72,7 @@ func (r *userRepository) DeleteUser(ctx context.Context, id uint) error {
 
 func (r *userRepository) GetAllUsers(ctx context.Context) ([]model.User, error) {
 	var users []model.User
-	if err := r.db.WithContext(ctx).Preload("Organizations").Find(&users).Error; err != nil {
+	if err := conn(ctx, r.db).Preload("Organizations").Find(&users).Error; err != nil {
 		log.Printf("Error fetching all users: %v", err)
 		return nil, err
 	}
@@ -80,7 +81,7 @@ func (r *userRepository) GetAllUsers(ctx context.Context) ([]model.User, error)
 
 func (r *userRepository) GetUsersByOrganization(ctx context.Context, orgID uint) ([]model.User, error) {
 	var users []model.User
-	if err := r.db.WithContext(ctx).Joins("JOIN user_organizations ON users.id = user_organizations.user_id").
+	if err := conn(ctx, r.db).Joins("JOIN user_organizations ON users.id = user_organizations.user_id").
 		Where("user_organizations.organization_id = ?", orgID).Find(&users).Error; err != nil {
 		log.Printf("Error fetching users by organization ID %d: %v", orgID, err)
 		return nil, err
@@ -88,16 +89,22 @@ func (r *userRepository) GetUsersByOrganization(ctx context.Context, orgID uint)
 	return users, nil
 }
 
+// AddUserToOrganization makes the user a member of the organization, which
+// counts against its member quota. Adding an existing member does nothing.
 func (r *userRepository) AddUserToOrganization(ctx context.Context, userID, orgID uint) error {
-	var user model.User
-	if err := r.db.WithContext(ctx).First(&user, userID).Error; err != nil {
-		return err
-	}
-
-	var org model.Organization
-	if err := r.db.WithContext(ctx).First(&org, orgID).Error; err != nil {
-		return err
-	}
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&model.User{}, userID).Error; err != nil {
+			return err
+		}
+		if err := tx.First(&model.Organization{}, orgID).Error; err != nil {
+			return err
+		}
 
-	return r.db.WithContext(ctx).Model(&user).Association("Organizations").Append(&org)
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&model.UserOrganization{UserID: userID, OrganizationID: orgID, Role: model.RoleMember})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return consumeQuota(tx, orgID, QuotaMembers, 1)
+	})
 }